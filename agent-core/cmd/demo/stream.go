@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/biome/agent-core/packages/agent/core"
@@ -44,9 +45,14 @@ func RunExample(agent *core.Agent, userText string) {
 			} else {
 				fmt.Printf("%s  [step %d/%d done: %v]\n%s", ansiCyan, p.Index+1, p.StepCount, p.Result, ansiReset)
 			}
+		case core.EventToolCallDelta:
+			p := event.Payload.(core.ToolCallDeltaPayload)
+			if p.ToolName != "" {
+				fmt.Printf("\r\033[K%s  [calling %s(%s)…]%s", ansiCyan, p.ToolName, formatArgs(p.Args), ansiReset)
+			}
 		case core.EventToolCall:
 			p := event.Payload.(core.ToolCallPayload)
-			fmt.Printf("%s  [tool: %s]\n%s", ansiCyan, p.ToolName, ansiReset)
+			fmt.Printf("\r\033[K%s  [tool: %s]\n%s", ansiCyan, p.ToolName, ansiReset)
 		case core.EventToolResult:
 			p := event.Payload.(core.ToolResultPayload)
 			if m, ok := p.Result.(map[string]interface{}); ok {
@@ -64,11 +70,34 @@ func RunExample(agent *core.Agent, userText string) {
 				}
 			}
 			fmt.Printf("%s  [result: %v]\n%s", ansiCyan, p.Result, ansiReset)
+		case core.EventThinkingDelta:
+			p := event.Payload.(core.TextDeltaPayload)
+			fmt.Printf("%s%s%s", ansiCyan, p.Text, ansiReset)
 		case core.EventTextDelta:
 			p := event.Payload.(core.TextDeltaPayload)
 			fmt.Print(p.Text)
+		case core.EventTurnEnd:
+			p := event.Payload.(core.TurnEndPayload)
+			if p.Usage.TotalTokens > 0 {
+				fmt.Printf("\n%s  [tokens: %d in, %d out, %d cached; cost $%.4f]%s", ansiCyan,
+					p.Usage.Input, p.Usage.Output, p.Usage.CacheRead, p.Usage.Cost.Total, ansiReset)
+			}
 		}
 	}
 
 	fmt.Println()
 }
+
+// formatArgs renders tool arguments as key=value pairs in key order (for live tool-call display).
+func formatArgs(args map[string]interface{}) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, args[k]))
+	}
+	return strings.Join(parts, ", ")
+}
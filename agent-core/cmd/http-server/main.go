@@ -5,10 +5,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	_ "github.com/biome/agent-core/packages/agent/orchestrators/agentic"
 	"github.com/biome/agent-core/pkg/httpapi"
 	"github.com/biome/agent-mind/openrouter"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
 )
 
 func main() {
@@ -17,14 +20,15 @@ func main() {
 
 	llmModel := "anthropic/claude-3-haiku"
 
-	// Get API key (optional)
+	// Get API key (optional). Without one, a scripted fake provider echoes the user for offline demos.
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	var llmProvider *openrouter.Provider
+	var llmProvider provider.Provider
 	if apiKey != "" {
 		llmProvider = openrouter.NewProvider(apiKey, llmModel)
 		fmt.Printf("✅ LLM Provider: %s\n", llmModel)
 	} else {
-		fmt.Println("⚠️  No OPENROUTER_API_KEY - using mock mode")
+		llmProvider = mockProvider()
+		fmt.Println("⚠️  No OPENROUTER_API_KEY - using mock mode (fake provider)")
 	}
 
 	// Tools are supplied per request in POST /agent/prompt via the "tools" array (tool configs).
@@ -36,6 +40,7 @@ func main() {
 	http.HandleFunc("/agent/prompt", apiServer.CORSMiddleware(apiServer.PromptHandler))
 	http.HandleFunc("/tools/register", apiServer.CORSMiddleware(apiServer.RegisterToolHandler))
 	http.HandleFunc("/tools", apiServer.CORSMiddleware(apiServer.ListToolsHandler))
+	http.HandleFunc("/models", apiServer.CORSMiddleware(apiServer.ListModelsHandler))
 	http.HandleFunc("/health", apiServer.CORSMiddleware(apiServer.HealthHandler))
 
 	// Start server
@@ -45,6 +50,7 @@ func main() {
 	fmt.Println("  POST   http://localhost:8080/agent/prompt")
 	fmt.Println("  POST   http://localhost:8080/tools/register")
 	fmt.Println("  GET    http://localhost:8080/tools")
+	fmt.Println("  GET    http://localhost:8080/models")
 	fmt.Println("  GET    http://localhost:8080/health")
 	fmt.Println("\nExample (with optional tools in request):")
 	fmt.Println("  curl -X POST http://localhost:8080/agent/prompt \\")
@@ -56,3 +62,12 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// mockProvider returns a fake provider that answers every request by echoing the last user message.
+func mockProvider() provider.Provider {
+	return fake.New().WithName("mock").WithFallback(func(req provider.CompletionRequest) fake.Response {
+		r := fake.Text("(mock) You said: " + fake.LastUserText(req))
+		r.ChunkDelay = 20 * time.Millisecond
+		return r
+	})
+}
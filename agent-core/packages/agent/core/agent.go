@@ -3,8 +3,12 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/biome/agent-core/packages/agent/session"
 	"github.com/biome/agent-core/packages/agent/tools"
 	"github.com/biome/agent-core/packages/agent/transform"
 	"github.com/biome/agent-core/packages/agent/types"
@@ -32,12 +36,79 @@ type AgentConfig struct {
 	SteeringInstruction string
 	// Orchestrator drives the turn loop. Nil = default agentic loop (steering + tools + respond). Set to use another arrangement (e.g. ReAct, plan-execute).
 	Orchestrator Orchestrator
+	// Prices is used to compute AssistantMessage.Usage.Cost. Nil = provider.DefaultPrices().
+	Prices provider.Pricer
+	// ToolChoice constrains tool calling on the first LLM call of each turn (e.g. provider.RequireTools() or provider.ForceTool("search")); calls after tool results use auto so the turn can finish. Nil = provider default. Override per call with WithToolChoice.
+	ToolChoice *provider.ToolChoice
+	// Compact shrinks the history when the provider reports that it no longer fits the context window (provider.ErrContextLength); orchestrators then retry the call once. Nil = transform.CompactHistory().
+	Compact transform.TransformFunc
+	// OnBusy says what Prompt does while another turn is in progress. Default RejectWhenBusy.
+	OnBusy BusyPolicy
+	// Budget limits the steps, tool calls, tokens, cost and time of each turn and stops repeated
+	// identical tool calls. Zero value = unlimited.
+	Budget TurnBudget
 }
 
-// Agent manages conversation state and tool execution.
+// BusyPolicy says what Prompt does when it is called while a turn is in progress.
+type BusyPolicy int
+
+const (
+	// RejectWhenBusy ends the new prompt's stream with ErrBusy.
+	RejectWhenBusy BusyPolicy = iota
+	// QueueWhenBusy runs the new prompt once the turns started or queued before it have ended.
+	QueueWhenBusy
+)
+
+// ErrBusy is returned (by ending the stream) when Prompt is called during a turn under RejectWhenBusy,
+// and by Reset during a turn.
+var ErrBusy = errors.New("agent: a turn is already in progress")
+
+// PromptOption customizes a single Prompt call.
+type PromptOption func(*promptOptions)
+
+type promptOptions struct {
+	toolChoice    *provider.ToolChoice
+	toolChoiceSet bool
+}
+
+// WithToolChoice overrides AgentConfig.ToolChoice for one Prompt call (nil = provider default).
+func WithToolChoice(choice *provider.ToolChoice) PromptOption {
+	return func(o *promptOptions) {
+		o.toolChoice = choice
+		o.toolChoiceSet = true
+	}
+}
+
+// Agent manages conversation state and tool execution. It is safe for concurrent use: one turn runs at
+// a time, and readers get snapshots of the state.
 type Agent struct {
 	config AgentConfig
-	state  *types.AgentState
+
+	// sessionMu serialises session I/O so writes reach the store in history order; it is taken before
+	// mu, and mu is not held during the I/O.
+	sessionMu sync.Mutex
+
+	mu    sync.Mutex // guards the fields below
+	state *types.AgentState
+	// prompt holds the options of the Prompt call in progress.
+	prompt promptOptions
+	// turn is the Prompt call in progress (nil when idle); queued holds calls waiting under QueueWhenBusy.
+	turn   *turn
+	queued []*turn
+	// store and sessionID are the attached session (see LoadSession); nil when none.
+	store     session.Store
+	sessionID string
+}
+
+// turn is one Prompt or Continue call
+type turn struct {
+	ctx         context.Context
+	userMessage types.UserMessage
+	resume      bool // Continue: no user message
+	opts        promptOptions
+	eventStream *stream.EventStream[AgentEvent, []types.AgentMessage]
+	cancel      context.CancelFunc
+	aborted     bool
 }
 
 // newAgentState creates initial state from config.
@@ -69,46 +140,320 @@ func (a *Agent) Config() AgentConfig {
 // SteeringDecision asks the LLM for a steering decision (respond or steer with tool calls).
 // Orchestrators call this with the current agent state; isFollowUp is true for iterations after the first in a turn.
 func (a *Agent) SteeringDecision(ctx context.Context, isFollowUp bool) (SteeringDecision, error) {
+	return a.StreamSteeringDecision(ctx, isFollowUp, nil)
+}
+
+// StreamSteeringDecision is SteeringDecision with streaming: the LLM is called via Provider.Stream and
+// text_delta / tool_call_delta events are passed to emit as they arrive (emit may be nil).
+func (a *Agent) StreamSteeringDecision(ctx context.Context, isFollowUp bool, emit func(AgentEvent)) (SteeringDecision, error) {
+	return a.StreamSteeringDecisionWith(ctx, isFollowUp, a.ToolChoice(isFollowUp), emit)
+}
+
+// StreamSteeringDecisionWith is StreamSteeringDecision with an explicit tool choice for this call
+// (nil = provider default), for orchestrators that force or forbid tools at specific steps.
+func (a *Agent) StreamSteeringDecisionWith(ctx context.Context, isFollowUp bool, toolChoice *provider.ToolChoice, emit func(AgentEvent)) (SteeringDecision, error) {
 	if a.config.Provider == nil {
 		return SteeringDecision{Mode: SteeringModeRespond, Response: ""}, nil
 	}
-	snapshot := a.state.ToContext().Clone()
-	return makeSteeringDecision(ctx, a.config.Provider, snapshot, a.config.Pipeline, a.config.Tools, isFollowUp, a.config.SteeringInstruction)
+	snapshot := a.Context()
+	return makeSteeringDecision(ctx, a.config.Provider, snapshot, a.config.Pipeline, a.config.Tools, isFollowUp, a.config.SteeringInstruction, toolChoice, emit)
+}
+
+// ToolChoice returns the tool choice for an LLM call in the current turn: the Prompt override or
+// AgentConfig.ToolChoice for the first call, nil (auto) for follow-ups after tool results.
+func (a *Agent) ToolChoice(isFollowUp bool) *provider.ToolChoice {
+	if isFollowUp {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.prompt.toolChoiceSet {
+		return a.prompt.toolChoice
+	}
+	return a.config.ToolChoice
+}
+
+// CompactHistory replaces the history with its compacted form (AgentConfig.Compact), for orchestrators
+// recovering from provider.ErrContextLength. After < Before in the result when messages were removed.
+func (a *Agent) CompactHistory(ctx context.Context) (ContextCompactedPayload, error) {
+	compact := a.config.Compact
+	if compact == nil {
+		compact = transform.CompactHistory()
+	}
+	messages := a.Messages()
+	before := len(messages)
+	compacted, err := compact(ctx, messages)
+	if err != nil {
+		return ContextCompactedPayload{Before: before, After: before}, fmt.Errorf("compact history: %w", err)
+	}
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	a.mu.Lock()
+	after := len(compacted)
+	for i, m := range compacted {
+		compacted[i] = types.Stamp(m)
+	}
+	// Keep anything appended while the transform ran
+	if len(a.state.Messages) > before {
+		compacted = append(compacted, a.state.Messages[before:]...)
+	}
+	a.state.Messages = compacted
+	store, id := a.store, a.sessionID
+	saved := append([]types.AgentMessage(nil), compacted...)
+	a.mu.Unlock()
+	if store != nil {
+		a.sessionErr(id, store.Replace(context.Background(), id, saved))
+	}
+	return ContextCompactedPayload{Before: before, After: after}, nil
 }
 
 // SetError sets the agent state error (for use by orchestrators).
 func (a *Agent) SetError(s string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.state.Error = &s
 }
 
+// AppendMessages appends messages to the history (for use by orchestrators). Messages without an ID or
+// CreatedAt are stamped with them (types.Stamp), and they are appended to the attached session.
+func (a *Agent) AppendMessages(msgs ...types.AgentMessage) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	a.mu.Lock()
+	added := make([]types.AgentMessage, 0, len(msgs))
+	for _, m := range msgs {
+		added = append(added, types.Stamp(m))
+	}
+	a.state.Messages = append(a.state.Messages, added...)
+	store, id := a.store, a.sessionID
+	a.mu.Unlock()
+	if store != nil && len(added) > 0 {
+		a.sessionErr(id, store.Append(context.Background(), id, added...))
+	}
+}
+
+// sessionErr records a failed write to session id as the state error; the history in memory is kept
+func (a *Agent) sessionErr(id string, err error) {
+	if err != nil {
+		msg := fmt.Sprintf("session %s: %v", id, err)
+		a.SetError(msg)
+	}
+}
+
+// LoadSession replaces the history with the messages of session id in store and attaches the session:
+// messages added to the history from then on are appended to it, and compacting the history replaces
+// its messages. A failed write sets the state error. Returns ErrBusy during a turn, and
+// session.ErrNotFound for a session that does not exist (create it first with store.Create).
+func (a *Agent) LoadSession(ctx context.Context, store session.Store, id string) error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.Busy() {
+		return ErrBusy
+	}
+	messages, err := store.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.turn != nil { // a turn started while the session loaded
+		return ErrBusy
+	}
+	a.state.Messages = append([]types.AgentMessage{}, messages...)
+	a.store, a.sessionID = store, id
+	return nil
+}
+
+// SessionID returns the ID of the attached session, or "" when none is attached.
+func (a *Agent) SessionID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sessionID
+}
+
+// SetStreaming records whether an LLM call is streaming; ending it clears StreamMessage (for use by orchestrators).
+func (a *Agent) SetStreaming(streaming bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.IsStreaming = streaming
+	if !streaming {
+		a.state.StreamMessage = nil
+	}
+}
+
+// SetToolCallPending marks a tool call as running (true) or finished (false) (for use by orchestrators).
+func (a *Agent) SetToolCallPending(toolCallID string, pending bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pending {
+		a.state.PendingToolCalls[toolCallID] = true
+	} else {
+		delete(a.state.PendingToolCalls, toolCallID)
+	}
+}
+
+// Context returns a snapshot of the system prompt, history and tools for an LLM call or transform.
+func (a *Agent) Context() types.AgentContext {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state.ToContext().Clone()
+}
+
 // Prompt starts a new conversation turn with the given user message.
 // Returns an EventStream for consuming events and the final result.
+// While another turn is in progress the prompt is rejected with ErrBusy or queued, per AgentConfig.OnBusy;
+// a queued prompt's message joins the history when its turn starts.
 func (a *Agent) Prompt(
 	ctx context.Context,
 	userMessage types.UserMessage,
+	opts ...PromptOption,
 ) *stream.EventStream[AgentEvent, []types.AgentMessage] {
+	return a.submit(&turn{ctx: ctx, userMessage: userMessage}, opts)
+}
+
+// Continue runs a turn from the current history without adding a user message, e.g. to resume after
+// Abort. Busy handling is as for Prompt.
+func (a *Agent) Continue(ctx context.Context, opts ...PromptOption) *stream.EventStream[AgentEvent, []types.AgentMessage] {
+	return a.submit(&turn{ctx: ctx, resume: true}, opts)
+}
+
+// submit starts, queues or rejects t and returns its stream
+func (a *Agent) submit(t *turn, opts []PromptOption) *stream.EventStream[AgentEvent, []types.AgentMessage] {
+	t.eventStream = stream.NewEventStream[AgentEvent, []types.AgentMessage]()
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.turn == nil:
+		a.startLocked(t)
+	case a.config.OnBusy == QueueWhenBusy:
+		a.queued = append(a.queued, t)
+	default:
+		go t.eventStream.EndWithError(ErrBusy)
+	}
+	return t.eventStream
+}
+
+// Abort stops the turn in progress. The orchestrator ends it cleanly: text streamed so far is kept in an
+// AssistantMessage with StopReasonAborted, unfinished tool calls get cancelled results, and the stream
+// ends with the history rather than an error. Queued prompts still run. Returns false when no turn is
+// in progress.
+func (a *Agent) Abort() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.turn == nil {
+		return false
+	}
+	a.turn.aborted = true
+	a.turn.cancel()
+	return true
+}
 
-	eventStream := stream.NewEventStream[AgentEvent, []types.AgentMessage]()
+// Aborted reports whether Abort stopped the turn in progress (for use by orchestrators, whose context
+// is then cancelled).
+func (a *Agent) Aborted() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.turn != nil && a.turn.aborted
+}
 
-	// Append user message before delegating to orchestrator
-	a.state.Messages = append(a.state.Messages, userMessage)
+// Busy reports whether a turn is in progress.
+func (a *Agent) Busy() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.turn != nil
+}
 
+// startLocked starts t; a.mu must be held. The turn ends when its stream ends.
+func (a *Agent) startLocked(t *turn) {
 	orch := a.config.Orchestrator
 	if orch == nil {
 		orch = defaultOrchestrator
 	}
 	if orch == nil {
-		go func() {
-			eventStream.EndWithError(fmt.Errorf("no orchestrator configured: set AgentConfig.Orchestrator or import github.com/biome/agent-core/packages/agent/orchestrators/agentic for the default agentic loop"))
-		}()
-		return eventStream
+		go t.eventStream.EndWithError(fmt.Errorf("no orchestrator configured: set AgentConfig.Orchestrator or import github.com/biome/agent-core/packages/agent/orchestrators/agentic for the default agentic loop"))
+		return
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := a.config.Budget.Timeout; timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(t.ctx, timeout, ErrTurnTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(t.ctx)
 	}
+	a.turn = t
+	a.prompt = t.opts
+	t.cancel = cancel
+	// Ending the turn before consumers see the end lets them prompt again right away
+	t.eventStream.OnEnd(func() { a.endTurn(t) })
 
 	go func() {
-		orch.Run(ctx, a, userMessage, eventStream)
+		defer a.endTurn(t)
+		var pending []types.AgentMessage
+		if !t.resume {
+			pending = append(pending, t.userMessage)
+		}
+		if err := a.CheckCapabilities(ctx, pending...); err != nil {
+			a.SetError(err.Error())
+			t.eventStream.EndWithError(err)
+			return
+		}
+		// Append user message before delegating to orchestrator; a rejected prompt leaves history as it was
+		a.AppendMessages(pending...)
+		orch.Run(ctx, a, t.userMessage, t.eventStream)
 	}()
+}
 
-	return eventStream
+// endTurn marks t as finished and starts the next queued prompt. Safe to call more than once.
+func (a *Agent) endTurn(t *turn) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.turn != t {
+		return
+	}
+	t.cancel()
+	a.turn = nil
+	a.prompt = promptOptions{}
+	for a.turn == nil && len(a.queued) > 0 {
+		next := a.queued[0]
+		a.queued = a.queued[1:]
+		if err := next.ctx.Err(); err != nil {
+			go next.eventStream.EndWithError(err)
+			continue
+		}
+		a.startLocked(next)
+	}
+}
+
+// CheckCapabilities verifies that the provider's model can serve this agent before a turn starts:
+// registered tools need a tool-calling model (unless the turn's tool choice disables them) and image
+// content needs a vision model. pending messages (e.g. a prompt not yet in the history) are checked
+// with the history. Called by Prompt. Providers that do not implement provider.Cataloger, models
+// missing from the catalog and catalog failures are not treated as errors.
+func (a *Agent) CheckCapabilities(ctx context.Context, pending ...types.AgentMessage) error {
+	cataloger, ok := a.config.Provider.(provider.Cataloger)
+	if !ok {
+		return nil
+	}
+	info, err := cataloger.Capabilities(ctx)
+	if err != nil {
+		return nil
+	}
+	req := provider.CompletionRequest{ToolChoice: a.ToolChoice(false)}
+	for _, msg := range append(a.Messages(), pending...) {
+		req.Messages = append(req.Messages, msg)
+	}
+	if a.config.Tools != nil {
+		req.Tools = convertToolsToProvider(a.config.Tools)
+	}
+	if err := info.CheckRequest(req); err != nil {
+		return fmt.Errorf("agent: %w", err)
+	}
+	return nil
 }
 
 // ExecuteTool runs a single tool and returns the result message. Used by orchestrators.
@@ -152,6 +497,86 @@ func (a *Agent) ExecuteTool(ctx context.Context, toolCall ToolCallRequest) types
 	}
 }
 
+// ExecuteTools runs tool calls in parallel and returns their results in call order, tracking them in
+// PendingToolCalls while they run. When Abort or the turn's timeout (TurnBudget.Timeout) stops the turn
+// it returns without waiting for unfinished calls, whose results are CancelledToolResult. Used by
+// orchestrators.
+func (a *Agent) ExecuteTools(ctx context.Context, calls []ToolCallRequest) []types.ToolResultMessage {
+	results := make([]types.ToolResultMessage, len(calls))
+	finished := make([]bool, len(calls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		a.SetToolCallPending(call.ToolCallId, true)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res := a.ExecuteTool(ctx, calls[i])
+			mu.Lock()
+			defer mu.Unlock()
+			// A call that failed because the turn was cancelled counts as unfinished
+			if !finished[i] && !(res.IsError && ctx.Err() != nil) {
+				results[i], finished[i] = res, true
+			}
+		}(i)
+	}
+	allDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDone)
+	}()
+	select {
+	case <-allDone:
+	case <-ctx.Done():
+		if !a.Aborted() && !TimedOut(ctx) {
+			<-allDone
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, call := range calls {
+		if !finished[i] {
+			results[i], finished[i] = CancelledToolResult(call), true
+		}
+		a.SetToolCallPending(call.ToolCallId, false)
+	}
+	return results
+}
+
+// CancelledToolResult is the result recorded for a tool call that was cancelled before it finished.
+func CancelledToolResult(call ToolCallRequest) types.ToolResultMessage {
+	return types.ToolResultMessage{
+		Content:    []types.ContentBlock{types.TextContent{Text: "tool call cancelled"}},
+		ToolCallID: call.ToolCallId,
+		ToolName:   call.ToolName,
+		IsError:    true,
+	}
+}
+
+// EndAborted ends a turn stopped by Abort (for use by orchestrators): partialText (may be empty) is
+// recorded as an AssistantMessage with StopReasonAborted, turn_end is pushed and the stream ends with
+// the history.
+func (a *Agent) EndAborted(eventStream *stream.EventStream[AgentEvent, []types.AgentMessage], partialText string, startTime time.Time, turnUsage types.UsageMetrics) {
+	msg := types.AssistantMessage{Model: "unknown", StopReason: types.StopReasonAborted}
+	if a.config.Provider != nil {
+		msg.Provider = a.config.Provider.Name()
+	}
+	if partialText != "" {
+		msg.Content = []types.ContentBlock{types.TextContent{Text: partialText}}
+	}
+	a.AppendMessages(msg)
+	eventStream.Push(AgentEvent{
+		Type: EventTurnEnd,
+		Payload: TurnEndPayload{
+			Message:  msg,
+			Duration: time.Since(startTime).Milliseconds(),
+			Usage:    turnUsage,
+		},
+	})
+	eventStream.End(a.Messages())
+}
+
 // ToolResultError extracts error text from a tool result if present. Used by orchestrators.
 func ToolResultError(tr types.ToolResultMessage) string {
 	if !tr.IsError {
@@ -165,18 +590,33 @@ func ToolResultError(tr types.ToolResultMessage) string {
 	return "unknown error"
 }
 
-// Messages returns the current conversation history.
+// Messages returns a copy of the current conversation history.
 func (a *Agent) Messages() []types.AgentMessage {
-	return a.state.Messages
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	msgs := make([]types.AgentMessage, len(a.state.Messages))
+	copy(msgs, a.state.Messages)
+	return msgs
 }
 
-// State returns the current agent state (for observability or testing).
+// State returns a snapshot of the current agent state (for observability or testing). Changing it does
+// not affect the agent.
 func (a *Agent) State() *types.AgentState {
-	return a.state
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state.Clone()
 }
 
-// Reset clears the conversation history and runtime flags; keeps system prompt.
-func (a *Agent) Reset() {
+// Reset clears the conversation history and runtime flags; keeps system prompt. An attached session is
+// detached and keeps its messages. Returns ErrBusy (and changes nothing) while a turn is in progress.
+func (a *Agent) Reset() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.turn != nil {
+		return ErrBusy
+	}
 	a.state = newAgentState(a.config)
+	a.store, a.sessionID = nil, ""
+	return nil
 }
 
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+// TurnBudget limits the work of one turn. Zero fields are unlimited. Limits are checked before each LLM
+// call and each batch of tool calls, so a step in progress is not cut short; Timeout is the exception,
+// as it is also the deadline of the turn's context.
+type TurnBudget struct {
+	// MaxSteeringIterations caps the LLM calls that ask for tools.
+	MaxSteeringIterations int
+	// MaxToolCalls caps the tool calls run.
+	MaxToolCalls int
+	// MaxTokens caps the total tokens of the turn's LLM calls.
+	MaxTokens int
+	// MaxCost caps the cost of the turn's LLM calls in USD (priced with AgentConfig.Prices).
+	MaxCost float64
+	// Timeout caps the wall-clock time from the start of the turn. Prompt sets it as the deadline of the
+	// turn's context, so a hung LLM call or tool is interrupted; the turn then stops (no forced answer).
+	Timeout time.Duration
+	// MaxRepeatedToolCalls caps how often one tool call (same tool, same arguments) runs in a turn.
+	MaxRepeatedToolCalls int
+	// OnExceeded says what happens when a limit trips. Default BudgetForceAnswer.
+	OnExceeded BudgetAction
+}
+
+// BudgetAction is what an orchestrator does when a TurnBudget limit trips.
+type BudgetAction int
+
+const (
+	// BudgetForceAnswer asks the model for a final answer with tools disabled.
+	BudgetForceAnswer BudgetAction = iota
+	// BudgetStop ends the turn with an AssistantMessage whose StopReason is StopReasonBudgetExceeded.
+	BudgetStop
+)
+
+func (a BudgetAction) String() string {
+	if a == BudgetStop {
+		return "stop"
+	}
+	return "force_answer"
+}
+
+// Budget limits reported in BudgetExceededPayload.Limit
+const (
+	LimitSteeringIterations = "steering_iterations"
+	LimitToolCalls          = "tool_calls"
+	LimitTokens             = "tokens"
+	LimitCost               = "cost"
+	LimitTimeout            = "timeout"
+	LimitRepeatedToolCall   = "repeated_tool_call"
+)
+
+// ErrTurnTimeout is the cause (context.Cause) of a turn context ended by TurnBudget.Timeout.
+var ErrTurnTimeout = errors.New("agent: turn budget timed out")
+
+// TimedOut reports whether ctx (a turn's context) ended because TurnBudget.Timeout elapsed.
+func TimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrTurnTimeout)
+}
+
+// BudgetTracker accounts one turn against a TurnBudget. Used by orchestrators; not safe for concurrent use.
+type BudgetTracker struct {
+	budget     TurnBudget
+	ctx        context.Context
+	iterations int
+	toolCalls  int
+	usage      types.UsageMetrics
+	seen       map[string]int // tool call signature -> runs
+	repeated   string         // tool name of a call over MaxRepeatedToolCalls
+}
+
+// NewBudgetTracker starts accounting the turn running in ctx; its timeout is ctx's (see TimedOut).
+func NewBudgetTracker(ctx context.Context, budget TurnBudget) *BudgetTracker {
+	return &BudgetTracker{budget: budget, ctx: ctx, seen: make(map[string]int)}
+}
+
+// AddUsage records the usage of an LLM call.
+func (b *BudgetTracker) AddUsage(u types.UsageMetrics) {
+	b.usage = b.usage.Add(u)
+}
+
+// AddSteering records an LLM call that asks for calls, before they run.
+func (b *BudgetTracker) AddSteering(calls []ToolCallRequest) {
+	b.iterations++
+	b.AddToolCalls(calls)
+}
+
+// AddToolCalls records calls about to run that no steering call asked for (e.g. plan steps).
+func (b *BudgetTracker) AddToolCalls(calls []ToolCallRequest) {
+	b.toolCalls += len(calls)
+	for _, call := range calls {
+		args, _ := json.Marshal(call.Args) // map keys are sorted, so equal arguments give equal JSON
+		key := call.ToolName + "\x00" + string(args)
+		b.seen[key]++
+		if b.budget.MaxRepeatedToolCalls > 0 && b.seen[key] > b.budget.MaxRepeatedToolCalls && b.repeated == "" {
+			b.repeated = call.ToolName
+		}
+	}
+}
+
+// Exceeded returns the first limit the turn has gone over, with a description for the model and for
+// BudgetExceededPayload. ok is false while the turn is within budget. A timeout always stops the turn,
+// since no call can be made past the deadline.
+func (b *BudgetTracker) Exceeded() (payload BudgetExceededPayload, ok bool) {
+	limit := b.budget
+	payload.Action = limit.OnExceeded.String()
+	switch {
+	case b.repeated != "":
+		payload.Limit = LimitRepeatedToolCall
+		payload.Detail = fmt.Sprintf("the same %s call was requested more than %d time(s)", b.repeated, limit.MaxRepeatedToolCalls)
+	case limit.MaxSteeringIterations > 0 && b.iterations > limit.MaxSteeringIterations:
+		payload.Limit = LimitSteeringIterations
+		payload.Detail = fmt.Sprintf("more than %d tool-use step(s)", limit.MaxSteeringIterations)
+	case limit.MaxToolCalls > 0 && b.toolCalls > limit.MaxToolCalls:
+		payload.Limit = LimitToolCalls
+		payload.Detail = fmt.Sprintf("more than %d tool call(s)", limit.MaxToolCalls)
+	case limit.MaxTokens > 0 && b.usage.TotalTokens > limit.MaxTokens:
+		payload.Limit = LimitTokens
+		payload.Detail = fmt.Sprintf("%d tokens used, limit %d", b.usage.TotalTokens, limit.MaxTokens)
+	case limit.MaxCost > 0 && b.usage.Cost.Total > limit.MaxCost:
+		payload.Limit = LimitCost
+		payload.Detail = fmt.Sprintf("$%.4f spent, limit $%.4f", b.usage.Cost.Total, limit.MaxCost)
+	case limit.Timeout > 0 && TimedOut(b.ctx):
+		payload.Limit = LimitTimeout
+		payload.Action = BudgetStop.String()
+		payload.Detail = fmt.Sprintf("turn ran longer than %s", limit.Timeout)
+	default:
+		return BudgetExceededPayload{}, false
+	}
+	return payload, true
+}
+
+// Stops reports whether the limit ends the turn without another LLM call (BudgetStop or a timeout)
+// rather than forcing an answer.
+func (p BudgetExceededPayload) Stops() bool {
+	return p.Action == BudgetStop.String()
+}
+
+// ForceAnswerMessage is the control message that asks the model to finish after a limit tripped.
+func ForceAnswerMessage(exceeded BudgetExceededPayload) types.ControlMessage {
+	text := fmt.Sprintf("Stop using tools: this turn's budget is used up (%s). Answer the user now with the information you already have.", exceeded.Detail)
+	return types.ControlMessage{Content: []types.ContentBlock{types.TextContent{Text: text}}}
+}
+
+// BudgetStopText is the text of the message that ends a turn stopped by its budget (BudgetStop).
+func BudgetStopText(exceeded BudgetExceededPayload) string {
+	return fmt.Sprintf("I stopped before answering: this turn's budget is used up (%s).", exceeded.Detail)
+}
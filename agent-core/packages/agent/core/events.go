@@ -1,18 +1,29 @@
 package core
 
-import "github.com/biome/agent-core/packages/agent/types"
+import (
+	"errors"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
 
 const (
 	EventTurnStart     = "turn_start"
 	EventTextDelta     = "text_delta"
 	EventThinking      = "thinking"
+	EventThinkingDelta = "thinking_delta"
 	EventSteeringMode  = "steering_mode"
 	EventToolCall      = "tool_call"
+	EventToolCallDelta = "tool_call_delta"
 	EventToolResult    = "tool_result"
 	EventTurnEnd       = "turn_end"
 	EventPlanCreated   = "plan_created"
 	EventPlanStepStart = "plan_step_start"
 	EventPlanStepEnd   = "plan_step_end"
+	EventError         = "error"
+	EventContextCompacted = "context_compacted"
+	EventBudgetExceeded = "budget_exceeded"
 )
 
 type AgentEvent struct {
@@ -35,6 +46,15 @@ type ToolCallPayload struct {
 	Args		map[string]interface{}
 }
 
+// ToolCallDeltaPayload is emitted while a tool call is still streaming from the LLM.
+// Args is a best-effort parse of the arguments received so far.
+type ToolCallDeltaPayload struct {
+	Index		int
+	ToolCallId	string
+	ToolName 	string
+	Args		map[string]interface{}
+}
+
 type ToolResultPayload struct {
 	ToolCallId	string
 	ToolName 	string
@@ -42,8 +62,11 @@ type ToolResultPayload struct {
 	Error		string
 }
 
+// ThinkingPayload carries what the model said before acting: Text is any answer text it produced
+// alongside tool calls, Reasoning is its reasoning/thinking output (reasoning models only).
 type ThinkingPayload struct {
-	Text string
+	Text		string
+	Reasoning	string
 }
 
 type SteeringModePayload struct {
@@ -55,6 +78,8 @@ type SteeringModePayload struct {
 type TurnEndPayload struct {
 	Message		types.AssistantMessage
 	Duration 	int64
+	// Usage totals every LLM call made during the turn (tool-use steps and the final answer).
+	Usage		types.UsageMetrics
 }
 
 // PlanCreatedPayload is emitted when the plan-and-execute orchestrator has produced a plan.
@@ -85,3 +110,38 @@ type PlanStepEndPayload struct {
 	Result    interface{}
 	Error     string
 }
+
+// ErrorPayload is emitted when an LLM call fails. Kind is provider.ErrorKind of the error ("rate_limited",
+// "context_length", "auth", ...; empty when unclassified); StatusCode and RetryAfter are set for HTTP errors.
+type ErrorPayload struct {
+	Kind		string
+	Message		string
+	StatusCode	int
+	RetryAfter	time.Duration
+}
+
+// NewErrorPayload describes err for an EventError.
+func NewErrorPayload(err error) ErrorPayload {
+	p := ErrorPayload{Kind: provider.ErrorKind(err), Message: err.Error()}
+	var httpErr *provider.HTTPError
+	if errors.As(err, &httpErr) {
+		p.StatusCode = httpErr.StatusCode
+		p.RetryAfter = httpErr.RetryAfter
+	}
+	return p
+}
+
+// ContextCompactedPayload is emitted when the history was compacted because it no longer fit the
+// model's context window; Before and After are message counts.
+type ContextCompactedPayload struct {
+	Before	int
+	After	int
+}
+
+// BudgetExceededPayload is emitted when a turn goes over a limit of AgentConfig.Budget. Limit is one of
+// the Limit* constants, Action is "force_answer" or "stop" and Detail describes the overrun.
+type BudgetExceededPayload struct {
+	Limit	string
+	Action	string
+	Detail	string
+}
\ No newline at end of file
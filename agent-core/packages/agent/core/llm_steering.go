@@ -13,7 +13,8 @@ import (
 
 // makeSteeringDecision uses the LLM to decide whether to respond or use tools.
 // It takes an AgentContext snapshot so transforms and LLM see immutable state.
-// Tools are always included when toolRegistry is non-nil.
+// Tools are always included when toolRegistry is non-nil; toolChoice (nil = provider default) constrains their use.
+// The LLM is called via Stream; when emit is non-nil, text and tool-call deltas are forwarded as they arrive.
 func makeSteeringDecision(
 	ctx context.Context,
 	llm provider.Provider,
@@ -22,8 +23,14 @@ func makeSteeringDecision(
 	toolRegistry *tools.ToolRegistry,
 	isFollowUp bool,
 	initialSteeringInstruction string,
+	toolChoice *provider.ToolChoice,
+	emit func(AgentEvent),
 ) (SteeringDecision, error) {
 	steeringPrompt := buildSteeringPrompt(agentContext.SystemPrompt, isFollowUp, initialSteeringInstruction)
+	if !toolChoice.AllowsTools() {
+		// The default instruction insists on tool use; don't contradict a request that forbids it.
+		steeringPrompt += "\n\nTool calls are disabled for this reply: answer directly from the conversation."
+	}
 
 	var providerMessages []types.Message
 	if pipeline != nil {
@@ -63,41 +70,42 @@ func makeSteeringDecision(
 		Temperature:  0.7,
 		MaxTokens:    1000,
 		Tools:        providerTools,
+		ToolChoice:   toolChoice,
 	}
 
-	fmt.Printf("[DEBUG] Req for steering mode to provider: \n SystemPrompt(len=%d), \n Messages(%d), \n Temperature=%.2f, \n MaxTokens=%d, \n Tools=%d\n",
-		len(steeringPrompt), len(providerMessages), req.Temperature, req.MaxTokens, len(providerTools))
+	fmt.Printf("[DEBUG] Req for steering mode to provider: \n SystemPrompt(len=%d), \n Messages(%d), \n Temperature=%.2f, \n MaxTokens=%d, \n Tools=%d, \n ToolChoice=%s\n",
+		len(steeringPrompt), len(providerMessages), req.Temperature, req.MaxTokens, len(providerTools), toolChoice)
 
 	// Get response from LLM
-	resp, err := llm.Complete(ctx, req)
+	resp, err := StreamCompletion(ctx, llm, req, emit)
 	if err != nil {
 		return SteeringDecision{}, fmt.Errorf("steering decision failed: %w", err)
 	}
 
 	fmt.Printf("LLM Response for Steering Decision: %+v\n", resp)
 
+	thinking := ThinkingFromReasoning(resp.Reasoning)
+
 	// Check if LLM wants to use tools (structured)
 	if len(resp.ToolCalls) > 0 {
 		// Convert to agent ToolCallRequests
 		toolCalls := make([]ToolCallRequest, 0, len(resp.ToolCalls))
-		toolNames := make([]string, 0, len(resp.ToolCalls))
 		for _, tc := range resp.ToolCalls {
 			toolCalls = append(toolCalls, ToolCallRequest{
 				ToolCallId: tc.ID,
 				ToolName:   tc.Name,
 				Args:       tc.Arguments,
 			})
-			toolNames = append(toolNames, tc.Name)
 		}
 
-		// Create descriptive thinking text
-		thinkingText := fmt.Sprintf("I'll use %s to help answer this.", strings.Join(toolNames, ", "))
-
 		return SteeringDecision{
 			Mode:         SteeringModeSteer,
-			ThinkingText: thinkingText,
+			ThinkingText: resp.Text,
+			Thinking:     thinking,
 			ToolCalls:    toolCalls,
 			Model:        resp.Model,
+			Provider:     resp.Provider,
+			Usage:        resp.Usage,
 		}, nil
 	}
 
@@ -105,10 +113,86 @@ func makeSteeringDecision(
 	return SteeringDecision{
 		Mode:     SteeringModeRespond,
 		Response: resp.Text,
+		Thinking: thinking,
 		Model:    resp.Model,
+		Provider: resp.Provider,
+		Usage:    resp.Usage,
 	}, nil
 }
 
+// StreamCompletion calls llm.Stream and collects the result into a CompletionResponse.
+// When emit is non-nil, text deltas are forwarded as EventTextDelta, reasoning deltas as
+// EventThinkingDelta and streamed tool-call arguments as EventToolCallDelta while the model is
+// still producing them. Used by orchestrators.
+func StreamCompletion(ctx context.Context, llm provider.Provider, req provider.CompletionRequest, emit func(AgentEvent)) (*provider.CompletionResponse, error) {
+	events, err := llm.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	textIndex, thinkingIndex := 0, 0
+	return provider.Collect(events, func(ev provider.StreamEvent) {
+		if emit == nil {
+			return
+		}
+		switch ev.Type {
+		case provider.EventTextDelta:
+			if ev.Delta == "" {
+				return
+			}
+			emit(AgentEvent{
+				Type:    EventTextDelta,
+				Payload: TextDeltaPayload{Text: ev.Delta, Index: textIndex},
+			})
+			textIndex++
+		case provider.EventReasoningDelta:
+			if ev.Delta == "" {
+				return
+			}
+			emit(AgentEvent{
+				Type:    EventThinkingDelta,
+				Payload: TextDeltaPayload{Text: ev.Delta, Index: thinkingIndex},
+			})
+			thinkingIndex++
+		case provider.EventToolDelta:
+			if p, ok := ev.Content.(*provider.ToolCallStreamPayload); ok && p != nil {
+				emit(AgentEvent{
+					Type: EventToolCallDelta,
+					Payload: ToolCallDeltaPayload{
+						Index:      p.Index,
+						ToolCallId: p.ID,
+						ToolName:   p.Name,
+						Args:       p.Arguments,
+					},
+				})
+			}
+		}
+	})
+}
+
+// ThinkingFromReasoning converts provider reasoning blocks to ThinkingContent, keeping signatures
+// so providers that require it can have the reasoning replayed. Used by orchestrators.
+func ThinkingFromReasoning(blocks []provider.ReasoningBlock) []types.ThinkingContent {
+	if len(blocks) == 0 {
+		return nil
+	}
+	out := make([]types.ThinkingContent, 0, len(blocks))
+	for _, b := range blocks {
+		out = append(out, types.ThinkingContent{Thinking: b.Text, Signature: b.Signature})
+	}
+	return out
+}
+
+// ThinkingText joins the text of thinking blocks.
+func ThinkingText(blocks []types.ThinkingContent) string {
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Thinking != "" {
+			parts = append(parts, b.Thinking)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // convertToolsToProvider converts tool registry to provider tools
 func convertToolsToProvider(registry *tools.ToolRegistry) []provider.Tool {
 	if registry == nil {
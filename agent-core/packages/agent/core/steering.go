@@ -1,5 +1,10 @@
 package core
 
+import (
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
 type SteeringMode string
 
 const (
@@ -10,10 +15,17 @@ const (
 type SteeringDecision struct {
 	Mode         SteeringMode
 	ToolCalls    []ToolCallRequest
+	// ThinkingText is the text the model produced alongside its tool calls (empty if none).
 	ThinkingText string
 	Response     string
 	// Model is the model identifier used for this steering call (e.g. anthropic/claude-3-haiku). Empty if the provider did not report it.
 	Model string
+	// Provider names the backend that served the call when it differs from the configured provider's Name (e.g. failover).
+	Provider string
+	// Thinking holds the model's reasoning blocks, in order, to be stored on the assistant message.
+	Thinking []types.ThinkingContent
+	// Usage is the token usage reported for the steering call.
+	Usage provider.UsageInfo
 }
 
 type ToolCallRequest struct {
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// UsageFromProvider converts provider usage into message usage. Input counts uncached prompt tokens
+// only; cache reads and writes are reported separately. Cost is filled in when prices knows the model.
+func UsageFromProvider(u provider.UsageInfo, model string, prices provider.Pricer) types.UsageMetrics {
+	input := u.PromptTokens - u.CacheReadTokens - u.CacheWriteTokens
+	if input < 0 {
+		input = 0
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	out := types.UsageMetrics{
+		Input:       input,
+		Output:      u.CompletionTokens,
+		CacheRead:   u.CacheReadTokens,
+		CacheWrite:  u.CacheWriteTokens,
+		TotalTokens: total,
+	}
+	if prices == nil {
+		return out
+	}
+	price, ok := prices.Price(model)
+	if !ok {
+		return out
+	}
+	const perToken = 1.0 / 1_000_000
+	out.Cost = types.Cost{
+		Input:      float64(out.Input) * price.Input * perToken,
+		Output:     float64(out.Output) * price.Output * perToken,
+		CacheRead:  float64(out.CacheRead) * price.CacheRead * perToken,
+		CacheWrite: float64(out.CacheWrite) * price.CacheWrite * perToken,
+	}
+	out.Cost.Total = out.Cost.Input + out.Cost.Output + out.Cost.CacheRead + out.Cost.CacheWrite
+	return out
+}
+
+// Usage converts usage reported for model into message usage priced with AgentConfig.Prices
+// (provider.DefaultPrices when unset). Used by orchestrators.
+func (a *Agent) Usage(model string, u provider.UsageInfo) types.UsageMetrics {
+	prices := a.config.Prices
+	if prices == nil {
+		prices = provider.DefaultPrices()
+	}
+	return UsageFromProvider(u, model, prices)
+}
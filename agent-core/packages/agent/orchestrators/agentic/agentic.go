@@ -2,14 +2,15 @@ package agentic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/biome/agent-core/packages/agent/core"
 	"github.com/biome/agent-core/packages/agent/types"
 	"github.com/biome/agent-core/packages/stream"
+	"github.com/biome/agent-mind/provider"
 )
 
 func init() {
@@ -28,7 +29,6 @@ type AgenticOrchestrator struct{}
 func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMessage types.UserMessage, eventStream *stream.EventStream[core.AgentEvent, []types.AgentMessage]) {
 	_ = userMessage // already appended by Prompt() before Run()
 	startTime := time.Now()
-	state := agent.State()
 	config := agent.Config()
 
 	// Emit turn start
@@ -37,6 +37,85 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 		Payload: core.TurnStartPayload{Timestamp: time.Now().UnixMilli()},
 	})
 
+	// emit forwards streamed deltas from the LLM to the event stream as they arrive; partial keeps the
+	// text of the current call so an aborted turn can record it.
+	streamedText := false
+	var partial strings.Builder
+	emit := func(e core.AgentEvent) {
+		if e.Type == core.EventTextDelta {
+			streamedText = true
+			if p, ok := e.Payload.(core.TextDeltaPayload); ok {
+				partial.WriteString(p.Text)
+			}
+		}
+		eventStream.Push(e)
+	}
+	// compact shrinks a history that overflowed the model's context window; false when nothing was removed.
+	compact := func() bool {
+		compacted, err := agent.CompactHistory(ctx)
+		if err != nil || compacted.After >= compacted.Before {
+			return false
+		}
+		eventStream.Push(core.AgentEvent{Type: core.EventContextCompacted, Payload: compacted})
+		return true
+	}
+	decideWith := func(isFollowUp bool, toolChoice *provider.ToolChoice) (core.SteeringDecision, error) {
+		streamedText = false
+		partial.Reset()
+		agent.SetStreaming(true)
+		defer agent.SetStreaming(false)
+		decision, err := agent.StreamSteeringDecisionWith(ctx, isFollowUp, toolChoice, emit)
+		if errors.Is(err, provider.ErrContextLength) && compact() {
+			streamedText = false
+			partial.Reset()
+			decision, err = agent.StreamSteeringDecisionWith(ctx, isFollowUp, toolChoice, emit)
+		}
+		return decision, err
+	}
+	decide := func(isFollowUp bool) (core.SteeringDecision, error) {
+		return decideWith(isFollowUp, agent.ToolChoice(isFollowUp))
+	}
+	// fail surfaces a failed LLM call; the turn then ends with an error message instead of an answer.
+	fail := func(err error) {
+		agent.SetError(fmt.Sprintf("%v", err))
+		streamedText = false
+		eventStream.Push(core.AgentEvent{Type: core.EventError, Payload: core.NewErrorPayload(err)})
+	}
+
+	// The budget covers the whole turn, follow-ups included, and is checked before every LLM call.
+	budget := core.NewBudgetTracker(ctx, config.Budget)
+	// overBudget reports a limit the turn has gone over with a budget_exceeded event.
+	overBudget := func() (core.BudgetExceededPayload, bool) {
+		exceeded, over := budget.Exceeded()
+		if over {
+			eventStream.Push(core.AgentEvent{Type: core.EventBudgetExceeded, Payload: exceeded})
+		}
+		return exceeded, over
+	}
+	// call makes the next LLM call if the budget allows it. Over a limit the model is asked for a final
+	// answer with tools disabled, or no call is made and the tripped limit is returned as stopped (also
+	// when the turn's timeout cuts the call short).
+	call := func(isFollowUp bool) (decision core.SteeringDecision, stopped *core.BudgetExceededPayload, err error) {
+		if exceeded, over := overBudget(); over && exceeded.Stops() {
+			return decision, &exceeded, nil
+		} else if over {
+			agent.AppendMessages(core.ForceAnswerMessage(exceeded))
+			decision, err = decideWith(true, provider.DisableTools())
+			if err == nil && decision.Mode == core.SteeringModeSteer {
+				// Tool calls despite the instruction: whatever text came with them is the answer.
+				decision.Mode, decision.Response, decision.ToolCalls = core.SteeringModeRespond, decision.ThinkingText, nil
+			}
+		} else {
+			decision, err = decide(isFollowUp)
+		}
+		if err != nil && core.TimedOut(ctx) {
+			if exceeded, over := overBudget(); over {
+				return core.SteeringDecision{}, &exceeded, nil
+			}
+		}
+		return decision, nil, err
+	}
+
 	firstTurn := true
 	for {
 		if !firstTurn {
@@ -46,44 +125,88 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 			})
 		}
 
-		if ctx.Err() != nil {
+		if agent.Aborted() {
+			agent.EndAborted(eventStream, "", startTime, types.UsageMetrics{})
+			return
+		}
+		if ctx.Err() != nil && !core.TimedOut(ctx) {
 			eventStream.EndWithError(ctx.Err())
 			return
 		}
 
-		decision, err := agent.SteeringDecision(ctx, !firstTurn)
+		// callUsage is the usage of the latest LLM call (zero when it failed); turnUsage totals the turn.
+		var callUsage, turnUsage types.UsageMetrics
+		// stopped is set when the budget stopped the turn
+		decision, stopped, err := call(!firstTurn)
+		if agent.Aborted() {
+			agent.EndAborted(eventStream, partial.String(), startTime, turnUsage)
+			return
+		}
 		if err != nil {
-			agent.SetError(fmt.Sprintf("%v", err))
-			decision = core.SteeringDecision{
-				Mode:     core.SteeringModeRespond,
-				Response: fmt.Sprintf("I encountered an error: %v", err),
-			}
+			fail(err)
+			decision = core.SteeringDecision{Mode: core.SteeringModeRespond}
+		} else {
+			callUsage = agent.Usage(decision.Model, decision.Usage)
+			budget.AddUsage(callUsage)
 		}
 
-		eventStream.Push(core.AgentEvent{
-			Type: core.EventSteeringMode,
-			Payload: core.SteeringModePayload{
-				Mode:      string(decision.Mode),
-				QueueSize: len(decision.ToolCalls),
-			},
-		})
+		if stopped == nil {
+			eventStream.Push(core.AgentEvent{
+				Type: core.EventSteeringMode,
+				Payload: core.SteeringModePayload{
+					Mode:      string(decision.Mode),
+					QueueSize: len(decision.ToolCalls),
+				},
+			})
+		}
 
 		var responseText string
 
 		for decision.Mode == core.SteeringModeSteer {
-			if ctx.Err() != nil {
+			if agent.Aborted() {
+				agent.EndAborted(eventStream, "", startTime, turnUsage)
+				return
+			}
+			if ctx.Err() != nil && !core.TimedOut(ctx) {
 				eventStream.EndWithError(ctx.Err())
 				return
 			}
 
-			if decision.ThinkingText != "" {
+			// Over budget, the requested calls are dropped before they run and the turn answers or stops.
+			budget.AddSteering(decision.ToolCalls)
+			if _, over := budget.Exceeded(); over {
+				turnUsage = turnUsage.Add(callUsage)
+				callUsage = types.UsageMetrics{}
+				decision, stopped, err = call(true)
+				if agent.Aborted() {
+					agent.EndAborted(eventStream, partial.String(), startTime, turnUsage)
+					return
+				}
+				if err != nil {
+					fail(err)
+					break
+				}
+				callUsage = agent.Usage(decision.Model, decision.Usage)
+				responseText = decision.Response
+				break
+			}
+
+			reasoning := core.ThinkingText(decision.Thinking)
+			if decision.ThinkingText != "" || reasoning != "" {
 				eventStream.Push(core.AgentEvent{
 					Type:    core.EventThinking,
-					Payload: core.ThinkingPayload{Text: decision.ThinkingText},
+					Payload: core.ThinkingPayload{Text: decision.ThinkingText, Reasoning: reasoning},
 				})
 			}
 
-			assistantBlocks := make([]types.ContentBlock, 0, len(decision.ToolCalls))
+			// Reasoning first, then any text, then the tool calls: the order providers expect on replay.
+			assistantBlocks := make([]types.ContentBlock, 0, len(decision.Thinking)+len(decision.ToolCalls)+1)
+			for _, th := range decision.Thinking {
+				assistantBlocks = append(assistantBlocks, th)
+			}
+			if decision.ThinkingText != "" {
+				assistantBlocks = append(assistantBlocks, types.TextContent{Text: decision.ThinkingText})
+			}
 			for _, tc := range decision.ToolCalls {
 				assistantBlocks = append(assistantBlocks, types.ToolCallContent{
 					ID:        tc.ToolCallId,
@@ -95,34 +218,23 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 			if modelUsed == "" {
 				modelUsed = "unknown"
 			}
-			providerNameForTurn := ""
-			if config.Provider != nil {
+			providerNameForTurn := decision.Provider
+			if providerNameForTurn == "" && config.Provider != nil {
 				providerNameForTurn = config.Provider.Name()
 			}
 			assistantWithToolCalls := types.AssistantMessage{
 				Content:    assistantBlocks,
 				Provider:   providerNameForTurn,
 				Model:      modelUsed,
+				Usage:      callUsage,
 				StopReason: types.StopReasonToolUse,
 			}
-			state.Messages = append(state.Messages, assistantWithToolCalls)
-
-			calls := decision.ToolCalls
-			for _, tc := range calls {
-				state.PendingToolCalls[tc.ToolCallId] = true
-			}
+			agent.AppendMessages(assistantWithToolCalls)
+			turnUsage = turnUsage.Add(callUsage)
 
 			// Run all tool calls in parallel; collect results in invocation order.
-			results := make([]types.ToolResultMessage, len(calls))
-			var wg sync.WaitGroup
-			for i := range calls {
-				wg.Add(1)
-				go func(i int) {
-					defer wg.Done()
-					results[i] = agent.ExecuteTool(ctx, calls[i])
-				}(i)
-			}
-			wg.Wait()
+			calls := decision.ToolCalls
+			results := agent.ExecuteTools(ctx, calls)
 
 			// Emit events and append results in order.
 			for _, tc := range calls {
@@ -146,15 +258,16 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 						Error:      core.ToolResultError(res),
 					},
 				})
-				delete(state.PendingToolCalls, tc.ToolCallId)
-				state.Messages = append(state.Messages, res)
+				agent.AppendMessages(res)
+			}
+			if agent.Aborted() {
+				agent.EndAborted(eventStream, "", startTime, turnUsage)
+				return
 			}
 
 			if config.GetSteeringMessages != nil {
 				if steering := config.GetSteeringMessages(); len(steering) > 0 {
-					for _, m := range steering {
-						state.Messages = append(state.Messages, m)
-					}
+					agent.AppendMessages(steering...)
 				}
 			}
 
@@ -169,16 +282,26 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 			if len(summaryLines) > 0 {
 				controlText = strings.Join(summaryLines, " ") + " " + controlText
 			}
-			state.Messages = append(state.Messages, types.ControlMessage{
+			agent.AppendMessages(types.ControlMessage{
 				Content: []types.ContentBlock{types.TextContent{Text: controlText}},
 			})
 
-			decision, err = agent.SteeringDecision(ctx, true)
+			decision, stopped, err = call(true)
+			if agent.Aborted() {
+				agent.EndAborted(eventStream, partial.String(), startTime, turnUsage)
+				return
+			}
 			if err != nil {
-				agent.SetError(fmt.Sprintf("%v", err))
-				responseText = fmt.Sprintf("I encountered an error: %v", err)
+				fail(err)
+				callUsage = types.UsageMetrics{}
 				break
 			}
+			if stopped != nil {
+				callUsage = types.UsageMetrics{}
+				break
+			}
+			callUsage = agent.Usage(decision.Model, decision.Usage)
+			budget.AddUsage(callUsage)
 
 			eventStream.Push(core.AgentEvent{
 				Type: core.EventSteeringMode,
@@ -198,37 +321,61 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 			responseText = decision.Response
 		}
 
-		if responseText != "" {
-			state.IsStreaming = true
-			words := strings.Split(responseText, " ")
-			for i, word := range words {
-				eventStream.Push(core.AgentEvent{
-					Type: core.EventTextDelta,
-					Payload: core.TextDeltaPayload{
-						Text:  word + " ",
-						Index: i,
-					},
-				})
-			}
-			state.IsStreaming = false
-			state.StreamMessage = nil
+		// Text from the LLM was already streamed as it arrived; text produced locally is pushed as one delta.
+		if responseText != "" && !streamedText {
+			eventStream.Push(core.AgentEvent{
+				Type:    core.EventTextDelta,
+				Payload: core.TextDeltaPayload{Text: responseText, Index: 0},
+			})
+		}
+
+		// Reasoning behind the final answer (none when the last call failed).
+		var finalThinking []types.ThinkingContent
+		if decision.Mode == core.SteeringModeRespond && err == nil {
+			finalThinking = decision.Thinking
+		}
+		if reasoning := core.ThinkingText(finalThinking); reasoning != "" {
+			eventStream.Push(core.AgentEvent{
+				Type:    core.EventThinking,
+				Payload: core.ThinkingPayload{Reasoning: reasoning},
+			})
 		}
 
-		providerName := ""
-		if config.Provider != nil {
+		providerName := decision.Provider
+		if providerName == "" && config.Provider != nil {
 			providerName = config.Provider.Name()
 		}
 		modelUsed := decision.Model
 		if modelUsed == "" {
 			modelUsed = "unknown"
 		}
+		finalBlocks := make([]types.ContentBlock, 0, len(finalThinking)+1)
+		for _, th := range finalThinking {
+			finalBlocks = append(finalBlocks, th)
+		}
 		assistantMessage := types.AssistantMessage{
-			Content:    []types.ContentBlock{types.TextContent{Text: responseText}},
 			Provider:   providerName,
 			Model:      modelUsed,
+			Usage:      callUsage,
 			StopReason: types.StopReasonStop,
 		}
-		state.Messages = append(state.Messages, assistantMessage)
+		if err != nil {
+			// No answer: the message records the failure (the error event already reported it). It keeps
+			// the error as text, since providers reject an assistant message without content on replay.
+			errorMessage := err.Error()
+			assistantMessage.StopReason = types.StopReasonError
+			assistantMessage.ErrorMessage = &errorMessage
+			finalBlocks = append(finalBlocks, types.TextContent{Text: fmt.Sprintf("I encountered an error: %v", err)})
+		} else if stopped != nil {
+			// No answer: the text says which limit stopped the turn, as the budget_exceeded event did.
+			assistantMessage.StopReason = types.StopReasonBudgetExceeded
+			finalBlocks = append(finalBlocks, types.TextContent{Text: core.BudgetStopText(*stopped)})
+		} else {
+			finalBlocks = append(finalBlocks, types.TextContent{Text: responseText})
+		}
+		assistantMessage.Content = finalBlocks
+		agent.AppendMessages(assistantMessage)
+		turnUsage = turnUsage.Add(callUsage)
 
 		duration := time.Since(startTime).Milliseconds()
 		eventStream.Push(core.AgentEvent{
@@ -236,21 +383,20 @@ func (o *AgenticOrchestrator) Run(ctx context.Context, agent *core.Agent, userMe
 			Payload: core.TurnEndPayload{
 				Message:  assistantMessage,
 				Duration: duration,
+				Usage:    turnUsage,
 			},
 		})
 
-		if config.GetFollowUpMessages == nil {
-			break
+		if config.GetFollowUpMessages == nil || core.TimedOut(ctx) {
+			break // no follow-up can run past the turn's timeout
 		}
 		followUp := config.GetFollowUpMessages()
 		if len(followUp) == 0 {
 			break
 		}
-		for _, m := range followUp {
-			state.Messages = append(state.Messages, m)
-		}
+		agent.AppendMessages(followUp...)
 		firstTurn = false
 	}
 
-	eventStream.End(state.Messages)
+	eventStream.End(agent.Messages())
 }
@@ -1,5 +1,7 @@
 package planexecute
 
+import "github.com/biome/agent-mind/provider"
+
 // PlanStep represents a single step in a plan (tool name and arguments).
 type PlanStep struct {
 	Tool string                 `json:"tool"`
@@ -12,3 +14,50 @@ type PlanStep struct {
 type Plan struct {
 	Steps []PlanStep `json:"steps"`
 }
+
+// planFormat is the response format requested from the planning call. When toolNames is non-empty the
+// step's tool is constrained to those names, and minSteps > 0 requires that many steps; parsing
+// validates structure only, so an unknown tool still reaches execution and is reported as a failed step.
+func planFormat(toolNames []string, minSteps int) *provider.ResponseFormat {
+	tool := map[string]interface{}{"type": "string"}
+	if len(toolNames) > 0 {
+		tool["enum"] = toolNames
+	}
+	steps := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tool":   tool,
+				"args":   map[string]interface{}{"type": "object"},
+				"reason": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"tool", "args"},
+		},
+	}
+	if minSteps > 0 {
+		steps["minItems"] = minSteps
+	}
+	return provider.JSONSchemaFormat("plan", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"steps": steps},
+		"required":   []string{"steps"},
+	})
+}
+
+// planConstraints maps the turn's tool choice onto the plan: the tools a step may use and the minimum
+// number of steps. ok is false when tools are disabled, in which case planning is skipped.
+func planConstraints(choice *provider.ToolChoice, registered []string) (toolNames []string, minSteps int, ok bool) {
+	if choice == nil {
+		return registered, 0, true
+	}
+	switch choice.Mode {
+	case provider.ToolChoiceNone:
+		return nil, 0, false
+	case provider.ToolChoiceRequired:
+		return registered, 1, true
+	case provider.ToolChoiceTool:
+		return []string{choice.Name}, 1, true
+	}
+	return registered, 0, true
+}
@@ -2,9 +2,8 @@ package planexecute
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
@@ -28,7 +27,6 @@ type PlanExecuteOrchestrator struct{}
 func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, userMessage types.UserMessage, eventStream *stream.EventStream[core.AgentEvent, []types.AgentMessage]) {
 	_ = userMessage // already appended by Prompt() before Run()
 	startTime := time.Now()
-	state := agent.State()
 	config := agent.Config()
 
 	eventStream.Push(core.AgentEvent{
@@ -42,34 +40,103 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 	}
 
 	// --- Planning phase ---
-	agentContext := state.ToContext().Clone()
+	agentContext := agent.Context()
 	providerMessages, err := buildProviderMessages(ctx, agentContext, config.Pipeline)
 	if err != nil {
 		eventStream.EndWithError(fmt.Errorf("plan-and-execute: build messages: %w", err))
 		return
 	}
 
-	planningPrompt := buildPlanningPrompt(agentContext.SystemPrompt, config.Tools)
-	planReq := provider.CompletionRequest{
-		SystemPrompt: planningPrompt,
-		Messages:     providerMessages,
-		Temperature:  0.3,
-		MaxTokens:    2000,
-		Tools:        nil, // no tools for plan phase; we want JSON in text
+	// The turn's tool choice shapes the plan; with tools disabled there is nothing to plan.
+	var registered []string
+	if config.Tools != nil {
+		registered = config.Tools.ListTools()
 	}
+	toolNames, minSteps, planning := planConstraints(agent.ToolChoice(false), registered)
 
-	planResp, err := config.Provider.Complete(ctx, planReq)
-	if err != nil {
-		agent.SetError(fmt.Sprintf("%v", err))
-		eventStream.EndWithError(fmt.Errorf("plan-and-execute: planning call: %w", err))
-		return
+	var turnUsage types.UsageMetrics
+	budget := core.NewBudgetTracker(ctx, config.Budget)
+	// overBudget reports a limit the turn has gone over with a budget_exceeded event.
+	overBudget := func() (core.BudgetExceededPayload, bool) {
+		exceeded, over := budget.Exceeded()
+		if over {
+			eventStream.Push(core.AgentEvent{Type: core.EventBudgetExceeded, Payload: exceeded})
+		}
+		return exceeded, over
 	}
+	// stopIfTimedOut ends a turn whose timeout cut a call short; false when the turn has not timed out.
+	stopIfTimedOut := func() bool {
+		if !core.TimedOut(ctx) {
+			return false
+		}
+		exceeded, _ := overBudget()
+		endBudgetStopped(agent, eventStream, exceeded, startTime, turnUsage)
+		return true
+	}
+	plan := &Plan{Steps: nil}
+	if planning {
+		planningPrompt := buildPlanningPrompt(agentContext.SystemPrompt, config.Tools)
+		planReq := provider.CompletionRequest{
+			SystemPrompt:   planningPrompt,
+			Messages:       providerMessages,
+			Temperature:    0.3,
+			MaxTokens:      2000,
+			Tools:          nil, // no tools for plan phase; we want JSON in text
+			ResponseFormat: planFormat(toolNames, minSteps),
+		}
 
-	plan, err := parsePlan(planResp.Text)
-	if err != nil {
-		agent.SetError(fmt.Sprintf("failed to parse plan: %v", err))
-		// Empty plan: skip execution, go to synthesis
-		plan = &Plan{Steps: nil}
+		planResp, err := completeWithCompaction(ctx, agent, eventStream, providerMessages, func(messages []types.Message) (*provider.CompletionResponse, error) {
+			planReq.Messages = messages
+			return config.Provider.Complete(ctx, planReq)
+		})
+		if agent.Aborted() {
+			agent.EndAborted(eventStream, "", startTime, turnUsage)
+			return
+		}
+		if err != nil && stopIfTimedOut() {
+			return
+		}
+		if err != nil {
+			agent.SetError(fmt.Sprintf("%v", err))
+			eventStream.Push(core.AgentEvent{Type: core.EventError, Payload: core.NewErrorPayload(err)})
+			eventStream.EndWithError(fmt.Errorf("plan-and-execute: planning call: %w", err))
+			return
+		}
+
+		planUsage := agent.Usage(planResp.Model, planResp.Usage)
+		turnUsage = planUsage
+		budget.AddUsage(planUsage)
+
+		if parsed, err := parsePlan(planResp.Text); err != nil {
+			agent.SetError(fmt.Sprintf("failed to parse plan: %v", err))
+			// Empty plan: skip execution, go to synthesis
+		} else {
+			plan = parsed
+		}
+
+		// Append assistant "plan" message for context (optional; synthesis will see tool results)
+		if planResp.Text != "" {
+			providerName := planResp.Provider
+			if providerName == "" && config.Provider != nil {
+				providerName = config.Provider.Name()
+			}
+			modelUsed := planResp.Model
+			if modelUsed == "" {
+				modelUsed = "unknown"
+			}
+			planBlocks := make([]types.ContentBlock, 0, len(planResp.Reasoning)+1)
+			for _, th := range core.ThinkingFromReasoning(planResp.Reasoning) {
+				planBlocks = append(planBlocks, th)
+			}
+			planBlocks = append(planBlocks, types.TextContent{Text: planResp.Text})
+			agent.AppendMessages(types.AssistantMessage{
+				Content:    planBlocks,
+				Provider:   providerName,
+				Model:      modelUsed,
+				Usage:      planUsage,
+				StopReason: types.StopReasonStop,
+			})
+		}
 	}
 
 	stepCount := len(plan.Steps)
@@ -82,27 +149,14 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 		Payload: core.PlanCreatedPayload{StepCount: stepCount, Steps: stepsInfo},
 	})
 
-	// Append assistant "plan" message for context (optional; synthesis will see tool results)
-	if planResp.Text != "" {
-		providerName := ""
-		if config.Provider != nil {
-			providerName = config.Provider.Name()
-		}
-		modelUsed := planResp.Model
-		if modelUsed == "" {
-			modelUsed = "unknown"
-		}
-		state.Messages = append(state.Messages, types.AssistantMessage{
-			Content:    []types.ContentBlock{types.TextContent{Text: planResp.Text}},
-			Provider:   providerName,
-			Model:      modelUsed,
-			StopReason: types.StopReasonStop,
-		})
-	}
-
 	// --- Execution phase ---
+	forced := false // a force-answer instruction was added
 	for i, step := range plan.Steps {
-		if ctx.Err() != nil {
+		if agent.Aborted() {
+			agent.EndAborted(eventStream, "", startTime, turnUsage)
+			return
+		}
+		if ctx.Err() != nil && !core.TimedOut(ctx) {
 			eventStream.EndWithError(ctx.Err())
 			return
 		}
@@ -113,6 +167,19 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 			Args:       step.Args,
 		}
 
+		// Over budget, the remaining steps are skipped: synthesis answers from the results so far, or
+		// the turn stops.
+		budget.AddToolCalls([]core.ToolCallRequest{toolCall})
+		if exceeded, over := overBudget(); over {
+			if exceeded.Stops() {
+				endBudgetStopped(agent, eventStream, exceeded, startTime, turnUsage)
+				return
+			}
+			agent.AppendMessages(core.ForceAnswerMessage(exceeded))
+			forced = true
+			break
+		}
+
 		eventStream.Push(core.AgentEvent{
 			Type: core.EventPlanStepStart,
 			Payload: core.PlanStepStartPayload{
@@ -131,7 +198,7 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 			},
 		})
 
-		toolResult := agent.ExecuteTool(ctx, toolCall)
+		toolResult := agent.ExecuteTools(ctx, []core.ToolCallRequest{toolCall})[0]
 
 		eventStream.Push(core.AgentEvent{
 			Type: core.EventToolResult,
@@ -153,11 +220,25 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 			},
 		})
 
-		state.Messages = append(state.Messages, toolResult)
+		agent.AppendMessages(toolResult)
 	}
 
 	// --- Synthesis phase ---
-	synthContext := state.ToContext().Clone()
+	if agent.Aborted() {
+		agent.EndAborted(eventStream, "", startTime, turnUsage)
+		return
+	}
+	// The budget is checked before the synthesis call too (e.g. tokens spent planning, or the timeout)
+	if !forced {
+		if exceeded, over := overBudget(); over {
+			if exceeded.Stops() {
+				endBudgetStopped(agent, eventStream, exceeded, startTime, turnUsage)
+				return
+			}
+			agent.AppendMessages(core.ForceAnswerMessage(exceeded))
+		}
+	}
+	synthContext := agent.Context()
 	synthMessages, err := buildProviderMessages(ctx, synthContext, config.Pipeline)
 	if err != nil {
 		eventStream.EndWithError(fmt.Errorf("plan-and-execute: synthesis messages: %w", err))
@@ -171,11 +252,34 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 		Temperature:  0.5,
 		MaxTokens:    1000,
 		Tools:        nil,
+		ToolChoice:   provider.DisableTools(), // the answer is text; tool results are already in context
 	}
 
-	synthResp, err := config.Provider.Complete(ctx, synthReq)
+	// Synthesis is streamed: text deltas reach the event stream as the model produces them.
+	var partial strings.Builder
+	emit := func(e core.AgentEvent) {
+		if p, ok := e.Payload.(core.TextDeltaPayload); ok && e.Type == core.EventTextDelta {
+			partial.WriteString(p.Text)
+		}
+		eventStream.Push(e)
+	}
+	agent.SetStreaming(true)
+	synthResp, err := completeWithCompaction(ctx, agent, eventStream, synthMessages, func(messages []types.Message) (*provider.CompletionResponse, error) {
+		synthReq.Messages = messages
+		partial.Reset()
+		return core.StreamCompletion(ctx, config.Provider, synthReq, emit)
+	})
+	agent.SetStreaming(false)
+	if agent.Aborted() {
+		agent.EndAborted(eventStream, partial.String(), startTime, turnUsage)
+		return
+	}
+	if err != nil && stopIfTimedOut() {
+		return
+	}
 	if err != nil {
 		agent.SetError(fmt.Sprintf("%v", err))
+		eventStream.Push(core.AgentEvent{Type: core.EventError, Payload: core.NewErrorPayload(err)})
 		eventStream.EndWithError(fmt.Errorf("plan-and-execute: synthesis call: %w", err))
 		return
 	}
@@ -183,37 +287,35 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 	responseText := synthResp.Text
 	if responseText == "" {
 		responseText = "I've completed the steps."
-	}
-
-	state.IsStreaming = true
-	words := strings.Split(responseText, " ")
-	for i, word := range words {
 		eventStream.Push(core.AgentEvent{
-			Type: core.EventTextDelta,
-			Payload: core.TextDeltaPayload{
-				Text:  word + " ",
-				Index: i,
-			},
+			Type:    core.EventTextDelta,
+			Payload: core.TextDeltaPayload{Text: responseText, Index: 0},
 		})
 	}
-	state.IsStreaming = false
-	state.StreamMessage = nil
 
-	providerName := ""
-	if config.Provider != nil {
+	providerName := synthResp.Provider
+	if providerName == "" && config.Provider != nil {
 		providerName = config.Provider.Name()
 	}
 	modelUsed := synthResp.Model
 	if modelUsed == "" {
 		modelUsed = "unknown"
 	}
+	synthUsage := agent.Usage(synthResp.Model, synthResp.Usage)
+	turnUsage = turnUsage.Add(synthUsage)
+	finalBlocks := make([]types.ContentBlock, 0, len(synthResp.Reasoning)+1)
+	for _, th := range core.ThinkingFromReasoning(synthResp.Reasoning) {
+		finalBlocks = append(finalBlocks, th)
+	}
+	finalBlocks = append(finalBlocks, types.TextContent{Text: responseText})
 	assistantMessage := types.AssistantMessage{
-		Content:    []types.ContentBlock{types.TextContent{Text: responseText}},
+		Content:    finalBlocks,
 		Provider:   providerName,
 		Model:      modelUsed,
+		Usage:      synthUsage,
 		StopReason: types.StopReasonStop,
 	}
-	state.Messages = append(state.Messages, assistantMessage)
+	agent.AppendMessages(assistantMessage)
 
 	duration := time.Since(startTime).Milliseconds()
 	eventStream.Push(core.AgentEvent{
@@ -221,10 +323,35 @@ func (o *PlanExecuteOrchestrator) Run(ctx context.Context, agent *core.Agent, us
 		Payload: core.TurnEndPayload{
 			Message:  assistantMessage,
 			Duration: duration,
+			Usage:    turnUsage,
 		},
 	})
 
-	eventStream.End(state.Messages)
+	eventStream.End(agent.Messages())
+}
+
+// endBudgetStopped ends a turn stopped by its budget with a message saying which limit stopped it.
+func endBudgetStopped(agent *core.Agent, eventStream *stream.EventStream[core.AgentEvent, []types.AgentMessage], exceeded core.BudgetExceededPayload, startTime time.Time, turnUsage types.UsageMetrics) {
+	providerName := ""
+	if p := agent.Config().Provider; p != nil {
+		providerName = p.Name()
+	}
+	assistantMessage := types.AssistantMessage{
+		Provider:   providerName,
+		Model:      "unknown",
+		Content:    []types.ContentBlock{types.TextContent{Text: core.BudgetStopText(exceeded)}},
+		StopReason: types.StopReasonBudgetExceeded,
+	}
+	agent.AppendMessages(assistantMessage)
+	eventStream.Push(core.AgentEvent{
+		Type: core.EventTurnEnd,
+		Payload: core.TurnEndPayload{
+			Message:  assistantMessage,
+			Duration: time.Since(startTime).Milliseconds(),
+			Usage:    turnUsage,
+		},
+	})
+	eventStream.End(agent.Messages())
 }
 
 // buildProviderMessages returns messages for the provider from agent context, using pipeline if set.
@@ -235,6 +362,25 @@ func buildProviderMessages(ctx context.Context, agentContext types.AgentContext,
 	return types.ConvertToLLM(agentContext.Messages), nil
 }
 
+// completeWithCompaction runs call with the given provider messages. When the provider reports that they
+// overflow the model's context window, the history is compacted and call retried once with rebuilt messages.
+func completeWithCompaction(ctx context.Context, agent *core.Agent, eventStream *stream.EventStream[core.AgentEvent, []types.AgentMessage], messages []types.Message, call func([]types.Message) (*provider.CompletionResponse, error)) (*provider.CompletionResponse, error) {
+	resp, err := call(messages)
+	if !errors.Is(err, provider.ErrContextLength) {
+		return resp, err
+	}
+	compacted, compactErr := agent.CompactHistory(ctx)
+	if compactErr != nil || compacted.After >= compacted.Before {
+		return resp, err
+	}
+	eventStream.Push(core.AgentEvent{Type: core.EventContextCompacted, Payload: compacted})
+	messages, err = buildProviderMessages(ctx, agent.Context(), agent.Config().Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return call(messages)
+}
+
 // buildPlanningPrompt returns the system prompt for the planning call (tool list + JSON format).
 func buildPlanningPrompt(basePrompt string, toolRegistry *tools.ToolRegistry) string {
 	if basePrompt == "" {
@@ -266,20 +412,14 @@ func buildSynthesisPrompt(basePrompt string) string {
 	return basePrompt + "\n\nYou have executed a plan and received tool results. Provide a concise, natural language summary for the user based on the conversation and the tool results. Do not repeat raw JSON or tool internals."
 }
 
-var jsonBlockRE = regexp.MustCompile("(?s)\\s*```(?:json)?\\s*([\\s\\S]*?)```\\s*")
-
-// parsePlan extracts a Plan from the LLM response text (JSON only; optional markdown code block stripped).
+// parsePlan extracts a Plan from the LLM response text and validates it against the plan schema
+// (a markdown code block around the JSON is tolerated).
 func parsePlan(text string) (*Plan, error) {
-	trimmed := strings.TrimSpace(text)
-	if trimmed == "" {
+	if strings.TrimSpace(text) == "" {
 		return &Plan{Steps: nil}, nil
 	}
-	// Strip markdown code block if present
-	if m := jsonBlockRE.FindStringSubmatch(trimmed); len(m) > 1 {
-		trimmed = strings.TrimSpace(m[1])
-	}
 	var plan Plan
-	if err := json.Unmarshal([]byte(trimmed), &plan); err != nil {
+	if err := provider.DecodeJSON(text, planFormat(nil, 0), &plan); err != nil {
 		return nil, err
 	}
 	if plan.Steps == nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/biome/agent-core/packages/agent/tools"
 	"github.com/biome/agent-core/packages/agent/types"
 	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
 )
 
 // --- parsePlan (via parsePlan - we test via Run or export parsePlan for test)
@@ -18,10 +19,7 @@ import (
 
 func TestParsePlanEmpty(t *testing.T) {
 	// Test by running full orchestrator with mock that returns {"steps":[]}
-	mock := &mockPlanExecuteProvider{
-		planResponse:    `{"steps":[]}`,
-		synthesisResponse: "No tools needed.",
-	}
+	mock := newPlanExecuteProvider(`{"steps":[]}`, "No tools needed.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -63,16 +61,13 @@ func TestParsePlanEmpty(t *testing.T) {
 	if toolCalls != 0 {
 		t.Errorf("Expected 0 tool calls for empty plan, got %d", toolCalls)
 	}
-	if mock.completeCount != 2 {
-		t.Errorf("Expected 2 Complete calls (plan + synthesis), got %d", mock.completeCount)
+	if mock.Calls() != 2 {
+		t.Errorf("Expected 2 provider calls (plan + synthesis), got %d", mock.Calls())
 	}
 }
 
 func TestParsePlanSingleStep(t *testing.T) {
-	mock := &mockPlanExecuteProvider{
-		planResponse:      `{"steps":[{"tool":"calculator","args":{"expression":"15*3"}}]}`,
-		synthesisResponse: "15 times 3 is 45.",
-	}
+	mock := newPlanExecuteProvider(`{"steps":[{"tool":"calculator","args":{"expression":"15*3"}}]}`, "15 times 3 is 45.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -106,13 +101,10 @@ func TestParsePlanSingleStep(t *testing.T) {
 }
 
 func TestParsePlanMultipleSteps(t *testing.T) {
-	mock := &mockPlanExecuteProvider{
-		planResponse: `{"steps":[
+	mock := newPlanExecuteProvider(`{"steps":[
 			{"tool":"calculator","args":{"expression":"1+1"}},
 			{"tool":"calculator","args":{"expression":"2+2"}}
-		]}`,
-		synthesisResponse: "Done.",
-	}
+		]}`, "Done.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -144,10 +136,7 @@ func TestParsePlanMultipleSteps(t *testing.T) {
 }
 
 func TestPlanStepEvents(t *testing.T) {
-	mock := &mockPlanExecuteProvider{
-		planResponse:      `{"steps":[{"tool":"calculator","args":{"expression":"5*2"}}]}`,
-		synthesisResponse: "The result is 10.",
-	}
+	mock := newPlanExecuteProvider(`{"steps":[{"tool":"calculator","args":{"expression":"5*2"}}]}`, "The result is 10.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -207,10 +196,7 @@ func TestPlanStepEvents(t *testing.T) {
 
 func TestParsePlanMarkdownBlock(t *testing.T) {
 	// parsePlan is unexported; test via Run with response wrapped in ```json ... ```
-	mock := &mockPlanExecuteProvider{
-		planResponse: "Here is the plan:\n```json\n{\"steps\":[{\"tool\":\"calculator\",\"args\":{\"expression\":\"3+3\"}}]}\n```",
-		synthesisResponse: "The result is 6.",
-	}
+	mock := newPlanExecuteProvider("Here is the plan:\n```json\n{\"steps\":[{\"tool\":\"calculator\",\"args\":{\"expression\":\"3+3\"}}]}\n```", "The result is 6.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -239,10 +225,7 @@ func TestParsePlanMarkdownBlock(t *testing.T) {
 
 func TestParsePlanInvalidJSONFallbackToEmpty(t *testing.T) {
 	// When plan JSON is invalid, orchestrator treats as empty plan and continues to synthesis.
-	mock := &mockPlanExecuteProvider{
-		planResponse:      "I will use the calculator.",
-		synthesisResponse: "I couldn't parse a plan, so here is a reply.",
-	}
+	mock := newPlanExecuteProvider("I will use the calculator.", "I couldn't parse a plan, so here is a reply.")
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
@@ -273,6 +256,83 @@ func TestParsePlanInvalidJSONFallbackToEmpty(t *testing.T) {
 	}
 }
 
+func TestPlanRequestUsesJSONSchemaFormat(t *testing.T) {
+	mock := newPlanExecuteProvider(`{"steps":[]}`, "Done.")
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "You are helpful.",
+		Provider:     mock,
+		Tools:        registry,
+		Orchestrator: planexecute.Default(),
+	})
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Hi"}},
+	})
+	for range stream.Events() {
+	}
+
+	reqs := mock.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("Expected planning and synthesis calls, got %d", len(reqs))
+	}
+	format := reqs[0].ResponseFormat
+	if format == nil || format.Type != provider.ResponseFormatJSONSchema || format.Name != "plan" {
+		t.Fatalf("Expected planning call to request the plan schema, got %+v", format)
+	}
+	if err := provider.ValidateResponse(format, `{"steps":[{"tool":"unknown","args":{}}]}`); err == nil {
+		t.Error("Expected plan schema to restrict steps to registered tools")
+	}
+	if reqs[1].ResponseFormat != nil {
+		t.Errorf("Expected synthesis call to use free text, got %+v", reqs[1].ResponseFormat)
+	}
+}
+
+func TestPlanFollowsToolChoice(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	// Tools disabled: no planning call, straight to synthesis.
+	mock := fake.New(fake.Text("Hello."))
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "You are helpful.",
+		Provider:     mock,
+		Tools:        registry,
+		Orchestrator: planexecute.Default(),
+	})
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Hi"}},
+	}, core.WithToolChoice(provider.DisableTools()))
+	for range stream.Events() {
+	}
+	if _, err := stream.Result(); err != nil {
+		t.Fatalf("Expected synthesis-only turn, got %v", err)
+	}
+	if reqs := mock.Requests(); len(reqs) != 1 || reqs[0].ResponseFormat != nil {
+		t.Fatalf("Expected only the synthesis call, got %d calls", len(reqs))
+	}
+
+	// Tools required: the plan schema demands at least one step.
+	mock = newPlanExecuteProvider(`{"steps":[{"tool":"calculator","args":{"expression":"1+1"}}]}`, "2")
+	agent = core.NewAgent(core.AgentConfig{
+		SystemPrompt: "You are helpful.",
+		Provider:     mock,
+		Tools:        registry,
+		Orchestrator: planexecute.Default(),
+		ToolChoice:   provider.RequireTools(),
+	})
+	stream = agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "1+1?"}},
+	})
+	for range stream.Events() {
+	}
+	if err := provider.ValidateResponse(mock.Requests()[0].ResponseFormat, `{"steps":[]}`); err == nil {
+		t.Error("Expected an empty plan to be rejected when tools are required")
+	}
+}
+
 func TestPlanExecuteNoProvider(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
@@ -304,27 +364,47 @@ func TestPlanExecuteNoProvider(t *testing.T) {
 	}
 }
 
-// mockPlanExecuteProvider returns plan JSON on first Complete, synthesis text on second.
-type mockPlanExecuteProvider struct {
-	completeCount     int
-	planResponse     string
-	synthesisResponse string
+// newPlanExecuteProvider returns a scripted provider answering the planning call with planResponse and the synthesis call with synthesisResponse.
+func newPlanExecuteProvider(planResponse, synthesisResponse string) *fake.Provider {
+	return fake.New(fake.Text(planResponse), fake.Text(synthesisResponse)).WithName("mockPlanExecute")
 }
 
-func (m *mockPlanExecuteProvider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
-	m.completeCount++
-	if m.completeCount == 1 {
-		return &provider.CompletionResponse{Text: m.planResponse, Model: "mock"}, nil
-	}
-	return &provider.CompletionResponse{Text: m.synthesisResponse, Model: "mock"}, nil
-}
+func TestPlanStopsAtToolCallBudget(t *testing.T) {
+	mock := newPlanExecuteProvider(`{"steps":[{"tool":"calculator","args":{"expression":"1+1"}},{"tool":"calculator","args":{"expression":"2+2"}},{"tool":"calculator","args":{"expression":"3+3"}}]}`, "Partial result.")
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+	agent := core.NewAgent(core.AgentConfig{
+		Provider:     mock,
+		Tools:        registry,
+		Orchestrator: planexecute.Default(),
+		Budget:       core.TurnBudget{MaxToolCalls: 2},
+	})
 
-func (m *mockPlanExecuteProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
-	ch := make(chan provider.StreamEvent, 1)
-	ch <- provider.StreamEvent{Type: provider.EventDone}
-	close(ch)
-	return ch, nil
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Add things up"}},
+	})
+	steps := 0
+	var exceeded []core.BudgetExceededPayload
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventPlanStepEnd:
+			steps++
+		case core.EventBudgetExceeded:
+			exceeded = append(exceeded, event.Payload.(core.BudgetExceededPayload))
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+	if steps != 2 {
+		t.Errorf("expected the third step skipped, got %d steps", steps)
+	}
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitToolCalls || exceeded[0].Action != "force_answer" {
+		t.Errorf("unexpected budget events %+v", exceeded)
+	}
+	last := messages[len(messages)-1].(types.AssistantMessage)
+	if last.StopReason != types.StopReasonStop || last.Content[0].(types.TextContent).Text != "Partial result." {
+		t.Errorf("expected the synthesized answer, got %+v", last)
+	}
 }
-
-func (m *mockPlanExecuteProvider) Name() string     { return "mockPlanExecute" }
-func (m *mockPlanExecuteProvider) Models() []string { return nil }
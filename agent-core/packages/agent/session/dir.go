@@ -0,0 +1,276 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+const (
+	dirSessionFile  = "session.json"
+	dirMessagesFile = "messages.jsonl"
+)
+
+// DirStore keeps each session in its own directory under a root: session.json describes the session
+// and messages.jsonl holds one encoded message per line, so appending a message writes one line. A
+// line cut off by a crash is ignored. One process may use a root at a time.
+type DirStore struct {
+	mu   sync.Mutex
+	root string
+}
+
+// NewDirStore returns a store under root, creating the directory if needed.
+func NewDirStore(root string) (*DirStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{root: root}, nil
+}
+
+func (d *DirStore) Create(_ context.Context, id string, metadata map[string]string) (Session, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if id == "" {
+		id = NewID()
+	}
+	dir, err := d.dir(id)
+	if err != nil {
+		return Session{}, err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return Session{}, ErrExists
+		}
+		return Session{}, err
+	}
+	at := now()
+	s := Session{ID: id, Metadata: copyMetadata(metadata), CreatedAt: at, UpdatedAt: at}
+	if err := os.WriteFile(filepath.Join(dir, dirMessagesFile), nil, 0o644); err != nil {
+		return Session{}, err
+	}
+	if err := d.writeSession(s); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+func (d *DirStore) Get(_ context.Context, id string) (Session, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.readSession(id)
+}
+
+func (d *DirStore) List(ctx context.Context) ([]Session, error) {
+	return d.Search(ctx, nil)
+}
+
+func (d *DirStore) Search(_ context.Context, match map[string]string) ([]Session, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entries, err := os.ReadDir(d.root)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]Session, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := d.readSession(e.Name())
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidID) {
+			continue // not a session directory
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, s)
+	}
+	return search(all, match), nil
+}
+
+func (d *DirStore) SetMetadata(_ context.Context, id string, metadata map[string]string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSession(id)
+	if err != nil {
+		return err
+	}
+	s.Metadata, s.UpdatedAt = copyMetadata(metadata), now()
+	return d.writeSession(s)
+}
+
+func (d *DirStore) Delete(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.readSession(id); err != nil {
+		return err
+	}
+	dir, _ := d.dir(id)
+	return os.RemoveAll(dir)
+}
+
+func (d *DirStore) Load(_ context.Context, id string) ([]types.AgentMessage, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.readSession(id); err != nil {
+		return nil, err
+	}
+	dir, _ := d.dir(id)
+	file, err := os.Open(filepath.Join(dir, dirMessagesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var messages []types.AgentMessage
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return messages, nil // a last line without a newline was cut off
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		m, err := types.UnmarshalMessage(line)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+}
+
+func (d *DirStore) Append(_ context.Context, id string, messages ...types.AgentMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSession(id)
+	if err != nil {
+		return err
+	}
+	lines, err := encodeLines(messages)
+	if err != nil {
+		return err
+	}
+	dir, _ := d.dir(id)
+	file, err := os.OpenFile(filepath.Join(dir, dirMessagesFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	err = dropCutLine(file)
+	if err == nil {
+		_, err = file.Write(lines)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	s.MessageCount, s.UpdatedAt = s.MessageCount+len(messages), now()
+	return d.writeSession(s)
+}
+
+func (d *DirStore) Replace(_ context.Context, id string, messages []types.AgentMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, err := d.readSession(id)
+	if err != nil {
+		return err
+	}
+	lines, err := encodeLines(messages)
+	if err != nil {
+		return err
+	}
+	dir, _ := d.dir(id)
+	if err := writeFileAtomic(filepath.Join(dir, dirMessagesFile), lines); err != nil {
+		return err
+	}
+	s.MessageCount, s.UpdatedAt = len(messages), now()
+	return d.writeSession(s)
+}
+
+// dir returns the directory of session id, rejecting IDs that are not a single path element
+func (d *DirStore) dir(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) || filepath.Base(id) != id {
+		return "", ErrInvalidID
+	}
+	return filepath.Join(d.root, id), nil
+}
+
+func (d *DirStore) readSession(id string) (Session, error) {
+	dir, err := d.dir(id)
+	if err != nil {
+		return Session{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, dirSessionFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Session{}, ErrNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+func (d *DirStore) writeSession(s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	dir, err := d.dir(s.ID)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, dirSessionFile), data)
+}
+
+// dropCutLine truncates a last line without a newline, left by a crash, so appended lines start clean
+func dropCutLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil || last[0] == '\n' {
+		return err
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, info.Size()))
+	if err != nil {
+		return err
+	}
+	return file.Truncate(int64(bytes.LastIndexByte(data, '\n') + 1))
+}
+
+// encodeLines encodes messages one per line
+func encodeLines(messages []types.AgentMessage) ([]byte, error) {
+	encoded, err := encodeMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, data := range encoded {
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,278 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+// FileStore keeps every session in one JSONL file. Each line records a write (create, append, replace
+// or metadata) and the file is replayed into memory when the store is opened; Delete rewrites the file
+// without the session. A line cut off by a crash is dropped on open. One process may use a file at a time.
+type FileStore struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	sessions sessions
+}
+
+// record is one line of a FileStore file
+type record struct {
+	Op       string            `json:"op"` // "create", "append", "replace" or "metadata"
+	ID       string            `json:"id"`
+	At       int64             `json:"at"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Messages []json.RawMessage `json:"messages,omitempty"`
+}
+
+// NewFileStore opens the store in the file at path, creating the file if needed. Close it when done.
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileStore{path: path, file: file, sessions: sessions{}}
+	if err := f.replay(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("session: read %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Close closes the file.
+func (f *FileStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+func (f *FileStore) Create(_ context.Context, id string, metadata map[string]string) (Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id == "" {
+		id = NewID()
+	}
+	if _, ok := f.sessions[id]; ok {
+		return Session{}, ErrExists
+	}
+	rec := record{Op: "create", ID: id, At: now(), Metadata: metadata}
+	if err := f.write(rec); err != nil {
+		return Session{}, err
+	}
+	return f.sessions.create(id, metadata, rec.At)
+}
+
+func (f *FileStore) Get(_ context.Context, id string) (Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sessions.get(id)
+}
+
+func (f *FileStore) List(ctx context.Context) ([]Session, error) {
+	return f.Search(ctx, nil)
+}
+
+func (f *FileStore) Search(_ context.Context, match map[string]string) ([]Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return search(f.sessions.all(), match), nil
+}
+
+func (f *FileStore) SetMetadata(_ context.Context, id string, metadata map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	rec := record{Op: "metadata", ID: id, At: now(), Metadata: metadata}
+	if err := f.write(rec); err != nil {
+		return err
+	}
+	return f.sessions.setMetadata(id, metadata, rec.At)
+}
+
+func (f *FileStore) Delete(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	kept := make(sessions, len(f.sessions))
+	for k, e := range f.sessions {
+		if k != id {
+			kept[k] = e
+		}
+	}
+	if err := f.rewrite(kept); err != nil {
+		return err
+	}
+	f.sessions = kept
+	return nil
+}
+
+func (f *FileStore) Load(_ context.Context, id string) ([]types.AgentMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sessions.load(id)
+}
+
+func (f *FileStore) Append(_ context.Context, id string, messages ...types.AgentMessage) error {
+	return f.update("append", id, messages, f.sessions.append)
+}
+
+func (f *FileStore) Replace(_ context.Context, id string, messages []types.AgentMessage) error {
+	return f.update("replace", id, messages, f.sessions.replace)
+}
+
+// update records a write of messages and applies it to the sessions in memory
+func (f *FileStore) update(op, id string, messages []types.AgentMessage, apply func(string, []types.AgentMessage, int64) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	encoded, err := encodeMessages(messages)
+	if err != nil {
+		return err
+	}
+	rec := record{Op: op, ID: id, At: now(), Messages: encoded}
+	if err := f.write(rec); err != nil {
+		return err
+	}
+	return apply(id, messages, rec.At)
+}
+
+// write appends rec as one line
+func (f *FileStore) write(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// replay reads the file into f.sessions, truncating a cut-off last line
+func (f *FileStore) replay() error {
+	r := bufio.NewReader(f.file)
+	var offset int64
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				return f.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := f.apply(line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+}
+
+// apply applies one recorded write to f.sessions
+func (f *FileStore) apply(line []byte) error {
+	var rec record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case "create":
+		_, err := f.sessions.create(rec.ID, rec.Metadata, rec.At)
+		return err
+	case "metadata":
+		return f.sessions.setMetadata(rec.ID, rec.Metadata, rec.At)
+	case "append", "replace":
+		messages, err := decodeMessages(rec.Messages)
+		if err != nil {
+			return err
+		}
+		if rec.Op == "append" {
+			return f.sessions.append(rec.ID, messages, rec.At)
+		}
+		return f.sessions.replace(rec.ID, messages, rec.At)
+	}
+	return fmt.Errorf("unknown op %q", rec.Op)
+}
+
+// rewrite replaces the file with one holding only kept: a create and a replace record per session
+func (f *FileStore) rewrite(kept sessions) error {
+	var buf bytes.Buffer
+	for id, e := range kept {
+		encoded, err := encodeMessages(e.messages)
+		if err != nil {
+			return err
+		}
+		for _, rec := range []record{
+			{Op: "create", ID: id, At: e.info.CreatedAt, Metadata: e.info.Metadata},
+			{Op: "replace", ID: id, At: e.info.UpdatedAt, Messages: encoded},
+		} {
+			line, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			buf.Write(append(line, '\n'))
+		}
+	}
+	if err := writeFileAtomic(f.path, buf.Bytes()); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	f.file.Close()
+	f.file = file
+	return nil
+}
+
+func encodeMessages(messages []types.AgentMessage) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(messages))
+	for i, m := range messages {
+		data, err := types.MarshalMessage(m)
+		if err != nil {
+			return nil, fmt.Errorf("session: message %d: %w", i, err)
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+func decodeMessages(raw []json.RawMessage) ([]types.AgentMessage, error) {
+	out := make([]types.AgentMessage, len(raw))
+	for i, data := range raw {
+		m, err := types.UnmarshalMessage(data)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		out[i] = m
+	}
+	return out, nil
+}
+
+// writeFileAtomic replaces the file at path with data, so a crash leaves the old or the new content
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
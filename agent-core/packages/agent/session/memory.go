@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+// MemoryStore keeps sessions in memory, e.g. for tests or a single process that may lose them.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions sessions
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: sessions{}}
+}
+
+func (m *MemoryStore) Create(_ context.Context, id string, metadata map[string]string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.create(id, metadata, now())
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.get(id)
+}
+
+func (m *MemoryStore) List(ctx context.Context) ([]Session, error) {
+	return m.Search(ctx, nil)
+}
+
+func (m *MemoryStore) Search(_ context.Context, match map[string]string) ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return search(m.sessions.all(), match), nil
+}
+
+func (m *MemoryStore) SetMetadata(_ context.Context, id string, metadata map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.setMetadata(id, metadata, now())
+}
+
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.delete(id)
+}
+
+func (m *MemoryStore) Load(_ context.Context, id string) ([]types.AgentMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.load(id)
+}
+
+func (m *MemoryStore) Append(_ context.Context, id string, messages ...types.AgentMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.append(id, messages, now())
+}
+
+func (m *MemoryStore) Replace(_ context.Context, id string, messages []types.AgentMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions.replace(id, messages, now())
+}
+
+// sessions is the in-memory state of MemoryStore and FileStore; the store's lock must be held
+type sessions map[string]*entry
+
+type entry struct {
+	info     Session
+	messages []types.AgentMessage
+}
+
+func (s sessions) create(id string, metadata map[string]string, at int64) (Session, error) {
+	if id == "" {
+		id = NewID()
+	}
+	if _, ok := s[id]; ok {
+		return Session{}, ErrExists
+	}
+	e := &entry{info: Session{ID: id, Metadata: copyMetadata(metadata), CreatedAt: at, UpdatedAt: at}}
+	s[id] = e
+	return e.session(), nil
+}
+
+func (s sessions) get(id string) (Session, error) {
+	e, ok := s[id]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	return e.session(), nil
+}
+
+func (s sessions) all() []Session {
+	out := make([]Session, 0, len(s))
+	for _, e := range s {
+		out = append(out, e.session())
+	}
+	return out
+}
+
+func (s sessions) setMetadata(id string, metadata map[string]string, at int64) error {
+	e, ok := s[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.info.Metadata, e.info.UpdatedAt = copyMetadata(metadata), at
+	return nil
+}
+
+func (s sessions) delete(id string) error {
+	if _, ok := s[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s, id)
+	return nil
+}
+
+func (s sessions) load(id string) ([]types.AgentMessage, error) {
+	e, ok := s[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]types.AgentMessage(nil), e.messages...), nil
+}
+
+func (s sessions) append(id string, messages []types.AgentMessage, at int64) error {
+	e, ok := s[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.messages = append(e.messages, messages...)
+	e.info.MessageCount, e.info.UpdatedAt = len(e.messages), at
+	return nil
+}
+
+func (s sessions) replace(id string, messages []types.AgentMessage, at int64) error {
+	e, ok := s[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.messages = append([]types.AgentMessage(nil), messages...)
+	e.info.MessageCount, e.info.UpdatedAt = len(e.messages), at
+	return nil
+}
+
+// session returns a copy of the entry's description
+func (e *entry) session() Session {
+	out := e.info
+	out.Metadata = copyMetadata(e.info.Metadata)
+	return out
+}
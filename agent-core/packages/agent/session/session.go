@@ -0,0 +1,107 @@
+// Package session persists conversations. A Store keeps sessions (an ID, metadata and the messages of
+// one conversation); core.Agent.LoadSession attaches one to an agent so its history survives a restart.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+var (
+	// ErrNotFound is returned for a session that does not exist.
+	ErrNotFound = errors.New("session: not found")
+	// ErrExists is returned by Create for an ID that is taken.
+	ErrExists = errors.New("session: already exists")
+	// ErrInvalidID is returned for an ID a store cannot use (e.g. one with a path separator in DirStore).
+	ErrInvalidID = errors.New("session: invalid id")
+)
+
+// Session describes a stored conversation.
+type Session struct {
+	ID string `json:"id"`
+	// Metadata is set by the application (e.g. user, channel or title) and searched by Store.Search.
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CreatedAt    int64             `json:"created_at"` // unix milliseconds
+	UpdatedAt    int64             `json:"updated_at"` // unix milliseconds; changes on every write
+	MessageCount int               `json:"message_count"`
+}
+
+// Store persists sessions. Messages are stored with the types message codec, so custom message types
+// must be registered (types.RegisterMessageType). Implementations are safe for concurrent use.
+type Store interface {
+	// Create starts an empty session. An empty id gets a new one (NewID).
+	Create(ctx context.Context, id string, metadata map[string]string) (Session, error)
+	// Get describes a session.
+	Get(ctx context.Context, id string) (Session, error)
+	// List describes every session, most recently updated first.
+	List(ctx context.Context) ([]Session, error)
+	// Search describes the sessions whose metadata has every key of match with the same value, most
+	// recently updated first. An empty match lists every session.
+	Search(ctx context.Context, match map[string]string) ([]Session, error)
+	// SetMetadata replaces a session's metadata.
+	SetMetadata(ctx context.Context, id string, metadata map[string]string) error
+	// Delete removes a session and its messages.
+	Delete(ctx context.Context, id string) error
+
+	// Load returns a session's messages in order.
+	Load(ctx context.Context, id string) ([]types.AgentMessage, error)
+	// Append adds messages to the end of a session.
+	Append(ctx context.Context, id string, messages ...types.AgentMessage) error
+	// Replace replaces a session's messages (e.g. with a compacted history).
+	Replace(ctx context.Context, id string, messages []types.AgentMessage) error
+}
+
+// NewID returns a new random session ID ("ses_" followed by 24 hex characters).
+func NewID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return "ses_" + hex.EncodeToString(b[:])
+}
+
+// Matches reports whether metadata has every key of match with the same value.
+func Matches(metadata, match map[string]string) bool {
+	for k, v := range match {
+		if got, ok := metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// search filters sessions by match and sorts them most recently updated first
+func search(all []Session, match map[string]string) []Session {
+	out := make([]Session, 0, len(all))
+	for _, s := range all {
+		if Matches(s.Metadata, match) {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].UpdatedAt != out[j].UpdatedAt {
+			return out[i].UpdatedAt > out[j].UpdatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func copyMetadata(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
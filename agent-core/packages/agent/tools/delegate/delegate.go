@@ -213,6 +213,9 @@ func compactEvent(e core.AgentEvent) string {
 	case core.EventThinking:
 		if p, ok := e.Payload.(core.ThinkingPayload); ok {
 			s := p.Text
+			if s == "" {
+				s = p.Reasoning
+			}
 			if len(s) > maxThinkingLen {
 				s = s[:maxThinkingLen] + "..."
 			}
@@ -2,6 +2,7 @@ package transform
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/biome/agent-core/packages/agent/types"
 )
@@ -50,4 +51,31 @@ func ChainTrainsform(transforms ...TransformFunc) TransformFunc { // doubt: what
 
 		return result, nil
 	}
-}
\ No newline at end of file
+}
+
+// CompactHistory drops the older half of the conversation so it fits a smaller context window. The first
+// message (the original request) is kept and the kept tail starts at a user or control message, so tool
+// calls stay paired with their results; a control note tells the model how many messages were removed.
+// Messages are returned unchanged when there is no such boundary that removes at least two messages.
+func CompactHistory() TransformFunc {
+	return func(ctx context.Context, messages []types.AgentMessage) ([]types.AgentMessage, error) {
+		cut := -1
+		for i := 1 + (len(messages)-1)/2; i < len(messages) && cut < 0; i++ {
+			switch messages[i].(type) {
+			case types.UserMessage, types.ControlMessage:
+				cut = i
+			}
+		}
+		if cut < 3 {
+			return messages, nil
+		}
+
+		note := types.ControlMessage{Content: []types.ContentBlock{types.TextContent{
+			Text: fmt.Sprintf("[%d earlier messages were removed to fit the context window.]", cut-1),
+		}}}
+		result := make([]types.AgentMessage, 0, len(messages)-cut+2)
+		result = append(result, messages[0], note)
+		result = append(result, messages[cut:]...)
+		return result, nil
+	}
+}
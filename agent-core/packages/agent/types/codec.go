@@ -0,0 +1,352 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CodecVersion is the version of the JSON encoding of messages, written as "v" in every encoded
+// message. Decoding rejects newer versions; a missing version is read as the current one.
+const CodecVersion = 1
+
+var (
+	// ErrUnknownMessage is returned for a message whose role has no codec (see RegisterMessageType).
+	ErrUnknownMessage = errors.New("types: unknown message role")
+	// ErrUnknownContent is returned for a content block whose type has no codec.
+	ErrUnknownContent = errors.New("types: unknown content type")
+)
+
+// messageJSON is the encoding of every message type, discriminated by Role
+type messageJSON struct {
+	Version      int                    `json:"v"`
+	Role         string                 `json:"role"`
+	ID           string                 `json:"id,omitempty"`
+	CreatedAt    int64                  `json:"created_at,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Content      contentList            `json:"content,omitempty"`
+	ToolCallID   string                 `json:"tool_call_id,omitempty"`
+	ToolName     string                 `json:"tool_name,omitempty"`
+	Arguments    interface{}            `json:"arguments,omitempty"`
+	Details      interface{}            `json:"details,omitempty"`
+	IsError      bool                   `json:"is_error,omitempty"`
+	API          string                 `json:"api,omitempty"`
+	Provider     string                 `json:"provider,omitempty"`
+	Model        string                 `json:"model,omitempty"`
+	Usage        *usageJSON             `json:"usage,omitempty"`
+	StopReason   StopReason             `json:"stop_reason,omitempty"`
+	ErrorMessage *string                `json:"error_message,omitempty"`
+	// Data is the JSON of a registered custom message
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// contentJSON is the encoding of every content block type, discriminated by Type
+type contentJSON struct {
+	Type      string      `json:"type"`
+	Text      string      `json:"text,omitempty"`
+	Data      string      `json:"data,omitempty"`
+	MimeType  string      `json:"mime_type,omitempty"`
+	Thinking  string      `json:"thinking,omitempty"`
+	Signature string      `json:"signature,omitempty"`
+	ID        string      `json:"id,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type usageJSON struct {
+	Input       int      `json:"input"`
+	Output      int      `json:"output"`
+	CacheRead   int      `json:"cache_read"`
+	CacheWrite  int      `json:"cache_write"`
+	TotalTokens int      `json:"total_tokens"`
+	Cost        costJSON `json:"cost"`
+}
+
+type costJSON struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+	Total      float64 `json:"total"`
+}
+
+// contentList encodes each block with its type tag
+type contentList []ContentBlock
+
+var (
+	customMu       sync.RWMutex
+	customMessages = map[string]func(data json.RawMessage) (AgentMessage, error){}
+)
+
+// RegisterMessageType makes custom messages with the given role encodable: they are written with their
+// role tag and their own JSON encoding under "data", and decoded with decode. The custom type must not
+// encode itself with MarshalMessage. Built-in roles cannot be registered.
+func RegisterMessageType(role string, decode func(data json.RawMessage) (AgentMessage, error)) {
+	customMu.Lock()
+	defer customMu.Unlock()
+	customMessages[role] = decode
+}
+
+func customDecoder(role string) func(data json.RawMessage) (AgentMessage, error) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	return customMessages[role]
+}
+
+// MarshalMessage encodes a message as JSON tagged with its role and the codec version. Content blocks
+// are tagged with their type. Arguments, Details and Metadata values are encoded as plain JSON.
+func MarshalMessage(m AgentMessage) ([]byte, error) {
+	w := messageJSON{Version: CodecVersion}
+	switch v := m.(type) {
+	case UserMessage:
+		w.Role, w.ID, w.CreatedAt, w.Metadata, w.Content = v.Role(), v.ID, v.CreatedAt, v.Metadata, v.Content
+	case AssistantMessage:
+		w.Role, w.ID, w.CreatedAt, w.Metadata, w.Content = v.Role(), v.ID, v.CreatedAt, v.Metadata, v.Content
+		w.API, w.Provider, w.Model, w.StopReason, w.ErrorMessage = v.API, v.Provider, v.Model, v.StopReason, v.ErrorMessage
+		w.Usage = toUsageJSON(v.Usage)
+	case ToolCallMessage:
+		w.Role, w.ID, w.CreatedAt, w.Metadata, w.Content = v.Role(), v.ID, v.CreatedAt, v.Metadata, v.Content
+		w.ToolCallID, w.ToolName, w.Arguments = v.ToolCallID, v.ToolName, v.Arguments
+	case ToolResultMessage:
+		w.Role, w.ID, w.CreatedAt, w.Metadata, w.Content = v.Role(), v.ID, v.CreatedAt, v.Metadata, v.Content
+		w.ToolCallID, w.ToolName, w.Details, w.IsError = v.ToolCallID, v.ToolName, v.Details, v.IsError
+	case ControlMessage:
+		w.Role, w.ID, w.CreatedAt, w.Metadata, w.Content = v.Role(), v.ID, v.CreatedAt, v.Metadata, v.Content
+	case nil:
+		return nil, fmt.Errorf("%w: nil message", ErrUnknownMessage)
+	default:
+		if customDecoder(m.Role()) == nil {
+			return nil, fmt.Errorf("%w %q (%T)", ErrUnknownMessage, m.Role(), m)
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		w.Role, w.Data = m.Role(), data
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalMessage decodes a message encoded by MarshalMessage into its concrete type.
+func UnmarshalMessage(data []byte) (AgentMessage, error) {
+	var w messageJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if w.Version > CodecVersion {
+		return nil, fmt.Errorf("types: message codec version %d is newer than supported version %d", w.Version, CodecVersion)
+	}
+	switch w.Role {
+	case "user":
+		return UserMessage{Content: w.Content, ID: w.ID, CreatedAt: w.CreatedAt, Metadata: w.Metadata}, nil
+	case "assistant":
+		return AssistantMessage{
+			Content:      w.Content,
+			API:          w.API,
+			Provider:     w.Provider,
+			Model:        w.Model,
+			Usage:        fromUsageJSON(w.Usage),
+			StopReason:   w.StopReason,
+			ErrorMessage: w.ErrorMessage,
+			ID:           w.ID,
+			CreatedAt:    w.CreatedAt,
+			Metadata:     w.Metadata,
+		}, nil
+	case "toolCall":
+		return ToolCallMessage{Content: w.Content, ToolCallID: w.ToolCallID, ToolName: w.ToolName, Arguments: w.Arguments, ID: w.ID, CreatedAt: w.CreatedAt, Metadata: w.Metadata}, nil
+	case "toolResult":
+		return ToolResultMessage{Content: w.Content, ToolCallID: w.ToolCallID, ToolName: w.ToolName, Details: w.Details, IsError: w.IsError, ID: w.ID, CreatedAt: w.CreatedAt, Metadata: w.Metadata}, nil
+	case "control":
+		return ControlMessage{Content: w.Content, ID: w.ID, CreatedAt: w.CreatedAt, Metadata: w.Metadata}, nil
+	}
+	if decode := customDecoder(w.Role); decode != nil {
+		return decode(w.Data)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownMessage, w.Role)
+}
+
+// MarshalMessages encodes messages as a JSON array of MarshalMessage encodings.
+func MarshalMessages(messages []AgentMessage) ([]byte, error) {
+	raw := make([]json.RawMessage, len(messages))
+	for i, m := range messages {
+		data, err := MarshalMessage(m)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		raw[i] = data
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalMessages decodes a JSON array of encoded messages.
+func UnmarshalMessages(data []byte) ([]AgentMessage, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	messages := make([]AgentMessage, len(raw))
+	for i, r := range raw {
+		m, err := UnmarshalMessage(r)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		messages[i] = m
+	}
+	return messages, nil
+}
+
+// MarshalContent encodes a content block as JSON tagged with its type.
+func MarshalContent(block ContentBlock) ([]byte, error) {
+	w, err := toContentJSON(block)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalContent decodes a content block encoded by MarshalContent into its concrete type.
+func UnmarshalContent(data []byte) (ContentBlock, error) {
+	var w contentJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	switch w.Type {
+	case "text":
+		return TextContent{Text: w.Text}, nil
+	case "image":
+		return ImageContent{Data: w.Data, MimeType: w.MimeType}, nil
+	case "thinking":
+		return ThinkingContent{Thinking: w.Thinking, Signature: w.Signature}, nil
+	case "toolCall":
+		return ToolCallContent{ID: w.ID, Name: w.Name, Arguments: w.Arguments}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownContent, w.Type)
+}
+
+func toContentJSON(block ContentBlock) (contentJSON, error) {
+	switch b := block.(type) {
+	case TextContent:
+		return contentJSON{Type: b.ContentType(), Text: b.Text}, nil
+	case ImageContent:
+		return contentJSON{Type: b.ContentType(), Data: b.Data, MimeType: b.MimeType}, nil
+	case ThinkingContent:
+		return contentJSON{Type: b.ContentType(), Thinking: b.Thinking, Signature: b.Signature}, nil
+	case ToolCallContent:
+		return contentJSON{Type: b.ContentType(), ID: b.ID, Name: b.Name, Arguments: b.Arguments}, nil
+	case nil:
+		return contentJSON{}, fmt.Errorf("%w: nil content block", ErrUnknownContent)
+	}
+	return contentJSON{}, fmt.Errorf("%w %q (%T)", ErrUnknownContent, block.ContentType(), block)
+}
+
+func (l contentList) MarshalJSON() ([]byte, error) {
+	out := make([]contentJSON, len(l))
+	for i, block := range l {
+		w, err := toContentJSON(block)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = w
+	}
+	return json.Marshal(out)
+}
+
+func (l *contentList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*l = nil
+		return nil
+	}
+	blocks := make(contentList, len(raw))
+	for i, r := range raw {
+		block, err := UnmarshalContent(r)
+		if err != nil {
+			return err
+		}
+		blocks[i] = block
+	}
+	*l = blocks
+	return nil
+}
+
+func toUsageJSON(u UsageMetrics) *usageJSON {
+	return &usageJSON{
+		Input:       u.Input,
+		Output:      u.Output,
+		CacheRead:   u.CacheRead,
+		CacheWrite:  u.CacheWrite,
+		TotalTokens: u.TotalTokens,
+		Cost:        costJSON(u.Cost),
+	}
+}
+
+func fromUsageJSON(u *usageJSON) UsageMetrics {
+	if u == nil {
+		return UsageMetrics{}
+	}
+	return UsageMetrics{
+		Input:       u.Input,
+		Output:      u.Output,
+		CacheRead:   u.CacheRead,
+		CacheWrite:  u.CacheWrite,
+		TotalTokens: u.TotalTokens,
+		Cost:        Cost(u.Cost),
+	}
+}
+
+// decodeMessageAs decodes data into *dst, failing when it holds another message type
+func decodeMessageAs[T AgentMessage](data []byte, dst *T) error {
+	m, err := UnmarshalMessage(data)
+	if err != nil {
+		return err
+	}
+	v, ok := m.(T)
+	if !ok {
+		return fmt.Errorf("types: cannot decode a %q message into %T", m.Role(), *dst)
+	}
+	*dst = v
+	return nil
+}
+
+// decodeContentAs decodes data into *dst, failing when it holds another content type
+func decodeContentAs[T ContentBlock](data []byte, dst *T) error {
+	block, err := UnmarshalContent(data)
+	if err != nil {
+		return err
+	}
+	v, ok := block.(T)
+	if !ok {
+		return fmt.Errorf("types: cannot decode a %q block into %T", block.ContentType(), *dst)
+	}
+	*dst = v
+	return nil
+}
+
+// JSON methods: every message and content block type encodes with the codec, so encoding/json
+// (e.g. of a []AgentMessage or an event payload) keeps the concrete types.
+
+func (u UserMessage) MarshalJSON() ([]byte, error)        { return MarshalMessage(u) }
+func (a AssistantMessage) MarshalJSON() ([]byte, error)   { return MarshalMessage(a) }
+func (tc ToolCallMessage) MarshalJSON() ([]byte, error)   { return MarshalMessage(tc) }
+func (tr ToolResultMessage) MarshalJSON() ([]byte, error) { return MarshalMessage(tr) }
+func (c ControlMessage) MarshalJSON() ([]byte, error)     { return MarshalMessage(c) }
+
+func (u *UserMessage) UnmarshalJSON(data []byte) error        { return decodeMessageAs(data, u) }
+func (a *AssistantMessage) UnmarshalJSON(data []byte) error   { return decodeMessageAs(data, a) }
+func (tc *ToolCallMessage) UnmarshalJSON(data []byte) error   { return decodeMessageAs(data, tc) }
+func (tr *ToolResultMessage) UnmarshalJSON(data []byte) error { return decodeMessageAs(data, tr) }
+func (c *ControlMessage) UnmarshalJSON(data []byte) error     { return decodeMessageAs(data, c) }
+
+func (tc TextContent) MarshalJSON() ([]byte, error)      { return MarshalContent(tc) }
+func (ic ImageContent) MarshalJSON() ([]byte, error)     { return MarshalContent(ic) }
+func (thc ThinkingContent) MarshalJSON() ([]byte, error) { return MarshalContent(thc) }
+func (tcc ToolCallContent) MarshalJSON() ([]byte, error) { return MarshalContent(tcc) }
+
+func (tc *TextContent) UnmarshalJSON(data []byte) error      { return decodeContentAs(data, tc) }
+func (ic *ImageContent) UnmarshalJSON(data []byte) error     { return decodeContentAs(data, ic) }
+func (thc *ThinkingContent) UnmarshalJSON(data []byte) error { return decodeContentAs(data, thc) }
+func (tcc *ToolCallContent) UnmarshalJSON(data []byte) error { return decodeContentAs(data, tcc) }
@@ -17,6 +17,8 @@ type ImageContent struct {
 
 type ThinkingContent struct {
 	Thinking string
+	// Signature is an opaque provider token (e.g. Anthropic thinking signature) required to replay the block.
+	Signature string
 }
 
 type ToolCallContent struct {
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// NewMessageID returns a new random message ID ("msg_" followed by 24 hex characters).
+func NewMessageID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return "msg_" + hex.EncodeToString(b[:])
+}
+
+// NewUserMessage returns a user message with a new ID, created now.
+func NewUserMessage(content ...ContentBlock) UserMessage {
+	return UserMessage{Content: content, ID: NewMessageID(), CreatedAt: now()}
+}
+
+// NewAssistantMessage returns an assistant message with a new ID, created now.
+func NewAssistantMessage(content ...ContentBlock) AssistantMessage {
+	return AssistantMessage{Content: content, ID: NewMessageID(), CreatedAt: now()}
+}
+
+// NewToolCallMessage returns a tool call message with a new ID, created now.
+func NewToolCallMessage(toolCallID, toolName string, args interface{}) ToolCallMessage {
+	return ToolCallMessage{ToolCallID: toolCallID, ToolName: toolName, Arguments: args, ID: NewMessageID(), CreatedAt: now()}
+}
+
+// NewToolResultMessage returns a tool result message with a new ID, created now.
+func NewToolResultMessage(toolCallID, toolName string, content ...ContentBlock) ToolResultMessage {
+	return ToolResultMessage{Content: content, ToolCallID: toolCallID, ToolName: toolName, ID: NewMessageID(), CreatedAt: now()}
+}
+
+// NewControlMessage returns a control message with a new ID, created now.
+func NewControlMessage(content ...ContentBlock) ControlMessage {
+	return ControlMessage{Content: content, ID: NewMessageID(), CreatedAt: now()}
+}
+
+// Stamp returns m with a new ID and a CreatedAt of now where they are unset. Messages of other types
+// are returned unchanged.
+func Stamp(m AgentMessage) AgentMessage {
+	switch v := m.(type) {
+	case UserMessage:
+		stamp(&v.ID, &v.CreatedAt)
+		return v
+	case AssistantMessage:
+		stamp(&v.ID, &v.CreatedAt)
+		return v
+	case ToolCallMessage:
+		stamp(&v.ID, &v.CreatedAt)
+		return v
+	case ToolResultMessage:
+		stamp(&v.ID, &v.CreatedAt)
+		return v
+	case ControlMessage:
+		stamp(&v.ID, &v.CreatedAt)
+		return v
+	}
+	return m
+}
+
+// MessageID returns the ID of a built-in message type, or "" for other types.
+func MessageID(m AgentMessage) string {
+	switch v := m.(type) {
+	case UserMessage:
+		return v.ID
+	case AssistantMessage:
+		return v.ID
+	case ToolCallMessage:
+		return v.ID
+	case ToolResultMessage:
+		return v.ID
+	case ControlMessage:
+		return v.ID
+	}
+	return ""
+}
+
+// MessageMetadata returns the Metadata of a built-in message type, or nil for other types.
+func MessageMetadata(m AgentMessage) map[string]interface{} {
+	switch v := m.(type) {
+	case UserMessage:
+		return v.Metadata
+	case AssistantMessage:
+		return v.Metadata
+	case ToolCallMessage:
+		return v.Metadata
+	case ToolResultMessage:
+		return v.Metadata
+	case ControlMessage:
+		return v.Metadata
+	}
+	return nil
+}
+
+func stamp(id *string, createdAt *int64) {
+	if *id == "" {
+		*id = NewMessageID()
+	}
+	if *createdAt == 0 {
+		*createdAt = now()
+	}
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
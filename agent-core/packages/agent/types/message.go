@@ -9,6 +9,8 @@ const (
 	StopReasonToolUse StopReason = "toolUse"
 	StopReasonAborted StopReason = "aborted"
 	StopReasonError StopReason = "error"
+	// StopReasonBudgetExceeded ends a turn stopped by its budget (see core.TurnBudget).
+	StopReasonBudgetExceeded StopReason = "budgetExceeded"
 )
 
 type Cost struct {
@@ -35,9 +37,15 @@ type Message interface {
 }
 
 // Messages Types
+//
+// ID, CreatedAt (unix milliseconds) and Metadata are the message's identity; the New* constructors set
+// ID and CreatedAt, and the agent stamps messages appended without them (see Stamp). Metadata is free
+// for applications (e.g. tags searched by a session store) and is never sent to the LLM.
 type UserMessage struct {
 	Content []ContentBlock
-	timestamp int64
+	ID string
+	CreatedAt int64
+	Metadata map[string]interface{}
 }
 
 type AssistantMessage struct {
@@ -48,7 +56,9 @@ type AssistantMessage struct {
 	Usage UsageMetrics
 	StopReason StopReason
 	ErrorMessage *string
-	timestamp int64
+	ID string
+	CreatedAt int64
+	Metadata map[string]interface{}
 }
 
 type ToolCallMessage struct {
@@ -56,7 +66,9 @@ type ToolCallMessage struct {
 	ToolCallID string
 	ToolName string
 	Arguments interface{}
-	timestamp int64
+	ID string
+	CreatedAt int64
+	Metadata map[string]interface{}
 }
 
 type ToolResultMessage struct {
@@ -65,7 +77,9 @@ type ToolResultMessage struct {
 	ToolName string
 	Details interface{}
 	IsError bool
-	timestamp int64
+	ID string
+	CreatedAt int64
+	Metadata map[string]interface{}
 }
 
 // ControlMessage is a system-driven follow-up message (e.g. after a batch of tool calls).
@@ -73,21 +87,23 @@ type ToolResultMessage struct {
 // converts it to a UserMessage so the LLM sees it as the latest user turn.
 type ControlMessage struct {
 	Content   []ContentBlock
-	timestamp int64
+	ID        string
+	CreatedAt int64
+	Metadata  map[string]interface{}
 }
 
 // Impls
 func (u UserMessage) Role() string { return "user" }
-func (u UserMessage) Timestamp() int64 { return u.timestamp }
+func (u UserMessage) Timestamp() int64 { return u.CreatedAt }
 
 func (a AssistantMessage) Role() string { return "assistant" }
-func (a AssistantMessage) Timestamp() int64 { return a.timestamp }
+func (a AssistantMessage) Timestamp() int64 { return a.CreatedAt }
 
 func (tc ToolCallMessage) Role() string { return "toolCall" }
-func (tc ToolCallMessage) Timestamp() int64 { return tc.timestamp }
+func (tc ToolCallMessage) Timestamp() int64 { return tc.CreatedAt }
 
 func (tr ToolResultMessage) Role() string { return "toolResult" }
-func (tr ToolResultMessage) Timestamp() int64 { return tr.timestamp }
+func (tr ToolResultMessage) Timestamp() int64 { return tr.CreatedAt }
 
 func (c ControlMessage) Role() string { return "control" }
-func (c ControlMessage) Timestamp() int64 { return c.timestamp }
+func (c ControlMessage) Timestamp() int64 { return c.CreatedAt }
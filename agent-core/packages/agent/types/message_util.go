@@ -18,3 +18,32 @@ func LastAssistantText(messages []AgentMessage) string {
 	}
 	return ""
 }
+
+// Add returns the sum of two usage records, including cost.
+func (u UsageMetrics) Add(o UsageMetrics) UsageMetrics {
+	return UsageMetrics{
+		Input:       u.Input + o.Input,
+		Output:      u.Output + o.Output,
+		CacheRead:   u.CacheRead + o.CacheRead,
+		CacheWrite:  u.CacheWrite + o.CacheWrite,
+		TotalTokens: u.TotalTokens + o.TotalTokens,
+		Cost: Cost{
+			Input:      u.Cost.Input + o.Cost.Input,
+			Output:     u.Cost.Output + o.Cost.Output,
+			CacheRead:  u.Cost.CacheRead + o.Cost.CacheRead,
+			CacheWrite: u.Cost.CacheWrite + o.Cost.CacheWrite,
+			Total:      u.Cost.Total + o.Cost.Total,
+		},
+	}
+}
+
+// TotalUsage sums the usage of every assistant message in the slice (e.g. a whole conversation).
+func TotalUsage(messages []AgentMessage) UsageMetrics {
+	var total UsageMetrics
+	for _, msg := range messages {
+		if am, ok := msg.(AssistantMessage); ok {
+			total = total.Add(am.Usage)
+		}
+	}
+	return total
+}
@@ -47,4 +47,20 @@ func (s *AgentState) ToContext() AgentContext {
 		Messages:     s.Messages,
 		Tools:        s.Tools,
 	}
-}
\ No newline at end of file
+}
+
+// Clone returns a copy of the state that shares no mutable data with s (messages are copied; message values are shared).
+func (s *AgentState) Clone() *AgentState {
+	out := *s
+	out.Messages = make([]AgentMessage, len(s.Messages))
+	copy(out.Messages, s.Messages)
+	out.PendingToolCalls = make(map[string]bool, len(s.PendingToolCalls))
+	for id, pending := range s.PendingToolCalls {
+		out.PendingToolCalls[id] = pending
+	}
+	if s.Error != nil {
+		e := *s.Error
+		out.Error = &e
+	}
+	return &out
+}
@@ -1,12 +1,20 @@
 package stream
 
+import "sync"
+
+// EventStream is safe for concurrent use: any goroutine may Push, End or register OnEnd callbacks.
 type EventStream[T, R any] struct {
 	events		chan T
 	resultChan 	chan R
 	doneChan	chan struct{}
+	stopChan	chan struct{} // closed once the stream starts ending; releases blocked pushers
 	resultValue R
 	err			error
+
+	mu			sync.Mutex // guards closed and onEnd
 	closed		bool
+	onEnd		[]func()
+	pushing		sync.RWMutex // read-held by Push while sending, so events is never closed under a sender
 }
 
 func NewEventStream[T, R any]() *EventStream[T, R] {
@@ -14,40 +22,74 @@ func NewEventStream[T, R any]() *EventStream[T, R] {
 		events:		make(chan T, 10),
 		resultChan: make(chan R, 1),
 		doneChan: 	make(chan struct{}),
+		stopChan:	make(chan struct{}),
 	}
 }
 
 func (es *EventStream[T, R]) Push(event T) {
-	if es.closed {
+	es.pushing.RLock()
+	defer es.pushing.RUnlock()
+	select {
+	case <-es.stopChan:
 		return
+	default:
 	}
 	select {
 	case es.events <- event:
-	case <-es.doneChan:
+	case <-es.stopChan:
 	}
 }
 
 func (es *EventStream[T, R]) End(result R) {
-	if es.closed {
+	if !es.finish(func() { es.resultValue = result }) {
 		return
 	}
-	es.closed = true
-	es.resultValue = result
-
-	close(es.events)
 	es.resultChan <- result
 	close(es.doneChan)
 }
 
 func (es *EventStream[T, R]) EndWithError(err error) {
-	if es.closed {
+	if !es.finish(func() { es.err = err }) {
+		return
+	}
+	close(es.doneChan)
+}
+
+// OnEnd registers fn to run when the stream ends (End or EndWithError), before consumers observe the end.
+// fn runs at once if the stream has already ended.
+func (es *EventStream[T, R]) OnEnd(fn func()) {
+	es.mu.Lock()
+	if !es.closed {
+		es.onEnd = append(es.onEnd, fn)
+		es.mu.Unlock()
 		return
 	}
+	es.mu.Unlock()
+	fn()
+}
+
+// finish marks the stream ended (false if it already was), records the outcome with set, runs the
+// OnEnd callbacks and closes events once no Push is sending
+func (es *EventStream[T, R]) finish(set func()) bool {
+	es.mu.Lock()
+	if es.closed {
+		es.mu.Unlock()
+		return false
+	}
 	es.closed = true
-	es.err = err
+	set()
+	onEnd := es.onEnd
+	es.onEnd = nil
+	es.mu.Unlock()
 
+	for _, fn := range onEnd {
+		fn()
+	}
+	close(es.stopChan)
+	es.pushing.Lock()
 	close(es.events)
-	close(es.doneChan)
+	es.pushing.Unlock()
+	return true
 }
 
 func (es *EventStream[T, R]) Events() <-chan T {
@@ -79,7 +79,7 @@ type PromptRequest struct {
 	Message      string                   `json:"message"`
 	Stream       bool                     `json:"stream"`
 	SystemPrompt string                   `json:"system_prompt,omitempty"`
-	Messages     []map[string]interface{} `json:"messages,omitempty"`
+	Messages     []json.RawMessage        `json:"messages,omitempty"` // prior conversation, encoded as in responses (types.MarshalMessage)
 	Temperature  float64                  `json:"temperature,omitempty"`
 	MaxTokens    int                      `json:"max_tokens,omitempty"`
 	Model        string                   `json:"model,omitempty"`
@@ -124,6 +124,40 @@ func (s *Server) ListToolsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListModelsHandler handles GET /models. Lists the default provider's models with context window,
+// capabilities and pricing when the provider describes them (provider.Cataloger), otherwise their IDs only.
+func (s *Server) ListModelsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.defaultProvider == nil {
+		http.Error(w, "no provider configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	models := []provider.ModelInfo{}
+	if c, ok := s.defaultProvider.(provider.Cataloger); ok {
+		list, err := c.ListModels(r.Context())
+		if err != nil {
+			http.Error(w, "list models: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		models = append(models, list...)
+	} else {
+		for _, id := range s.defaultProvider.Models() {
+			models = append(models, provider.ModelInfo{ID: id})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider": s.defaultProvider.Name(),
+		"models":   models,
+	})
+}
+
 // PromptHandler handles POST /agent/prompt
 func (s *Server) PromptHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -141,6 +175,15 @@ func (s *Server) PromptHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Message is required", http.StatusBadRequest)
 		return
 	}
+	history := make([]types.AgentMessage, 0, len(req.Messages))
+	for i, raw := range req.Messages {
+		msg, err := types.UnmarshalMessage(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid message %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		history = append(history, msg)
+	}
 
 	// Build tool configs: primary Tools field + legacy CustomTools converted to http ToolConfig
 	configs := make([]tools.ToolConfig, 0, len(req.Tools)+len(req.CustomTools))
@@ -160,13 +203,10 @@ func (s *Server) PromptHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create agent
 	agent := core.NewAgent(agentConfig)
+	agent.AppendMessages(history...)
 
 	// Create user message
-	userMsg := types.UserMessage{
-		Content: []types.ContentBlock{
-			types.TextContent{Text: req.Message},
-		},
-	}
+	userMsg := types.NewUserMessage(types.TextContent{Text: req.Message})
 
 	// Get event stream
 	eventStream := agent.Prompt(context.Background(), userMsg)
@@ -249,12 +289,17 @@ func (s *Server) collectEvents(w http.ResponseWriter, eventStream *stream.EventS
 	}
 
 	messages, _ := eventStream.Result()
+	encoded, err := types.MarshalMessages(messages)
+	if err != nil {
+		http.Error(w, "encode messages: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"events":   events,
-		"messages": messages,
+		"messages": json.RawMessage(encoded),
 	})
 }
 
@@ -2,12 +2,18 @@ package core_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/biome/agent-core/packages/agent/core"
 	_ "github.com/biome/agent-core/packages/agent/orchestrators/agentic"
+	"github.com/biome/agent-core/packages/agent/session"
+	"github.com/biome/agent-core/packages/agent/tools"
 	"github.com/biome/agent-core/packages/agent/transform"
 	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
 )
 
 func TestNewAgent(t *testing.T) {
@@ -183,3 +189,296 @@ func TestAgentEventTiming(t *testing.T) {
 		t.Error("Expected turn_end event")
 	}
 }
+
+// echoProvider answers each request with the last user message after a short delay
+func echoProvider() *fake.Provider {
+	return fake.New().WithFallback(func(req provider.CompletionRequest) fake.Response {
+		r := fake.Text("re: " + fake.LastUserText(req))
+		r.Delay = 20 * time.Millisecond
+		return r
+	})
+}
+
+func userText(text string) types.UserMessage {
+	return types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: text}}}
+}
+
+func TestAgentRejectsOverlappingPrompt(t *testing.T) {
+	agent := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+
+	first := agent.Prompt(context.Background(), userText("one"))
+	second := agent.Prompt(context.Background(), userText("two"))
+	if _, err := second.Result(); !errors.Is(err, core.ErrBusy) {
+		t.Errorf("expected ErrBusy for the overlapping prompt, got %v", err)
+	}
+	if !agent.Busy() || !errors.Is(agent.Reset(), core.ErrBusy) {
+		t.Error("expected the agent to be busy and refuse Reset during the turn")
+	}
+	if messages, err := first.Result(); err != nil || len(messages) != 2 {
+		t.Fatalf("expected the first turn to finish untouched, got %d messages, %v", len(messages), err)
+	}
+
+	// The turn has ended by the time its result is visible
+	if _, err := agent.Prompt(context.Background(), userText("three")).Result(); err != nil {
+		t.Errorf("expected a prompt right after the turn to run, got %v", err)
+	}
+	if got := len(agent.Messages()); got != 4 {
+		t.Errorf("expected 4 messages, got %d", got)
+	}
+}
+
+func TestAgentQueuesPrompts(t *testing.T) {
+	agent := core.NewAgent(core.AgentConfig{Provider: echoProvider(), OnBusy: core.QueueWhenBusy})
+
+	done := make(chan struct{})
+	go func() {
+		// Readers get snapshots while turns run
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = agent.Messages()
+				_ = agent.State().PendingToolCalls
+			}
+		}
+	}()
+	defer close(done)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	streams := []interface {
+		Result() ([]types.AgentMessage, error)
+	}{
+		agent.Prompt(context.Background(), userText("one")),
+		agent.Prompt(cancelled, userText("skipped")),
+		agent.Prompt(context.Background(), userText("two")),
+	}
+	for i, s := range streams {
+		_, err := s.Result()
+		if (i == 1) != errors.Is(err, context.Canceled) {
+			t.Errorf("prompt %d: unexpected error %v", i, err)
+		}
+	}
+
+	var texts []string
+	for _, m := range agent.Messages() {
+		switch msg := m.(type) {
+		case types.UserMessage:
+			texts = append(texts, msg.Content[0].(types.TextContent).Text)
+		case types.AssistantMessage:
+			texts = append(texts, msg.Content[0].(types.TextContent).Text)
+		}
+	}
+	want := []string{"one", "re: one", "two", "re: two"}
+	if len(texts) != len(want) {
+		t.Fatalf("expected %v, got %v", want, texts)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Errorf("message %d: got %q, want %q", i, texts[i], want[i])
+		}
+	}
+}
+
+func TestAgentStateIsSnapshot(t *testing.T) {
+	agent := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+	if _, err := agent.Prompt(context.Background(), userText("one")).Result(); err != nil {
+		t.Fatal(err)
+	}
+	agent.State().Messages[0] = userText("changed")
+	agent.Messages()[1] = userText("changed")
+	if msg := agent.Messages()[0].(types.UserMessage); msg.Content[0].(types.TextContent).Text != "one" {
+		t.Error("changing a snapshot must not change the agent")
+	}
+	if _, ok := agent.Messages()[1].(types.AssistantMessage); !ok {
+		t.Error("changing a snapshot must not change the agent")
+	}
+}
+
+func TestAgentAbortKeepsPartialText(t *testing.T) {
+	slow := fake.Text("one two three four five six seven eight")
+	slow.ChunkDelay = 20 * time.Millisecond
+	agent := core.NewAgent(core.AgentConfig{Provider: fake.New(slow, fake.Text("resumed"))})
+
+	stream := agent.Prompt(context.Background(), userText("count"))
+	for event := range stream.Events() {
+		if event.Type == core.EventTextDelta {
+			agent.Abort()
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("expected an aborted turn to end cleanly, got %v", err)
+	}
+	last, ok := messages[len(messages)-1].(types.AssistantMessage)
+	if !ok || last.StopReason != types.StopReasonAborted || len(last.Content) != 1 {
+		t.Fatalf("expected an aborted assistant message, got %+v", messages[len(messages)-1])
+	}
+	if text := last.Content[0].(types.TextContent).Text; text == "" || len(text) >= len("one two three four five six seven eight") {
+		t.Errorf("expected the partial text, got %q", text)
+	}
+	if agent.Busy() || agent.Abort() {
+		t.Error("expected no turn in progress after the abort")
+	}
+
+	// Continue resumes from the history without a new user message
+	messages, err = agent.Continue(context.Background()).Result()
+	if err != nil || len(messages) != 3 {
+		t.Fatalf("expected one more message, got %d, %v", len(messages), err)
+	}
+	if msg, ok := messages[2].(types.AssistantMessage); !ok || msg.Content[0].(types.TextContent).Text != "resumed" {
+		t.Errorf("unexpected continued message %+v", messages[2])
+	}
+}
+
+// blockingTool runs until release is closed, ignoring cancellation
+type blockingTool struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingTool) Name() string                     { return "wait" }
+func (b *blockingTool) Description() string              { return "Waits" }
+func (b *blockingTool) Parameters() tools.ToolParameters { return tools.ToolParameters{Type: "object"} }
+func (b *blockingTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	close(b.started)
+	<-b.release
+	return "done", nil
+}
+
+func TestAgentAbortCancelsRunningTools(t *testing.T) {
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(tool.release)
+	registry := tools.NewToolRegistry()
+	registry.Register(tool)
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: fake.New(fake.ToolCalls(fake.ToolCall("call_1", "wait", nil))),
+		Tools:    registry,
+	})
+
+	stream := agent.Prompt(context.Background(), userText("wait"))
+	<-tool.started
+	agent.Abort()
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+
+	if len(messages) != 4 {
+		t.Fatalf("expected user, tool use, tool result and aborted messages, got %d", len(messages))
+	}
+	result, ok := messages[2].(types.ToolResultMessage)
+	if !ok || result.ToolCallID != "call_1" || !result.IsError || core.ToolResultError(result) != "tool call cancelled" {
+		t.Errorf("expected a cancelled result for the running call, got %+v", messages[2])
+	}
+	if msg, ok := messages[3].(types.AssistantMessage); !ok || msg.StopReason != types.StopReasonAborted {
+		t.Errorf("expected the turn to end aborted, got %+v", messages[3])
+	}
+	if pending := agent.State().PendingToolCalls; len(pending) != 0 {
+		t.Errorf("expected no pending tool calls, got %v", pending)
+	}
+}
+
+func TestAgentStampsMessages(t *testing.T) {
+	agent := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+	messages, err := agent.Prompt(context.Background(), userText("hi")).Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+	for i, m := range messages {
+		if types.MessageID(m) == "" || m.Timestamp() == 0 {
+			t.Errorf("message %d (%s) has no identity", i, m.Role())
+		}
+	}
+}
+
+func TestAgentPersistsSession(t *testing.T) {
+	ctx := context.Background()
+	store, err := session.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := store.Create(ctx, "", map[string]string{"user": "ann"})
+
+	first := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+	if err := first.LoadSession(ctx, store, "missing"); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := first.LoadSession(ctx, store, s.ID); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if _, err := first.Prompt(ctx, userText("one")).Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new agent (e.g. after a restart) picks the conversation up where it was
+	second := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+	if err := second.LoadSession(ctx, store, s.ID); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(second.Messages()) != 2 || second.SessionID() != s.ID {
+		t.Fatalf("expected the stored history, got %d messages", len(second.Messages()))
+	}
+	if _, err := second.Prompt(ctx, userText("two")).Result(); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.Load(ctx, s.ID)
+	if len(stored) != 4 || types.MessageID(stored[3]) != types.MessageID(second.Messages()[3]) {
+		t.Errorf("expected every message stored as written, got %d", len(stored))
+	}
+
+	// Reset detaches the session and leaves it intact
+	second.Reset()
+	second.Prompt(ctx, userText("three")).Result()
+	if stored, _ := store.Load(ctx, s.ID); len(stored) != 4 || second.SessionID() != "" {
+		t.Errorf("expected the session untouched after Reset, got %d messages", len(stored))
+	}
+}
+
+// slowStore signals writing and holds each Append until release is closed
+type slowStore struct {
+	session.Store
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (s slowStore) Append(ctx context.Context, id string, messages ...types.AgentMessage) error {
+	select {
+	case s.writing <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s.Store.Append(ctx, id, messages...)
+}
+
+func TestAgentSessionWritesDoNotBlockReaders(t *testing.T) {
+	ctx := context.Background()
+	store := slowStore{Store: session.NewMemoryStore(), writing: make(chan struct{}, 1), release: make(chan struct{})}
+	s, _ := store.Create(ctx, "", nil)
+	agent := core.NewAgent(core.AgentConfig{Provider: echoProvider()})
+	if err := agent.LoadSession(ctx, store, s.ID); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+
+	appended := make(chan struct{})
+	go func() {
+		agent.AppendMessages(userText("one"))
+		agent.AppendMessages(userText("two"))
+		close(appended)
+	}()
+	<-store.writing
+	read := make(chan int)
+	go func() { read <- len(agent.Messages()) }()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("expected Messages not to wait for the session write")
+	}
+
+	close(store.release)
+	<-appended
+	if stored, _ := store.Load(ctx, s.ID); len(stored) != 2 || types.MessageID(stored[1]) != types.MessageID(agent.Messages()[1]) {
+		t.Errorf("expected both messages stored in order, got %+v", stored)
+	}
+}
@@ -0,0 +1,191 @@
+package core_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	examplestools "github.com/biome/agent-core/examples/tools"
+	"github.com/biome/agent-core/packages/agent/core"
+	"github.com/biome/agent-core/packages/agent/tools"
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+func calculatorRegistry() *tools.ToolRegistry {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+	return registry
+}
+
+// runBudgetTurn runs one prompt and returns its messages and budget_exceeded events
+func runBudgetTurn(t *testing.T, agent *core.Agent) ([]types.AgentMessage, []core.BudgetExceededPayload) {
+	t.Helper()
+	stream := agent.Prompt(context.Background(), userText("compute"))
+	var exceeded []core.BudgetExceededPayload
+	for event := range stream.Events() {
+		if event.Type == core.EventBudgetExceeded {
+			exceeded = append(exceeded, event.Payload.(core.BudgetExceededPayload))
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+	return messages, exceeded
+}
+
+func TestBudgetForcesAnswerOnRepeatedToolCall(t *testing.T) {
+	same := map[string]interface{}{"expression": "2+2"}
+	mock := fake.New(
+		fake.ToolCalls(fake.ToolCall("call_1", "calculator", same)),
+		fake.ToolCalls(fake.ToolCall("call_2", "calculator", same)),
+		fake.Text("It is 4."),
+	)
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: mock,
+		Tools:    calculatorRegistry(),
+		Budget:   core.TurnBudget{MaxRepeatedToolCalls: 1},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitRepeatedToolCall || exceeded[0].Action != "force_answer" {
+		t.Fatalf("unexpected budget events %+v", exceeded)
+	}
+	// user, tool use, tool result, control, force-answer control, answer: the repeated call never runs
+	if len(messages) != 6 {
+		t.Fatalf("expected 6 messages, got %d", len(messages))
+	}
+	last := messages[5].(types.AssistantMessage)
+	if last.StopReason != types.StopReasonStop || last.Content[0].(types.TextContent).Text != "It is 4." {
+		t.Errorf("expected the forced answer, got %+v", last)
+	}
+	if choice := mock.Requests()[2].ToolChoice; choice.AllowsTools() {
+		t.Errorf("expected tools disabled for the forced answer, got %+v", choice)
+	}
+}
+
+func TestBudgetStopsAtToolCallLimit(t *testing.T) {
+	mock := fake.New(fake.ToolCalls(
+		fake.ToolCall("call_1", "calculator", map[string]interface{}{"expression": "1+1"}),
+		fake.ToolCall("call_2", "calculator", map[string]interface{}{"expression": "2+2"}),
+	))
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: mock,
+		Tools:    calculatorRegistry(),
+		Budget:   core.TurnBudget{MaxToolCalls: 1, OnExceeded: core.BudgetStop},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitToolCalls || exceeded[0].Action != "stop" {
+		t.Fatalf("unexpected budget events %+v", exceeded)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected the user message and a stopped answer, got %d messages", len(messages))
+	}
+	msg := messages[1].(types.AssistantMessage)
+	if msg.StopReason != types.StopReasonBudgetExceeded || len(msg.Content) != 1 || !strings.Contains(msg.Content[0].(types.TextContent).Text, "more than 1 tool call(s)") {
+		t.Errorf("expected a budget-stopped message naming the limit, got %+v", msg)
+	}
+	if len(mock.Requests()) != 1 {
+		t.Errorf("expected no LLM call after the stop, got %d calls", len(mock.Requests()))
+	}
+}
+
+func TestBudgetStopsAtSteeringIterations(t *testing.T) {
+	mock := fake.New().WithFallback(func(req provider.CompletionRequest) fake.Response {
+		return fake.ToolCalls(fake.ToolCall("call", "calculator", map[string]interface{}{"expression": "1+1"}))
+	})
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: mock,
+		Tools:    calculatorRegistry(),
+		Budget:   core.TurnBudget{MaxSteeringIterations: 2, OnExceeded: core.BudgetStop},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitSteeringIterations {
+		t.Fatalf("unexpected budget events %+v", exceeded)
+	}
+	calls := 0
+	for _, m := range messages {
+		if _, ok := m.(types.ToolResultMessage); ok {
+			calls++
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected two tool-use steps before the stop, got %d", calls)
+	}
+}
+
+func TestBudgetTimeoutInterruptsLLMCall(t *testing.T) {
+	hung := fake.Text("too late")
+	hung.Delay = time.Hour
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: fake.New(hung),
+		Budget:   core.TurnBudget{Timeout: 20 * time.Millisecond},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitTimeout || exceeded[0].Action != "stop" {
+		t.Fatalf("unexpected budget events %+v", exceeded)
+	}
+	if msg := messages[len(messages)-1].(types.AssistantMessage); msg.StopReason != types.StopReasonBudgetExceeded {
+		t.Errorf("expected the turn stopped by its timeout, got %+v", msg)
+	}
+}
+
+func TestBudgetTimeoutInterruptsTool(t *testing.T) {
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(tool.release)
+	registry := tools.NewToolRegistry()
+	registry.Register(tool)
+	mock := fake.New(fake.ToolCalls(fake.ToolCall("call_1", "wait", nil)))
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: mock,
+		Tools:    registry,
+		Budget:   core.TurnBudget{Timeout: 20 * time.Millisecond},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitTimeout {
+		t.Fatalf("unexpected budget events %+v", exceeded)
+	}
+	if result, ok := messages[2].(types.ToolResultMessage); !ok || core.ToolResultError(result) != "tool call cancelled" {
+		t.Errorf("expected the hung call cancelled, got %+v", messages[2])
+	}
+	if msg := messages[len(messages)-1].(types.AssistantMessage); msg.StopReason != types.StopReasonBudgetExceeded {
+		t.Errorf("expected the turn stopped by its timeout, got %+v", msg)
+	}
+	if len(mock.Requests()) != 1 {
+		t.Errorf("expected no LLM call past the timeout, got %d calls", len(mock.Requests()))
+	}
+}
+
+func TestBudgetCheckedBeforeFollowUpCall(t *testing.T) {
+	answer := fake.Text("Hi")
+	answer.Completion.Usage = provider.UsageInfo{PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100}
+	mock := fake.New(answer, fake.Text("Short answer"))
+	followUps := []types.AgentMessage{userText("and more?")}
+	agent := core.NewAgent(core.AgentConfig{
+		Provider: mock,
+		Budget:   core.TurnBudget{MaxTokens: 50},
+		GetFollowUpMessages: func() []types.AgentMessage {
+			next := followUps
+			followUps = nil
+			return next
+		},
+	})
+
+	messages, exceeded := runBudgetTurn(t, agent)
+	if len(exceeded) != 1 || exceeded[0].Limit != core.LimitTokens {
+		t.Fatalf("expected the follow-up call checked against the tokens spent, got %+v", exceeded)
+	}
+	if choice := mock.Requests()[1].ToolChoice; choice.AllowsTools() {
+		t.Errorf("expected tools disabled for the forced answer, got %+v", choice)
+	}
+	if types.LastAssistantText(messages) != "Short answer" {
+		t.Errorf("unexpected final text %q", types.LastAssistantText(messages))
+	}
+}
@@ -2,6 +2,8 @@ package core_test
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	examplestools "github.com/biome/agent-core/examples/tools"
@@ -9,6 +11,8 @@ import (
 	"github.com/biome/agent-core/packages/agent/tools"
 	"github.com/biome/agent-core/packages/agent/types"
 	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/failover"
+	"github.com/biome/agent-mind/provider/fake"
 )
 
 // --- Queue Tests ---
@@ -162,28 +166,12 @@ func TestAgentResetClearsHistory(t *testing.T) {
 	}
 }
 
-// --- Mock Provider for Agent Tests ---
-
-type mockRespondProvider struct{}
-
-func (m *mockRespondProvider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
-	return &provider.CompletionResponse{Text: "Hello!"}, nil
-}
-
-func (m *mockRespondProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
-	ch := make(chan provider.StreamEvent, 1)
-	ch <- provider.StreamEvent{Type: provider.EventDone}
-	close(ch)
-	return ch, nil
-}
-
-func (m *mockRespondProvider) Name() string     { return "mockRespond" }
-func (m *mockRespondProvider) Models() []string { return nil }
+// --- Agent Tests with Scripted Provider ---
 
 func TestAgentRespondMode(t *testing.T) {
 	agent := core.NewAgent(core.AgentConfig{
 		SystemPrompt: "Test",
-		Provider:     &mockRespondProvider{},
+		Provider:     fake.New().WithFallback(func(provider.CompletionRequest) fake.Response { return fake.Text("Hello!") }),
 	})
 
 	stream := agent.Prompt(context.Background(), types.UserMessage{
@@ -217,42 +205,17 @@ func TestAgentRespondMode(t *testing.T) {
 	}
 }
 
-// --- Mock Provider with Tool Calls ---
-
-type mockToolProvider struct {
-	callCount int
-}
-
-func (m *mockToolProvider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
-	m.callCount++
-	if m.callCount == 1 {
-		return &provider.CompletionResponse{
-			ToolCalls: []provider.ToolCallResponse{
-				{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
-			},
-		}, nil
-	}
-	return &provider.CompletionResponse{Text: "The answer is 4"}, nil
-}
-
-func (m *mockToolProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
-	ch := make(chan provider.StreamEvent, 1)
-	ch <- provider.StreamEvent{Type: provider.EventDone}
-	close(ch)
-	return ch, nil
-}
-
-func (m *mockToolProvider) Name() string     { return "mockTool" }
-func (m *mockToolProvider) Models() []string { return nil }
-
 func TestAgentToolExecution(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
 	agent := core.NewAgent(core.AgentConfig{
 		SystemPrompt: "Test",
-		Provider:     &mockToolProvider{},
-		Tools:        registry,
+		Provider: fake.New(
+			fake.ToolCalls(fake.ToolCall("call_1", "calculator", map[string]interface{}{"expression": "2+2"})),
+			fake.Text("The answer is 4"),
+		),
+		Tools: registry,
 	})
 
 	stream := agent.Prompt(context.Background(), types.UserMessage{
@@ -281,7 +244,13 @@ func TestAgentSequentialToolCalls(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	registry.Register(&examplestools.CalculatorTool{})
 
-	mock := &mockMultiToolProvider{}
+	mock := fake.New(
+		fake.ToolCalls(
+			fake.ToolCall("c1", "calculator", map[string]interface{}{"expression": "1+1"}),
+			fake.ToolCall("c2", "calculator", map[string]interface{}{"expression": "2+2"}),
+		),
+		fake.Text("Done"),
+	)
 	agent := core.NewAgent(core.AgentConfig{
 		SystemPrompt: "Test",
 		Provider:     mock,
@@ -305,33 +274,6 @@ func TestAgentSequentialToolCalls(t *testing.T) {
 	}
 }
 
-type mockMultiToolProvider struct {
-	callCount int
-}
-
-func (m *mockMultiToolProvider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
-	m.callCount++
-	if m.callCount == 1 {
-		return &provider.CompletionResponse{
-			ToolCalls: []provider.ToolCallResponse{
-				{ID: "c1", Name: "calculator", Arguments: map[string]interface{}{"expression": "1+1"}},
-				{ID: "c2", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
-			},
-		}, nil
-	}
-	return &provider.CompletionResponse{Text: "Done"}, nil
-}
-
-func (m *mockMultiToolProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
-	ch := make(chan provider.StreamEvent, 1)
-	ch <- provider.StreamEvent{Type: provider.EventDone}
-	close(ch)
-	return ch, nil
-}
-
-func (m *mockMultiToolProvider) Name() string     { return "mockMulti" }
-func (m *mockMultiToolProvider) Models() []string { return nil }
-
 // --- Event Types Tests ---
 
 func TestEventTypeConstants(t *testing.T) {
@@ -351,3 +293,433 @@ func TestEventTypeConstants(t *testing.T) {
 		}
 	}
 }
+
+// --- Streaming Tests ---
+
+// streamingScript streams a tool call with argument deltas, then the answer in several chunks.
+func streamingScript() *fake.Provider {
+	done := provider.StreamEvent{Type: provider.EventDone, Content: &provider.StreamDonePayload{Model: "mock-model"}}
+	return fake.New(
+		fake.Events(
+			provider.StreamEvent{Type: provider.EventToolDelta, Content: &provider.ToolCallStreamPayload{Index: 0, ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{}}},
+			provider.StreamEvent{Type: provider.EventToolDelta, Content: &provider.ToolCallStreamPayload{Index: 0, ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}}},
+			provider.StreamEvent{Type: provider.EventToolCall, Content: &provider.ToolCallResponse{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}}},
+			done,
+		),
+		fake.Events(
+			provider.StreamEvent{Type: provider.EventTextDelta, Delta: "The "},
+			provider.StreamEvent{Type: provider.EventTextDelta, Delta: "answer "},
+			provider.StreamEvent{Type: provider.EventTextDelta, Delta: "is 4"},
+			done,
+		),
+	)
+}
+
+func TestAgentStreamsDeltas(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "Test",
+		Provider:     streamingScript(),
+		Tools:        registry,
+	})
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Calculate 2+2"}},
+	})
+
+	var deltas []string
+	var toolDeltas []core.ToolCallDeltaPayload
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventTextDelta:
+			deltas = append(deltas, event.Payload.(core.TextDeltaPayload).Text)
+		case core.EventToolCallDelta:
+			toolDeltas = append(toolDeltas, event.Payload.(core.ToolCallDeltaPayload))
+		}
+	}
+
+	if len(deltas) != 3 || strings.Join(deltas, "") != "The answer is 4" {
+		t.Errorf("Expected 3 streamed text deltas forming the answer, got %q", deltas)
+	}
+	if len(toolDeltas) != 2 {
+		t.Fatalf("Expected 2 tool_call_delta events, got %d", len(toolDeltas))
+	}
+	if toolDeltas[1].ToolName != "calculator" || toolDeltas[1].Args["expression"] != "2+2" {
+		t.Errorf("Unexpected tool_call_delta payload: %+v", toolDeltas[1])
+	}
+
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	last, ok := messages[len(messages)-1].(types.AssistantMessage)
+	if !ok {
+		t.Fatalf("Expected last message to be assistant, got %T", messages[len(messages)-1])
+	}
+	if last.Model != "mock-model" {
+		t.Errorf("Expected model from stream done payload, got %q", last.Model)
+	}
+	if types.LastAssistantText(messages) != "The answer is 4" {
+		t.Errorf("Unexpected final text %q", types.LastAssistantText(messages))
+	}
+}
+
+func TestAgentRecordsFailoverBackend(t *testing.T) {
+	primary := fake.New(fake.Error(&provider.HTTPError{StatusCode: 503})).WithName("primary").WithModel("model-a")
+	backup := fake.New(fake.Text("Hello from backup")).WithName("backup").WithModel("model-b")
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "Test",
+		Provider:     failover.New(primary, backup),
+	})
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Hi"}},
+	})
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	last, ok := messages[len(messages)-1].(types.AssistantMessage)
+	if !ok {
+		t.Fatalf("Expected last message to be assistant, got %T", messages[len(messages)-1])
+	}
+	if last.Provider != "backup" || last.Model != "model-b" {
+		t.Errorf("Expected backup provider/model, got %q/%q", last.Provider, last.Model)
+	}
+}
+
+func TestAgentCompactsHistoryOnContextOverflow(t *testing.T) {
+	overflow := provider.NewHTTPError(400, `{"error":{"code":"context_length_exceeded"}}`, nil)
+	mock := fake.New(fake.Text("one"), fake.Text("two"), fake.Error(overflow), fake.Text("three"))
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock})
+
+	for _, text := range []string{"first", "second"} {
+		if _, err := agent.Prompt(context.Background(), types.UserMessage{
+			Content: []types.ContentBlock{types.TextContent{Text: text}},
+		}).Result(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "third"}},
+	})
+	var compacted *core.ContextCompactedPayload
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventContextCompacted:
+			p := event.Payload.(core.ContextCompactedPayload)
+			compacted = &p
+		case core.EventError:
+			t.Errorf("Unexpected error event: %+v", event.Payload)
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if compacted == nil || compacted.Before != 5 || compacted.After != 3 {
+		t.Errorf("Expected the history compacted from 5 to 3 messages, got %+v", compacted)
+	}
+	if mock.Calls() != 4 || len(mock.Requests()[3].Messages) != 3 {
+		t.Errorf("Expected one retry with the compacted history, got %d calls", mock.Calls())
+	}
+	if types.LastAssistantText(messages) != "three" {
+		t.Errorf("Unexpected final text %q", types.LastAssistantText(messages))
+	}
+}
+
+func TestAgentEmitsErrorEvent(t *testing.T) {
+	mock := fake.New(fake.Error(provider.NewHTTPError(401, "invalid api key", nil)))
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock})
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Hi"}},
+	})
+	var payload *core.ErrorPayload
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventError:
+			p := event.Payload.(core.ErrorPayload)
+			payload = &p
+		case core.EventTextDelta:
+			t.Errorf("Expected no answer text, got %+v", event.Payload)
+		}
+	}
+	if payload == nil || payload.Kind != "auth" || payload.StatusCode != 401 {
+		t.Fatalf("Expected an auth error event, got %+v", payload)
+	}
+
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	last, ok := messages[len(messages)-1].(types.AssistantMessage)
+	if !ok || last.StopReason != types.StopReasonError || last.ErrorMessage == nil || !strings.Contains(*last.ErrorMessage, "invalid api key") {
+		t.Errorf("Expected the turn to end with an error message, got %+v", messages[len(messages)-1])
+	}
+}
+
+func TestAgentPromptsAgainAfterFailedTurn(t *testing.T) {
+	mock := fake.New(fake.Error(provider.NewHTTPError(503, "overloaded", nil)), fake.Text("Hello"))
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock})
+
+	for _, text := range []string{"Hi", "Hi again"} {
+		stream := agent.Prompt(context.Background(), types.UserMessage{
+			Content: []types.ContentBlock{types.TextContent{Text: text}},
+		})
+		if _, err := stream.Result(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	requests := mock.Requests()
+	if len(requests) != 2 || len(requests[1].Messages) != 3 {
+		t.Fatalf("Expected the second call to replay the failed turn, got %d calls", len(requests))
+	}
+	for _, m := range requests[1].Messages {
+		if am, ok := m.(types.AssistantMessage); ok && len(am.Content) == 0 {
+			t.Errorf("Expected no assistant message without content in the replayed history, got %+v", am)
+		}
+	}
+	if types.LastAssistantText(agent.Messages()) != "Hello" {
+		t.Errorf("Unexpected final text %q", types.LastAssistantText(agent.Messages()))
+	}
+}
+
+func TestAgentRecordsUsageAndCost(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	toolStep := fake.ToolCalls(fake.ToolCall("call_1", "calculator", map[string]interface{}{"expression": "2+2"}))
+	toolStep.Completion.Usage = provider.UsageInfo{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100, CacheReadTokens: 400}
+	answer := fake.Text("The answer is 4")
+	answer.Completion.Usage = provider.UsageInfo{PromptTokens: 2000, CompletionTokens: 200, TotalTokens: 2200}
+
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "Test",
+		Provider:     fake.New(toolStep, answer).WithModel("priced-model"),
+		Tools:        registry,
+		Prices:       provider.PriceTable{"priced-model": {Input: 1, Output: 10, CacheRead: 0.5}},
+	})
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Calculate 2+2"}},
+	})
+
+	var turnUsage types.UsageMetrics
+	for event := range stream.Events() {
+		if event.Type == core.EventTurnEnd {
+			turnUsage = event.Payload.(core.TurnEndPayload).Usage
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var assistants []types.AssistantMessage
+	for _, m := range messages {
+		if am, ok := m.(types.AssistantMessage); ok {
+			assistants = append(assistants, am)
+		}
+	}
+	if len(assistants) != 2 {
+		t.Fatalf("Expected 2 assistant messages, got %d", len(assistants))
+	}
+	first := assistants[0].Usage
+	if first.Input != 600 || first.CacheRead != 400 || first.Output != 100 || first.TotalTokens != 1100 {
+		t.Errorf("Unexpected tool-step usage: %+v", first)
+	}
+	// 600 input * $1/M + 100 output * $10/M + 400 cached * $0.5/M
+	if got, want := first.Cost.Total, 0.0006+0.001+0.0002; !approxEqual(got, want) {
+		t.Errorf("Tool-step cost = %v, want %v", got, want)
+	}
+	if assistants[1].Usage.TotalTokens != 2200 {
+		t.Errorf("Unexpected answer usage: %+v", assistants[1].Usage)
+	}
+	if turnUsage.TotalTokens != 3300 || !approxEqual(turnUsage.Cost.Total, first.Cost.Total+assistants[1].Usage.Cost.Total) {
+		t.Errorf("Turn usage should total both calls, got %+v", turnUsage)
+	}
+	if total := types.TotalUsage(messages); total.TotalTokens != 3300 {
+		t.Errorf("TotalUsage = %d tokens, want 3300", total.TotalTokens)
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-12 && d > -1e-12
+}
+
+func TestAgentKeepsReasoningSeparateFromAnswer(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	toolStep := fake.ToolCalls(fake.ToolCall("call_1", "calculator", map[string]interface{}{"expression": "2+2"}))
+	toolStep.Completion.Text = "Let me compute that."
+	toolStep.Completion.Reasoning = []provider.ReasoningBlock{{Text: "Need arithmetic.", Signature: "sig-1"}}
+	answer := fake.Text("The answer is 4")
+	answer.Completion.Reasoning = []provider.ReasoningBlock{{Text: "Tool said 4."}}
+	mock := fake.New(toolStep, answer)
+
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock, Tools: registry})
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Calculate 2+2"}},
+	})
+
+	var thinking []core.ThinkingPayload
+	var reasoningDeltas []string
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventThinking:
+			thinking = append(thinking, event.Payload.(core.ThinkingPayload))
+		case core.EventThinkingDelta:
+			reasoningDeltas = append(reasoningDeltas, event.Payload.(core.TextDeltaPayload).Text)
+		}
+	}
+	messages, err := stream.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(thinking) != 2 || thinking[0].Text != "Let me compute that." || thinking[0].Reasoning != "Need arithmetic." {
+		t.Fatalf("Expected real model text and reasoning in thinking events, got %+v", thinking)
+	}
+	if thinking[1].Reasoning != "Tool said 4." {
+		t.Errorf("Expected final answer reasoning in a thinking event, got %+v", thinking[1])
+	}
+	if strings.Join(reasoningDeltas, "") != "Need arithmetic.Tool said 4." {
+		t.Errorf("Unexpected thinking deltas %q", reasoningDeltas)
+	}
+	if got := types.LastAssistantText(messages); got != "The answer is 4" {
+		t.Errorf("Reasoning leaked into answer text: %q", got)
+	}
+
+	toolMsg := messages[1].(types.AssistantMessage)
+	th, ok := toolMsg.Content[0].(types.ThinkingContent)
+	if !ok || th.Thinking != "Need arithmetic." || th.Signature != "sig-1" {
+		t.Errorf("Expected signed ThinkingContent first on the tool-call message, got %+v", toolMsg.Content)
+	}
+
+	// The second call replays the signed reasoning to the provider.
+	replayed := mock.Requests()[1].Messages[1].(types.AssistantMessage)
+	if _, ok := replayed.Content[0].(types.ThinkingContent); !ok {
+		t.Errorf("Expected reasoning to be replayed on the next call, got %+v", replayed.Content)
+	}
+}
+
+func TestAgentToolChoice(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	mock := fake.New(
+		fake.ToolCalls(fake.ToolCall("c1", "calculator", map[string]interface{}{"expression": "1+1"})),
+		fake.Text("2"),
+		fake.Text("Hi"),
+	)
+	agent := core.NewAgent(core.AgentConfig{
+		SystemPrompt: "Test",
+		Provider:     mock,
+		Tools:        registry,
+		ToolChoice:   provider.ForceTool("calculator"),
+	})
+
+	// The configured choice applies to the first call of the turn; the call after tool results is auto.
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "1+1?"}},
+	})
+	for range stream.Events() {
+	}
+	// A per-Prompt option overrides the configured choice for that turn only.
+	stream = agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Say hi"}},
+	}, core.WithToolChoice(provider.DisableTools()))
+	for range stream.Events() {
+	}
+
+	reqs := mock.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("Expected 3 LLM calls, got %d", len(reqs))
+	}
+	if got := reqs[0].ToolChoice.String(); got != "tool:calculator" {
+		t.Errorf("first call: expected forced calculator, got %s", got)
+	}
+	if reqs[1].ToolChoice != nil {
+		t.Errorf("follow-up call: expected provider default, got %s", reqs[1].ToolChoice)
+	}
+	if got := reqs[2].ToolChoice.String(); got != "none" {
+		t.Errorf("overridden call: expected none, got %s", got)
+	}
+}
+
+func TestAgentChecksModelCapabilities(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+
+	// Tools on a model without tool calling fail before any LLM call.
+	mock := fake.New(fake.Text("unused")).WithModel("text-model").
+		WithCatalog(provider.ModelInfo{ID: "text-model"})
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock, Tools: registry})
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "1+1?"}},
+	})
+	for range stream.Events() {
+	}
+	if _, err := stream.Result(); !errors.Is(err, provider.ErrToolsNotSupported) {
+		t.Errorf("expected ErrToolsNotSupported, got %v", err)
+	}
+	if mock.Calls() != 0 {
+		t.Errorf("expected no LLM calls, got %d", mock.Calls())
+	}
+
+	// Images on a model without vision are rejected the same way.
+	mock = fake.New(fake.Text("unused")).WithModel("tool-model").
+		WithCatalog(provider.ModelInfo{ID: "tool-model", Tools: true})
+	agent = core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock, Tools: registry})
+	stream = agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.ImageContent{Data: "https://example.com/a.png"}},
+	})
+	for range stream.Events() {
+	}
+	if _, err := stream.Result(); !errors.Is(err, provider.ErrImagesNotSupported) {
+		t.Errorf("expected ErrImagesNotSupported, got %v", err)
+	}
+
+	// Models missing from the catalog are not blocked.
+	mock = fake.New(fake.Text("Hi")).WithModel("unlisted")
+	agent = core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock, Tools: registry})
+	stream = agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "Hello"}},
+	})
+	for range stream.Events() {
+	}
+	if _, err := stream.Result(); err != nil {
+		t.Errorf("unlisted model should not be blocked, got %v", err)
+	}
+}
+
+func TestAgentCapabilityRejectionKeepsHistory(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(&examplestools.CalculatorTool{})
+	mock := fake.New().WithModel("text-model").WithCatalog(provider.ModelInfo{ID: "text-model"})
+	agent := core.NewAgent(core.AgentConfig{SystemPrompt: "Test", Provider: mock, Tools: registry})
+	history := []types.AgentMessage{
+		types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "Earlier"}}},
+		types.AssistantMessage{Content: []types.ContentBlock{types.TextContent{Text: "Answer"}}, StopReason: types.StopReasonStop},
+	}
+	agent.AppendMessages(history...)
+
+	stream := agent.Prompt(context.Background(), types.UserMessage{
+		Content: []types.ContentBlock{types.TextContent{Text: "1+1?"}},
+	})
+	for range stream.Events() {
+	}
+	if _, err := stream.Result(); !errors.Is(err, provider.ErrToolsNotSupported) {
+		t.Fatalf("expected ErrToolsNotSupported, got %v", err)
+	}
+	if got := agent.Messages(); len(got) != len(history) || types.LastAssistantText(got) != "Answer" {
+		t.Errorf("expected the history unchanged by a rejected prompt, got %+v", got)
+	}
+}
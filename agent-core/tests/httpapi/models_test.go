@@ -0,0 +1,59 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/biome/agent-core/pkg/httpapi"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+// listModels serves GET /models for p and returns the status and the listed model IDs.
+func listModels(t *testing.T, p provider.Provider) (int, []string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	httpapi.NewServer(p).ListModelsHandler(rec, httptest.NewRequest(http.MethodGet, "/models", nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var body struct {
+		Models []provider.ModelInfo `json:"models"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode /models: %v", err)
+	}
+	var ids []string
+	for _, m := range body.Models {
+		ids = append(ids, m.ID)
+	}
+	return rec.Code, ids
+}
+
+func TestListModelsFallsBackWithoutCatalog(t *testing.T) {
+	// embedding only provider.Provider hides the fake's catalog
+	inner := struct{ provider.Provider }{fake.New().WithModel("plain-model")}
+	wrapped := map[string]provider.Provider{
+		"observe":      provider.Chain(inner, provider.Observe(func(*provider.Call, *provider.CompletionResponse, error) {})),
+		"prompt tools": provider.WithPromptTools(inner),
+	}
+	for name, p := range wrapped {
+		code, ids := listModels(t, p)
+		if code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", name, code)
+			continue
+		}
+		if len(ids) != 1 || ids[0] != "plain-model" {
+			t.Errorf("%s: expected the provider's Models(), got %v", name, ids)
+		}
+	}
+}
+
+func TestListModelsMockProvider(t *testing.T) {
+	code, ids := listModels(t, fake.New().WithName("mock"))
+	if code != http.StatusOK || len(ids) != 1 || ids[0] != "fake-model" {
+		t.Errorf("expected the fake's model to be listed without a catalog, got %d %v", code, ids)
+	}
+}
@@ -0,0 +1,164 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/session"
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+func text(role, s string) types.AgentMessage {
+	content := []types.ContentBlock{types.TextContent{Text: s}}
+	if role == "assistant" {
+		return types.AssistantMessage{Content: content, StopReason: types.StopReasonStop, ID: "msg_" + s}
+	}
+	return types.UserMessage{Content: content, ID: "msg_" + s}
+}
+
+// stores returns a fresh store of each kind, and a function that reopens it from disk (nil for memory)
+func stores(t *testing.T) map[string]func() (session.Store, func() session.Store) {
+	return map[string]func() (session.Store, func() session.Store){
+		"memory": func() (session.Store, func() session.Store) {
+			return session.NewMemoryStore(), nil
+		},
+		"file": func() (session.Store, func() session.Store) {
+			path := filepath.Join(t.TempDir(), "sessions.jsonl")
+			open := func() session.Store {
+				s, err := session.NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				t.Cleanup(func() { s.Close() })
+				return s
+			}
+			return open(), open
+		},
+		"dir": func() (session.Store, func() session.Store) {
+			root := t.TempDir()
+			open := func() session.Store {
+				s, err := session.NewDirStore(root)
+				if err != nil {
+					t.Fatalf("NewDirStore: %v", err)
+				}
+				return s
+			}
+			return open(), open
+		},
+	}
+}
+
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			store, reopen := newStore()
+
+			a, err := store.Create(ctx, "", map[string]string{"user": "ann", "topic": "math"})
+			if err != nil || a.ID == "" {
+				t.Fatalf("Create: %+v, %v", a, err)
+			}
+			if _, err := store.Create(ctx, "b", map[string]string{"user": "bob"}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := store.Create(ctx, "b", nil); !errors.Is(err, session.ErrExists) {
+				t.Errorf("expected ErrExists, got %v", err)
+			}
+
+			if err := store.Append(ctx, a.ID, text("user", "one"), text("assistant", "two")); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if err := store.Append(ctx, a.ID, text("user", "three")); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if err := store.Append(ctx, "missing", text("user", "x")); !errors.Is(err, session.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+			want := []types.AgentMessage{text("user", "one"), text("assistant", "two"), text("user", "three")}
+			if got, err := store.Load(ctx, a.ID); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Load: got %+v, %v", got, err)
+			}
+			if s, _ := store.Get(ctx, a.ID); s.MessageCount != 3 || s.UpdatedAt < s.CreatedAt {
+				t.Errorf("unexpected session %+v", s)
+			}
+
+			if found, _ := store.Search(ctx, map[string]string{"user": "ann"}); len(found) != 1 || found[0].ID != a.ID {
+				t.Errorf("Search: got %+v", found)
+			}
+			if found, _ := store.Search(ctx, map[string]string{"user": "ann", "topic": "art"}); len(found) != 0 {
+				t.Errorf("Search: expected no match, got %+v", found)
+			}
+			if err := store.SetMetadata(ctx, "b", map[string]string{"user": "ann"}); err != nil {
+				t.Fatalf("SetMetadata: %v", err)
+			}
+			if found, _ := store.Search(ctx, map[string]string{"user": "ann"}); len(found) != 2 {
+				t.Errorf("Search after SetMetadata: got %+v", found)
+			}
+
+			if err := store.Replace(ctx, a.ID, want[2:]); err != nil {
+				t.Fatalf("Replace: %v", err)
+			}
+			if err := store.Delete(ctx, "b"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := store.Get(ctx, "b"); !errors.Is(err, session.ErrNotFound) {
+				t.Errorf("expected a deleted session gone, got %v", err)
+			}
+
+			if reopen == nil {
+				return
+			}
+			store = reopen()
+			if list, err := store.List(ctx); err != nil || len(list) != 1 || list[0].ID != a.ID || list[0].Metadata["topic"] != "math" {
+				t.Errorf("List after reopen: got %+v, %v", list, err)
+			}
+			if got, err := store.Load(ctx, a.ID); err != nil || !reflect.DeepEqual(got, want[2:]) {
+				t.Errorf("Load after reopen: got %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestFileStoreDropsCutLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sessions.jsonl")
+	store, err := session.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(ctx, "s", nil)
+	store.Append(ctx, "s", text("user", "kept"))
+	store.Close()
+
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"op":"append","id":"s","mess`)
+	f.Close()
+
+	store, err = session.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("expected a cut-off line dropped, got %v", err)
+	}
+	defer store.Close()
+	if err := store.Append(ctx, "s", text("user", "next")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Load(ctx, "s"); len(got) != 2 {
+		t.Errorf("expected two messages, got %+v", got)
+	}
+}
+
+func TestDirStoreRejectsPathIDs(t *testing.T) {
+	store, err := session.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"..", "a/b", `a\b`} {
+		if _, err := store.Create(context.Background(), id, nil); !errors.Is(err, session.ErrInvalidID) {
+			t.Errorf("Create(%q): expected ErrInvalidID, got %v", id, err)
+		}
+	}
+}
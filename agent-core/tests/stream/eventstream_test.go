@@ -2,6 +2,7 @@ package stream_test
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -234,3 +235,33 @@ func TestEventStreamWithCustomTypes(t *testing.T) {
 		t.Errorf("Unexpected result: %+v", result)
 	}
 }
+
+func TestEventStreamOnEndConcurrent(t *testing.T) {
+	s := stream.NewEventStream[int, string]()
+
+	var mu sync.Mutex
+	calls := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.OnEnd(func() {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+			})
+			s.Push(1)
+		}()
+	}
+	go func() {
+		for range s.Events() {
+		}
+	}()
+	s.End("done")
+	wg.Wait()
+
+	if calls != 20 {
+		t.Errorf("Expected every OnEnd callback to run once, got %d calls", calls)
+	}
+}
@@ -2,6 +2,7 @@ package transform_test
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/biome/agent-core/packages/agent/transform"
@@ -194,3 +195,38 @@ func TestPipelineEmptyMessages(t *testing.T) {
 		t.Errorf("Expected 0 messages, got %d", len(result))
 	}
 }
+
+func TestCompactHistory(t *testing.T) {
+	text := func(s string) []types.ContentBlock { return []types.ContentBlock{types.TextContent{Text: s}} }
+	messages := []types.AgentMessage{
+		types.UserMessage{Content: text("first")},
+		types.AssistantMessage{Content: []types.ContentBlock{types.ToolCallContent{ID: "c1", Name: "search"}}},
+		types.ToolResultMessage{ToolCallID: "c1", Content: text("result")},
+		types.ControlMessage{Content: text("continue")},
+		types.AssistantMessage{Content: []types.ContentBlock{types.ToolCallContent{ID: "c2", Name: "search"}}},
+		types.ToolResultMessage{ToolCallID: "c2", Content: text("result")},
+		types.ControlMessage{Content: text("continue")},
+		types.AssistantMessage{Content: text("answer")},
+	}
+
+	result, err := transform.CompactHistory()(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The tail starts at the second control message so c2 keeps its result.
+	if len(result) != 4 {
+		t.Fatalf("Expected first message, note and 2 recent messages, got %d", len(result))
+	}
+	if !reflect.DeepEqual(result[0], messages[0]) || !reflect.DeepEqual(result[2:], messages[6:]) {
+		t.Errorf("Unexpected messages kept: %+v", result)
+	}
+	note, ok := result[1].(types.ControlMessage)
+	if !ok || note.Content[0].(types.TextContent).Text != "[5 earlier messages were removed to fit the context window.]" {
+		t.Errorf("Expected a note about the removed messages, got %+v", result[1])
+	}
+
+	short := messages[:3]
+	if result, _ := transform.CompactHistory()(context.Background(), short); len(result) != 3 {
+		t.Errorf("Expected a short history to stay unchanged, got %d messages", len(result))
+	}
+}
@@ -0,0 +1,148 @@
+package types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+func TestMessageConstructorsStampIdentity(t *testing.T) {
+	a := types.NewUserMessage(types.TextContent{Text: "hi"})
+	b := types.NewUserMessage(types.TextContent{Text: "hi"})
+	if a.ID == "" || a.ID == b.ID || !strings.HasPrefix(a.ID, "msg_") {
+		t.Errorf("expected unique message IDs, got %q and %q", a.ID, b.ID)
+	}
+	if a.Timestamp() == 0 {
+		t.Error("expected a creation time")
+	}
+
+	stamped := types.Stamp(types.ControlMessage{}).(types.ControlMessage)
+	if stamped.ID == "" || stamped.CreatedAt == 0 {
+		t.Errorf("expected Stamp to set identity, got %+v", stamped)
+	}
+	if again := types.Stamp(stamped).(types.ControlMessage); again.ID != stamped.ID || again.CreatedAt != stamped.CreatedAt {
+		t.Error("expected Stamp to keep an existing identity")
+	}
+}
+
+func TestMessageCodecRoundTrip(t *testing.T) {
+	errMsg := "boom"
+	messages := []types.AgentMessage{
+		types.UserMessage{
+			Content:   []types.ContentBlock{types.TextContent{Text: "look"}, types.ImageContent{Data: "aGk=", MimeType: "image/png"}},
+			ID:        "msg_1",
+			CreatedAt: 1700000000000,
+			Metadata:  map[string]interface{}{"tag": "a"},
+		},
+		types.AssistantMessage{
+			Content: []types.ContentBlock{
+				types.ThinkingContent{Thinking: "hmm", Signature: "sig"},
+				types.TextContent{Text: "calling"},
+				types.ToolCallContent{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
+			},
+			API:          "messages",
+			Provider:     "fake",
+			Model:        "m",
+			Usage:        types.UsageMetrics{Input: 3, Output: 4, TotalTokens: 7, Cost: types.Cost{Total: 0.5}},
+			StopReason:   types.StopReasonError,
+			ErrorMessage: &errMsg,
+			ID:           "msg_2",
+			CreatedAt:    1700000000001,
+		},
+		types.ToolCallMessage{ToolCallID: "call_1", ToolName: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}, ID: "msg_3"},
+		types.ToolResultMessage{
+			Content:    []types.ContentBlock{types.TextContent{Text: "4"}},
+			ToolCallID: "call_1",
+			ToolName:   "calculator",
+			Details:    map[string]interface{}{"result": float64(4)},
+			IsError:    true,
+			ID:         "msg_4",
+		},
+		types.ControlMessage{Content: []types.ContentBlock{types.TextContent{Text: "go on"}}, ID: "msg_5"},
+	}
+
+	data, err := types.MarshalMessages(messages)
+	if err != nil {
+		t.Fatalf("MarshalMessages: %v", err)
+	}
+	got, err := types.UnmarshalMessages(data)
+	if err != nil {
+		t.Fatalf("UnmarshalMessages: %v", err)
+	}
+	if !reflect.DeepEqual(got, messages) {
+		t.Errorf("round trip changed the messages:\ngot  %+v\nwant %+v", got, messages)
+	}
+
+	// encoding/json uses the codec too, so a plain slice keeps the concrete types
+	plain, err := json.Marshal(messages)
+	if err != nil || string(plain) != string(data) {
+		t.Errorf("expected json.Marshal to match MarshalMessages, got %s, %v", plain, err)
+	}
+	var raw []json.RawMessage
+	json.Unmarshal(plain, &raw)
+	var user types.UserMessage
+	if err := json.Unmarshal(raw[0], &user); err != nil || !reflect.DeepEqual(user, messages[0]) {
+		t.Errorf("expected the user message decoded, got %+v, %v", user, err)
+	}
+}
+
+func TestMessageCodecTags(t *testing.T) {
+	data, err := types.MarshalMessage(types.AssistantMessage{Content: []types.ContentBlock{types.TextContent{Text: "hi"}}, StopReason: types.StopReasonStop})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	if raw["v"] != float64(types.CodecVersion) || raw["role"] != "assistant" || raw["stop_reason"] != "stop" {
+		t.Errorf("unexpected encoding %s", data)
+	}
+	if block := raw["content"].([]interface{})[0].(map[string]interface{}); block["type"] != "text" || block["text"] != "hi" {
+		t.Errorf("unexpected content encoding %s", data)
+	}
+}
+
+func TestMessageCodecErrors(t *testing.T) {
+	if _, err := types.UnmarshalMessage([]byte(`{"v":99,"role":"user"}`)); err == nil {
+		t.Error("expected a newer codec version rejected")
+	}
+	if _, err := types.UnmarshalMessage([]byte(`{"v":1,"role":"unknown"}`)); !errors.Is(err, types.ErrUnknownMessage) {
+		t.Errorf("expected ErrUnknownMessage, got %v", err)
+	}
+	if _, err := types.UnmarshalMessage([]byte(`{"role":"user","content":[{"type":"video"}]}`)); !errors.Is(err, types.ErrUnknownContent) {
+		t.Errorf("expected ErrUnknownContent, got %v", err)
+	}
+	var user types.UserMessage
+	if err := json.Unmarshal([]byte(`{"v":1,"role":"assistant"}`), &user); err == nil {
+		t.Error("expected an assistant message not to decode into a UserMessage")
+	}
+	if _, err := types.MarshalMessage(CustomNotificationMessage{}); !errors.Is(err, types.ErrUnknownMessage) {
+		t.Errorf("expected unregistered custom messages rejected, got %v", err)
+	}
+}
+
+type bookmarkMessage struct {
+	Label string `json:"label"`
+}
+
+func (b bookmarkMessage) Role() string     { return "bookmark" }
+func (b bookmarkMessage) Timestamp() int64 { return 0 }
+
+func TestMessageCodecCustomType(t *testing.T) {
+	types.RegisterMessageType("bookmark", func(data json.RawMessage) (types.AgentMessage, error) {
+		var b bookmarkMessage
+		err := json.Unmarshal(data, &b)
+		return b, err
+	})
+	data, err := types.MarshalMessage(bookmarkMessage{Label: "here"})
+	if err != nil {
+		t.Fatalf("MarshalMessage: %v", err)
+	}
+	got, err := types.UnmarshalMessage(data)
+	if err != nil || got != (bookmarkMessage{Label: "here"}) {
+		t.Errorf("expected the custom message back, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// Provider implements the provider.Provider interface for the Anthropic Messages API
+type Provider struct {
+	client         *Client
+	model          string
+	thinkingBudget int
+}
+
+// NewProvider creates an Anthropic provider
+func NewProvider(apiKey, model string) *Provider {
+	return &Provider{
+		client: NewClient(apiKey),
+		model:  model,
+	}
+}
+
+// NewProviderFromConfig creates an Anthropic provider from a provider.Config. BaseURL defaults to DefaultBaseURL.
+func NewProviderFromConfig(cfg provider.Config) *Provider {
+	p := NewProvider(cfg.APIKey, cfg.Model)
+	if cfg.BaseURL != "" {
+		p.client.baseURL = cfg.BaseURL
+	}
+	return p
+}
+
+// WithThinking enables extended thinking with the given token budget (0 disables it). Returns p for chaining.
+func (p *Provider) WithThinking(budgetTokens int) *Provider {
+	p.thinkingBudget = budgetTokens
+	return p
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	return p.client.Stream(ctx, req, p.model, p.thinkingBudget)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	return p.client.Complete(ctx, req, p.model, p.thinkingBudget)
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return "anthropic"
+}
+
+// Model returns the model requests are sent to.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// Models implements provider.Provider
+func (p *Provider) Models() []string {
+	return []string{
+		"claude-3-5-haiku-latest",
+		"claude-3-7-sonnet-latest",
+		"claude-sonnet-4-0",
+		"claude-opus-4-0",
+	}
+}
@@ -0,0 +1,386 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+const (
+	DefaultBaseURL   = "https://api.anthropic.com"
+	APIVersion       = "2023-06-01"
+	DefaultMaxTokens = 4096
+)
+
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(apiKey string) *Client {
+	return &Client{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{},
+	}
+}
+
+// messagesRequest is the body of POST /v1/messages
+type messagesRequest struct {
+	Model       string          `json:"model"`
+	System      string          `json:"system,omitempty"`
+	Messages    []apiMessage    `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+	Tools       []toolDef       `json:"tools,omitempty"`
+	ToolChoice  *toolChoice     `json:"tool_choice,omitempty"`
+	Thinking    *thinkingConfig `json:"thinking,omitempty"`
+}
+
+// toolChoice is the Messages API tool_choice: "auto", "any", "tool" (with name) or "none".
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type thinkingConfig struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// apiMessage is one user or assistant turn; content is always a list of blocks.
+type apiMessage struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock covers every block type sent or received: text, image, thinking, tool_use, tool_result.
+type contentBlock struct {
+	Type string `json:"type"`
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// image
+	Source *imageSource `json:"source,omitempty"`
+
+	// thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string         `json:"tool_use_id,omitempty"`
+	Content   []contentBlock `json:"content,omitempty"`
+	IsError   bool           `json:"is_error,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type toolDef struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema interface{} `json:"input_schema"`
+}
+
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// apiError is the error envelope returned by the API and sent as an "error" SSE event.
+type apiError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// createRequest builds HTTP request with auth and version headers
+func (c *Client) createRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", APIVersion)
+
+	return req, nil
+}
+
+// buildRequest converts a provider request into a Messages API request.
+func (c *Client) buildRequest(req provider.CompletionRequest, model string, thinkingBudget int, stream bool) messagesRequest {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	system := req.SystemPrompt
+	// The Messages API has no response_format; describe the requested JSON in the system prompt instead.
+	if instr := req.ResponseFormat.Instructions(); instr != "" {
+		system = strings.TrimSpace(system + "\n\n" + instr)
+	}
+	out := messagesRequest{
+		Model:     model,
+		System:    system,
+		Messages:  convertMessages(req.Messages),
+		MaxTokens: maxTokens,
+		Stream:    stream,
+		Tools:     convertTools(req.Tools),
+	}
+	if len(out.Tools) > 0 {
+		out.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
+	if thinkingBudget > 0 {
+		// Extended thinking requires max_tokens > budget and does not accept a custom temperature.
+		out.Thinking = &thinkingConfig{Type: "enabled", BudgetTokens: thinkingBudget}
+		if out.MaxTokens <= thinkingBudget {
+			out.MaxTokens = thinkingBudget + maxTokens
+		}
+	} else if req.Temperature != 0 {
+		t := req.Temperature
+		out.Temperature = &t
+	}
+	return out
+}
+
+// Complete makes a non-streaming request
+func (c *Client) Complete(ctx context.Context, req provider.CompletionRequest, model string, thinkingBudget int) (*provider.CompletionResponse, error) {
+	httpReq, err := c.createRequest(ctx, "POST", "/v1/messages", c.buildRequest(req, model, thinkingBudget, false))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, provider.NewHTTPError(resp.StatusCode, string(body), resp.Header)
+	}
+
+	var msgResp messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	out := &provider.CompletionResponse{
+		Usage:        convertUsage(msgResp.Usage),
+		Model:        msgResp.Model,
+		FinishReason: convertStopReason(msgResp.StopReason),
+	}
+	if out.Model == "" {
+		out.Model = model
+	}
+	for _, block := range msgResp.Content {
+		switch block.Type {
+		case "text":
+			out.Text += block.Text
+		case "thinking":
+			out.Reasoning = append(out.Reasoning, provider.ReasoningBlock{Text: block.Thinking, Signature: block.Signature})
+		case "tool_use":
+			out.ToolCalls = append(out.ToolCalls, provider.ToolCallResponse{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: parseInput(block.Input),
+			})
+		}
+	}
+	return out, nil
+}
+
+// convertUsage maps Messages API usage to provider usage. Input tokens include cached reads and writes.
+func convertUsage(u usage) provider.UsageInfo {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return provider.UsageInfo{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
+}
+
+// parseInput decodes a tool_use input object; returns an empty map when input is missing or invalid.
+func parseInput(raw json.RawMessage) map[string]interface{} {
+	var args map[string]interface{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &args)
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return args
+}
+
+// convertMessages converts agent-core messages to Messages API turns. Tool results become
+// tool_result blocks in a user turn, and consecutive turns with the same role are merged
+// because the API requires user and assistant turns to alternate.
+func convertMessages(messages []types.Message) []apiMessage {
+	result := make([]apiMessage, 0, len(messages))
+	appendTurn := func(role string, blocks []contentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content = append(result[n-1].Content, blocks...)
+			return
+		}
+		result = append(result, apiMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case types.UserMessage:
+			appendTurn("user", convertUserBlocks(m.Content))
+		case types.AssistantMessage:
+			var blocks []contentBlock
+			for _, block := range m.Content {
+				switch b := block.(type) {
+				case types.ThinkingContent:
+					// Unsigned thinking cannot be replayed; the API rejects it.
+					if b.Signature != "" {
+						blocks = append(blocks, contentBlock{Type: "thinking", Thinking: b.Thinking, Signature: b.Signature})
+					}
+				case types.TextContent:
+					if b.Text != "" {
+						blocks = append(blocks, contentBlock{Type: "text", Text: b.Text})
+					}
+				case types.ToolCallContent:
+					input, err := json.Marshal(b.Arguments)
+					if err != nil || b.Arguments == nil {
+						input = []byte("{}")
+					}
+					blocks = append(blocks, contentBlock{Type: "tool_use", ID: b.ID, Name: b.Name, Input: input})
+				}
+			}
+			appendTurn("assistant", blocks)
+		case types.ToolResultMessage:
+			inner := convertUserBlocks(m.Content)
+			if len(inner) == 0 && m.Details != nil {
+				if j, err := json.Marshal(m.Details); err == nil {
+					inner = []contentBlock{{Type: "text", Text: string(j)}}
+				}
+			}
+			appendTurn("user", []contentBlock{{
+				Type:      "tool_result",
+				ToolUseID: m.ToolCallID,
+				Content:   inner,
+				IsError:   m.IsError,
+			}})
+		}
+	}
+
+	return result
+}
+
+// convertUserBlocks converts text and image blocks; empty text is dropped (the API rejects it).
+func convertUserBlocks(content []types.ContentBlock) []contentBlock {
+	var blocks []contentBlock
+	for _, block := range content {
+		switch b := block.(type) {
+		case types.TextContent:
+			if b.Text != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: b.Text})
+			}
+		case types.ImageContent:
+			blocks = append(blocks, contentBlock{Type: "image", Source: convertImage(b)})
+		}
+	}
+	return blocks
+}
+
+// convertImage maps ImageContent to an image source: http(s) URLs are passed by reference, anything else is base64 data.
+func convertImage(img types.ImageContent) *imageSource {
+	if strings.HasPrefix(img.Data, "http://") || strings.HasPrefix(img.Data, "https://") {
+		return &imageSource{Type: "url", URL: img.Data}
+	}
+	data := img.Data
+	mediaType := img.MimeType
+	// Accept data URLs ("data:image/png;base64,....") as well as raw base64.
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		if meta, payload, found := strings.Cut(rest, ","); found {
+			data = payload
+			if mediaType == "" {
+				mediaType = strings.TrimSuffix(meta, ";base64")
+			}
+		}
+	}
+	return &imageSource{Type: "base64", MediaType: mediaType, Data: data}
+}
+
+// convertToolChoice maps a provider tool choice to tool_choice; "required" is called "any" here
+func convertToolChoice(c *provider.ToolChoice) *toolChoice {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case provider.ToolChoiceRequired:
+		return &toolChoice{Type: "any"}
+	case provider.ToolChoiceTool:
+		return &toolChoice{Type: "tool", Name: c.Name}
+	}
+	return &toolChoice{Type: string(c.Mode)}
+}
+
+// convertTools converts provider tools to Messages API tool definitions
+func convertTools(tools []provider.Tool) []toolDef {
+	if len(tools) == 0 {
+		return nil
+	}
+	result := make([]toolDef, 0, len(tools))
+	for _, t := range tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		result = append(result, toolDef{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: schema,
+		})
+	}
+	return result
+}
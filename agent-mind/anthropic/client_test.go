@@ -0,0 +1,289 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// newTestProvider returns a provider pointed at an httptest server running handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewProviderFromConfig(provider.Config{APIKey: "test-key", BaseURL: srv.URL, Model: "claude-test"})
+}
+
+func TestConvertMessagesToolRoundTrip(t *testing.T) {
+	msgs := []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2?"}}},
+		types.AssistantMessage{Content: []types.ContentBlock{
+			types.ThinkingContent{Thinking: "use the tool", Signature: "sig"},
+			types.ThinkingContent{Thinking: "unsigned is dropped"},
+			types.ToolCallContent{ID: "tu_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
+		}},
+		types.ToolResultMessage{ToolCallID: "tu_1", ToolName: "calculator", Content: []types.ContentBlock{types.TextContent{Text: "4"}}},
+		types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "continue"}}},
+	}
+
+	got := convertMessages(msgs)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 turns (user, assistant, merged user), got %d", len(got))
+	}
+	if got[1].Role != "assistant" || len(got[1].Content) != 2 {
+		t.Fatalf("unexpected assistant turn: %+v", got[1])
+	}
+	if got[1].Content[0].Type != "thinking" || got[1].Content[0].Signature != "sig" {
+		t.Errorf("expected signed thinking block first, got %+v", got[1].Content[0])
+	}
+	if got[1].Content[1].Type != "tool_use" || string(got[1].Content[1].Input) != `{"expression":"2+2"}` {
+		t.Errorf("unexpected tool_use block: %+v", got[1].Content[1])
+	}
+	user := got[2]
+	if user.Role != "user" || len(user.Content) != 2 {
+		t.Fatalf("expected tool_result and text merged into one user turn, got %+v", user)
+	}
+	if user.Content[0].Type != "tool_result" || user.Content[0].ToolUseID != "tu_1" || user.Content[0].Content[0].Text != "4" {
+		t.Errorf("unexpected tool_result block: %+v", user.Content[0])
+	}
+}
+
+func TestCompleteRequestAndResponse(t *testing.T) {
+	var body messagesRequest
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != APIVersion {
+			t.Errorf("missing auth/version headers: %v", r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		fmt.Fprint(w, `{
+			"id":"msg_1","model":"claude-test-20250101","stop_reason":"tool_use",
+			"content":[
+				{"type":"thinking","thinking":"need math","signature":"abc"},
+				{"type":"text","text":"Let me calculate."},
+				{"type":"tool_use","id":"tu_1","name":"calculator","input":{"expression":"2+2"}}
+			],
+			"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":3}
+		}`)
+	})
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		SystemPrompt: "be terse",
+		Messages:     []types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2?"}}}},
+		Temperature:  0.5,
+		Tools:        []provider.Tool{{Name: "calculator", Description: "math", Parameters: map[string]interface{}{"type": "object"}}},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if body.System != "be terse" || body.MaxTokens != DefaultMaxTokens || body.Temperature == nil || *body.Temperature != 0.5 {
+		t.Errorf("unexpected request body: %+v", body)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Name != "calculator" {
+		t.Errorf("expected calculator tool, got %+v", body.Tools)
+	}
+	if resp.Text != "Let me calculate." || resp.Model != "claude-test-20250101" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Signature != "abc" {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if resp.Usage.PromptTokens != 13 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 18 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`)
+	})
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	if err == nil || !strings.Contains(err.Error(), "API error 400") {
+		t.Errorf("expected API error 400, got %v", err)
+	}
+}
+
+func TestThinkingOmitsTemperature(t *testing.T) {
+	var raw map[string]interface{}
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &raw)
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"ok"}]}`)
+	})
+	p.WithThinking(2048)
+	if _, err := p.Complete(context.Background(), provider.CompletionRequest{Temperature: 0.7, MaxTokens: 1000}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, ok := raw["temperature"]; ok {
+		t.Error("temperature must be omitted when thinking is enabled")
+	}
+	thinking, _ := raw["thinking"].(map[string]interface{})
+	if thinking["budget_tokens"] != float64(2048) {
+		t.Errorf("unexpected thinking config: %v", raw["thinking"])
+	}
+	if raw["max_tokens"].(float64) <= 2048 {
+		t.Errorf("max_tokens must exceed thinking budget, got %v", raw["max_tokens"])
+	}
+}
+
+const streamFixture = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-test-20250101","usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Adding."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-1"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Let me "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"check."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"tu_1","name":"calculator","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"expression\": \"2+"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"2\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestStream(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var body messagesRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			t.Error("expected stream=true")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, streamFixture)
+	})
+
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var partial []map[string]interface{}
+	resp, err := provider.Collect(events, func(ev provider.StreamEvent) {
+		if ev.Type == provider.EventToolDelta {
+			partial = append(partial, ev.Content.(*provider.ToolCallStreamPayload).Arguments)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if resp.Text != "Let me check." {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "Adding." || resp.Reasoning[0].Signature != "sig-1" {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if len(partial) != 3 {
+		t.Errorf("expected 3 tool deltas (start + 2 input_json_delta), got %d", len(partial))
+	} else if partial[1]["expression"] != "2+" || partial[2]["expression"] != "2+2" {
+		t.Errorf("expected each delta to carry the arguments so far, got %v", partial)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "tu_1" || resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if resp.Model != "claude-test-20250101" {
+		t.Errorf("unexpected model %q", resp.Model)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 30 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	if resp.FinishReason != provider.FinishToolCalls {
+		t.Errorf("unexpected finish reason %q", resp.FinishReason)
+	}
+}
+
+func TestStreamErrorEvent(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n"+
+			"event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	})
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	_, err = provider.Collect(events, nil)
+	if !errors.Is(err, provider.ErrServer) || !strings.Contains(err.Error(), "overloaded_error") {
+		t.Errorf("expected a server error mentioning overloaded_error, got %v", err)
+	}
+}
+
+func TestToolChoiceMapping(t *testing.T) {
+	var raw map[string]interface{}
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &raw)
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"ok"}]}`)
+	})
+	tools := []provider.Tool{{Name: "lookup", Parameters: map[string]interface{}{"type": "object"}}}
+	tests := []struct {
+		choice   *provider.ToolChoice
+		wantType string
+		wantName string
+	}{
+		{provider.RequireTools(), "any", ""},
+		{provider.ForceTool("lookup"), "tool", "lookup"},
+		{provider.DisableTools(), "none", ""},
+	}
+	for _, tt := range tests {
+		if _, err := p.Complete(context.Background(), provider.CompletionRequest{Tools: tools, ToolChoice: tt.choice}); err != nil {
+			t.Fatalf("Complete(%s): %v", tt.choice, err)
+		}
+		tc, _ := raw["tool_choice"].(map[string]interface{})
+		if tc["type"] != tt.wantType || (tt.wantName != "" && tc["name"] != tt.wantName) {
+			t.Errorf("%s: unexpected tool_choice %v", tt.choice, raw["tool_choice"])
+		}
+	}
+}
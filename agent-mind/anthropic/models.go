@@ -0,0 +1,34 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// knownModels describes the models listed by Models(). All accept tools and images and have a 200k
+// context window; the Messages API has no response_format, so structured output is prompt-based.
+var knownModels = provider.NewCatalog([]provider.ModelInfo{
+	{ID: "claude-3-5-haiku-latest", Name: "Claude 3.5 Haiku", ContextWindow: 200000, MaxOutputTokens: 8192, Tools: true, Vision: true,
+		Pricing: &provider.ModelPrice{Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00}},
+	{ID: "claude-3-7-sonnet-latest", Name: "Claude 3.7 Sonnet", ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, Reasoning: true,
+		Pricing: &provider.ModelPrice{Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75}},
+	{ID: "claude-sonnet-4-0", Name: "Claude Sonnet 4", ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, Reasoning: true,
+		Pricing: &provider.ModelPrice{Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75}},
+	{ID: "claude-opus-4-0", Name: "Claude Opus 4", ContextWindow: 200000, MaxOutputTokens: 32000, Tools: true, Vision: true, Reasoning: true,
+		Pricing: &provider.ModelPrice{Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75}},
+})
+
+// ListModels implements provider.Cataloger
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	return knownModels.Models(), nil
+}
+
+// Capabilities implements provider.Cataloger
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	if m, ok := knownModels.Lookup(p.model); ok {
+		return m, nil
+	}
+	return provider.ModelInfo{}, fmt.Errorf("anthropic: %s: %w", p.model, provider.ErrModelNotFound)
+}
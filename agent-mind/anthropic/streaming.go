@@ -0,0 +1,271 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/sse"
+)
+
+// streamEvent is the union of Messages API SSE payloads; Type selects which fields are set.
+type streamEvent struct {
+	Type         string        `json:"type"`
+	Index        int           `json:"index"`
+	Message      *streamMsg    `json:"message,omitempty"`       // message_start
+	ContentBlock *contentBlock `json:"content_block,omitempty"` // content_block_start
+	Delta        *streamDelta  `json:"delta,omitempty"`         // content_block_delta, message_delta
+	Usage        *usage        `json:"usage,omitempty"`         // message_delta
+	Error        *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"` // error
+}
+
+type streamMsg struct {
+	Model string `json:"model"`
+	Usage usage  `json:"usage"`
+}
+
+type streamDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+// streamBlockState accumulates one content block while it streams
+type streamBlockState struct {
+	Type  string
+	ID    string
+	Name  string
+	Input provider.PartialJSON
+}
+
+// Stream creates a streaming request to the Messages API
+func (c *Client) Stream(ctx context.Context, req provider.CompletionRequest, model string, thinkingBudget int) (<-chan provider.StreamEvent, error) {
+	httpReq, err := c.createRequest(ctx, "POST", "/v1/messages", c.buildRequest(req, model, thinkingBudget, true))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, provider.NewHTTPError(resp.StatusCode, string(body), resp.Header)
+	}
+
+	events := make(chan provider.StreamEvent, 10)
+	go c.parseSSE(ctx, resp.Body, events, model)
+	return events, nil
+}
+
+// parseSSE parses Messages API Server-Sent Events. Only the data is used; the JSON "type" field
+// duplicates the SSE event name.
+func (c *Client) parseSSE(ctx context.Context, body io.ReadCloser, events chan<- provider.StreamEvent, model string) {
+	defer close(events)
+	defer body.Close()
+
+	send := func(ev provider.StreamEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	reader := sse.NewReader(body)
+	blocks := make(map[int]*streamBlockState)
+	var u usage
+	var finishReason provider.FinishReason
+	modelUsed := model
+
+	for {
+		msg, err := reader.Next()
+		if ctx.Err() != nil {
+			events <- provider.StreamEvent{Type: provider.EventError, Error: ctx.Err()}
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("stream ended before message_stop")})
+			return
+		}
+		if err != nil {
+			send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("stream error: %w", err)})
+			return
+		}
+
+		var ev streamEvent
+		if err := json.Unmarshal([]byte(msg.Data), &ev); err != nil {
+			continue
+		}
+
+		switch ev.Type {
+		case "message_start":
+			if ev.Message != nil {
+				if ev.Message.Model != "" {
+					modelUsed = ev.Message.Model
+				}
+				u = ev.Message.Usage
+			}
+			if !send(provider.StreamEvent{Type: provider.EventStart}) {
+				return
+			}
+
+		case "content_block_start":
+			st := &streamBlockState{}
+			if ev.ContentBlock != nil {
+				st.Type = ev.ContentBlock.Type
+				st.ID = ev.ContentBlock.ID
+				st.Name = ev.ContentBlock.Name
+			}
+			blocks[ev.Index] = st
+			if st.Type == "tool_use" {
+				ok := send(provider.StreamEvent{
+					Type: provider.EventToolDelta,
+					Content: &provider.ToolCallStreamPayload{
+						Index:     ev.Index,
+						ID:        st.ID,
+						Name:      st.Name,
+						Arguments: map[string]interface{}{},
+					},
+				})
+				if !ok {
+					return
+				}
+			}
+
+		case "content_block_delta":
+			if ev.Delta == nil {
+				continue
+			}
+			var out provider.StreamEvent
+			switch ev.Delta.Type {
+			case "text_delta":
+				out = provider.StreamEvent{Type: provider.EventTextDelta, Delta: ev.Delta.Text}
+			case "thinking_delta":
+				out = provider.StreamEvent{
+					Type:    provider.EventReasoningDelta,
+					Delta:   ev.Delta.Thinking,
+					Content: &provider.ReasoningStreamPayload{Index: ev.Index},
+				}
+			case "signature_delta":
+				out = provider.StreamEvent{
+					Type:    provider.EventReasoningDelta,
+					Content: &provider.ReasoningStreamPayload{Index: ev.Index, Signature: ev.Delta.Signature},
+				}
+			case "input_json_delta":
+				st := blocks[ev.Index]
+				if st == nil {
+					st = &streamBlockState{Type: "tool_use"}
+					blocks[ev.Index] = st
+				}
+				st.Input.Append(ev.Delta.PartialJSON)
+				out = provider.StreamEvent{
+					Type: provider.EventToolDelta,
+					Content: &provider.ToolCallStreamPayload{
+						Index:     ev.Index,
+						ID:        st.ID,
+						Name:      st.Name,
+						Arguments: st.Input.Object(),
+					},
+				}
+			default:
+				continue
+			}
+			if !send(out) {
+				return
+			}
+
+		case "content_block_stop":
+			st := blocks[ev.Index]
+			if st == nil || st.Type != "tool_use" {
+				continue
+			}
+			ok := send(provider.StreamEvent{
+				Type: provider.EventToolCall,
+				Content: &provider.ToolCallResponse{
+					ID:        st.ID,
+					Name:      st.Name,
+					Arguments: st.Input.Object(),
+				},
+			})
+			if !ok {
+				return
+			}
+
+		case "message_delta":
+			if ev.Usage != nil {
+				u.OutputTokens = ev.Usage.OutputTokens
+			}
+			if ev.Delta != nil && ev.Delta.StopReason != "" {
+				finishReason = convertStopReason(ev.Delta.StopReason)
+			}
+
+		case "message_stop":
+			send(provider.StreamEvent{
+				Type:    provider.EventDone,
+				Content: &provider.StreamDonePayload{Model: modelUsed, Usage: convertUsage(u), FinishReason: finishReason},
+			})
+			return
+
+		case "error":
+			status := http.StatusInternalServerError
+			if ev.Error != nil {
+				status = errorStatus(ev.Error.Type)
+			}
+			send(provider.StreamEvent{Type: provider.EventError, Error: provider.NewHTTPError(status, msg.Data, nil)})
+			return
+		}
+	}
+}
+
+// errorStatus returns the HTTP status the API uses for an error type, so that an error event sent
+// mid-stream is classified like the same error returned before the stream started
+func errorStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	}
+	return http.StatusInternalServerError
+}
+
+// convertStopReason maps a stop_reason to a provider.FinishReason (unknown values pass through)
+func convertStopReason(reason string) provider.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return provider.FinishStop
+	case "max_tokens":
+		return provider.FinishLength
+	case "tool_use":
+		return provider.FinishToolCalls
+	case "refusal":
+		return provider.FinishContentFilter
+	}
+	return provider.FinishReason(reason)
+}
@@ -0,0 +1,82 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// Provider implements the provider.Provider interface for the Gemini generateContent API
+type Provider struct {
+	client         *Client
+	model          string
+	thinkingBudget *int
+}
+
+// NewProvider creates a Gemini provider
+func NewProvider(apiKey, model string) *Provider {
+	return &Provider{
+		client: NewClient(apiKey),
+		model:  model,
+	}
+}
+
+// NewProviderFromConfig creates a Gemini provider from a provider.Config. BaseURL defaults to DefaultBaseURL.
+func NewProviderFromConfig(cfg provider.Config) *Provider {
+	p := NewProvider(cfg.APIKey, cfg.Model)
+	if cfg.BaseURL != "" {
+		p.client.baseURL = cfg.BaseURL
+	}
+	return p
+}
+
+// WithThinking sets the thinking token budget and asks for thought summaries (-1 lets the model decide,
+// 0 turns thinking off where the model allows it). Without it the model's default applies and no
+// thoughts are returned. Returns p for chaining.
+func (p *Provider) WithThinking(budgetTokens int) *Provider {
+	p.thinkingBudget = &budgetTokens
+	return p
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	return p.client.Stream(ctx, req, p.model, p.thinkingBudget)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	return p.client.Complete(ctx, req, p.model, p.thinkingBudget)
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return "gemini"
+}
+
+// Model returns the model requests are sent to.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// Models implements provider.Provider
+func (p *Provider) Models() []string {
+	models := knownModels.Models()
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
@@ -0,0 +1,511 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// DefaultBaseURL is the Gemini API (Google AI Studio keys)
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(apiKey string) *Client {
+	return &Client{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{},
+	}
+}
+
+// generateRequest is the body of generateContent and streamGenerateContent
+type generateRequest struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	Tools             []toolDef         `json:"tools,omitempty"`
+	ToolConfig        *toolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+// content is one turn; role is "user" or "model" (omitted for the system instruction)
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// part covers every part type sent or received: text (thought text when Thought is set), inline data,
+// function calls and function responses. ThoughtSignature may ride on any part and must be sent back
+// on the same kind of part.
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	ThoughtSignature string            `json:"thoughtSignature,omitempty"`
+	InlineData       *inlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type inlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type functionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// functionResponse answers a function call by name; Response must be a JSON object
+type functionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type toolDef struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+type functionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// toolConfig carries the function calling mode: AUTO, ANY (optionally limited to some functions) or NONE
+type toolConfig struct {
+	FunctionCallingConfig functionCallingConfig `json:"functionCallingConfig"`
+}
+
+type functionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type generationConfig struct {
+	Temperature      *float64               `json:"temperature,omitempty"`
+	MaxOutputTokens  int                    `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
+	ThinkingConfig   *thinkingConfig        `json:"thinkingConfig,omitempty"`
+}
+
+type thinkingConfig struct {
+	IncludeThoughts bool `json:"includeThoughts"`
+	ThinkingBudget  int  `json:"thinkingBudget"` // -1 = dynamic, 0 = off (where the model allows it)
+}
+
+// generateResponse is the reply of generateContent, and each event of streamGenerateContent
+type generateResponse struct {
+	Candidates     []candidate     `json:"candidates"`
+	UsageMetadata  *usageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	PromptFeedback *promptFeedback `json:"promptFeedback,omitempty"`
+	Error          *apiError       `json:"error,omitempty"`
+}
+
+type candidate struct {
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+}
+
+type promptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type usageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+// convertUsage maps usage metadata to provider usage. The prompt count includes cached tokens;
+// thinking tokens are billed as output, so they count as completion tokens.
+func convertUsage(u *usageMetadata) provider.UsageInfo {
+	if u == nil {
+		return provider.UsageInfo{}
+	}
+	completion := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	total := u.TotalTokenCount
+	if total == 0 {
+		total = u.PromptTokenCount + completion
+	}
+	return provider.UsageInfo{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+		CacheReadTokens:  u.CachedContentTokenCount,
+	}
+}
+
+// blockedError reports a prompt refused outright (no candidates), or nil
+func (r generateResponse) blockedError() error {
+	if r.PromptFeedback != nil && r.PromptFeedback.BlockReason != "" && len(r.Candidates) == 0 {
+		return fmt.Errorf("gemini: prompt blocked: %s: %w", r.PromptFeedback.BlockReason, provider.ErrContentFiltered)
+	}
+	return nil
+}
+
+// createRequest builds HTTP request with the API key header
+func (c *Client) createRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	return req, nil
+}
+
+// modelPath is the path of a model method, e.g. /models/gemini-2.5-flash:generateContent
+func modelPath(model, method string) string {
+	return "/models/" + strings.TrimPrefix(model, "models/") + ":" + method
+}
+
+// buildRequest converts a provider request into a generateContent request.
+func (c *Client) buildRequest(req provider.CompletionRequest, thinkingBudget *int) (generateRequest, error) {
+	contents, err := convertMessages(req.Messages)
+	if err != nil {
+		return generateRequest{}, err
+	}
+	out := generateRequest{
+		Contents: contents,
+		Tools:    convertTools(req.Tools),
+	}
+	if req.SystemPrompt != "" {
+		out.SystemInstruction = &content{Parts: []part{{Text: req.SystemPrompt}}}
+	}
+	if len(out.Tools) > 0 {
+		out.ToolConfig = convertToolChoice(req.ToolChoice)
+	}
+
+	gen := &generationConfig{MaxOutputTokens: req.MaxTokens}
+	if req.Temperature != 0 {
+		t := req.Temperature
+		gen.Temperature = &t
+	}
+	if req.ResponseFormat.IsJSON() {
+		gen.ResponseMimeType = "application/json"
+		if req.ResponseFormat.Type == provider.ResponseFormatJSONSchema {
+			gen.ResponseSchema = convertSchema(req.ResponseFormat.Schema)
+		}
+	}
+	if thinkingBudget != nil {
+		gen.ThinkingConfig = &thinkingConfig{IncludeThoughts: *thinkingBudget != 0, ThinkingBudget: *thinkingBudget}
+	}
+	if gen.Temperature != nil || gen.MaxOutputTokens > 0 || gen.ResponseMimeType != "" || gen.ThinkingConfig != nil {
+		out.GenerationConfig = gen
+	}
+	return out, nil
+}
+
+// Complete makes a non-streaming request
+func (c *Client) Complete(ctx context.Context, req provider.CompletionRequest, model string, thinkingBudget *int) (*provider.CompletionResponse, error) {
+	body, err := c.buildRequest(req, thinkingBudget)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := c.createRequest(ctx, "POST", modelPath(model, "generateContent"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, provider.NewHTTPError(resp.StatusCode, string(b), resp.Header)
+	}
+
+	var genResp generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := genResp.blockedError(); err != nil {
+		return nil, err
+	}
+
+	out := &provider.CompletionResponse{
+		Usage: convertUsage(genResp.UsageMetadata),
+		Model: genResp.ModelVersion,
+	}
+	if out.Model == "" {
+		out.Model = model
+	}
+	var reasoning provider.ReasoningBlock
+	if len(genResp.Candidates) > 0 {
+		for _, p := range genResp.Candidates[0].Content.Parts {
+			if p.ThoughtSignature != "" {
+				reasoning.Signature = p.ThoughtSignature
+			}
+			switch {
+			case p.FunctionCall != nil:
+				out.ToolCalls = append(out.ToolCalls, convertFunctionCall(p.FunctionCall))
+			case p.Thought:
+				reasoning.Text += p.Text
+			default:
+				out.Text += p.Text
+			}
+		}
+		out.FinishReason = convertFinishReason(genResp.Candidates[0].FinishReason, len(out.ToolCalls) > 0)
+	}
+	if reasoning.Text != "" || reasoning.Signature != "" {
+		out.Reasoning = []provider.ReasoningBlock{reasoning}
+	}
+	return out, nil
+}
+
+// convertFinishReason maps a candidate's finishReason to a provider.FinishReason. The API reports STOP
+// after function calls too, so a reply with calls reports FinishToolCalls. Unknown values pass through.
+func convertFinishReason(reason string, calledTools bool) provider.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		if calledTools {
+			return provider.FinishToolCalls
+		}
+		return provider.FinishStop
+	case "MAX_TOKENS":
+		return provider.FinishLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return provider.FinishContentFilter
+	}
+	return provider.FinishReason(reason)
+}
+
+// convertFunctionCall maps a function call part to a tool call. The API only issues call IDs in
+// some cases, so one is generated when missing.
+func convertFunctionCall(fc *functionCall) provider.ToolCallResponse {
+	id := fc.ID
+	if id == "" {
+		id = provider.NewToolCallID()
+	}
+	args := fc.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return provider.ToolCallResponse{ID: id, Name: fc.Name, Arguments: args}
+}
+
+// convertMessages converts agent-core messages to contents. Tool results become functionResponse
+// parts in a user turn, and consecutive turns with the same role are merged so that all responses to
+// one model turn's calls arrive together.
+func convertMessages(messages []types.Message) ([]content, error) {
+	result := make([]content, 0, len(messages))
+	appendTurn := func(role string, parts []part) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Parts = append(result[n-1].Parts, parts...)
+			return
+		}
+		result = append(result, content{Role: role, Parts: parts})
+	}
+
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case types.UserMessage:
+			parts, err := convertUserParts(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			appendTurn("user", parts)
+		case types.AssistantMessage:
+			appendTurn("model", convertModelParts(m.Content))
+		case types.ToolResultMessage:
+			inner, err := convertUserParts(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			text := ""
+			var media []part
+			for _, p := range inner {
+				if p.InlineData != nil {
+					media = append(media, p)
+				} else {
+					text += p.Text
+				}
+			}
+			var value interface{} = text
+			if text == "" && m.Details != nil {
+				value = m.Details
+			}
+			key := "content"
+			if m.IsError {
+				key = "error"
+			}
+			parts := []part{{FunctionResponse: &functionResponse{
+				Name:     m.ToolName,
+				Response: map[string]interface{}{key: value},
+			}}}
+			appendTurn("user", append(parts, media...))
+		}
+	}
+	return result, nil
+}
+
+// convertModelParts converts an assistant message. Thought text is not replayed; the reasoning
+// signature goes back on the first function call (where the API issues it), else on the first text part.
+func convertModelParts(blocks []types.ContentBlock) []part {
+	var parts []part
+	signature := ""
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case types.ThinkingContent:
+			if b.Signature != "" {
+				signature = b.Signature
+			}
+		case types.TextContent:
+			if b.Text != "" {
+				parts = append(parts, part{Text: b.Text})
+			}
+		case types.ToolCallContent:
+			parts = append(parts, part{FunctionCall: &functionCall{Name: b.Name, Args: argumentsObject(b.Arguments)}})
+		}
+	}
+	if signature == "" {
+		return parts
+	}
+	for i := range parts {
+		if parts[i].FunctionCall != nil {
+			parts[i].ThoughtSignature = signature
+			return parts
+		}
+	}
+	if len(parts) > 0 {
+		parts[0].ThoughtSignature = signature
+		return parts
+	}
+	return []part{{ThoughtSignature: signature}}
+}
+
+// convertUserParts converts text and image blocks; empty text is dropped.
+func convertUserParts(blocks []types.ContentBlock) ([]part, error) {
+	var parts []part
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case types.TextContent:
+			if b.Text != "" {
+				parts = append(parts, part{Text: b.Text})
+			}
+		case types.ImageContent:
+			data, err := convertImage(b)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part{InlineData: data})
+		}
+	}
+	return parts, nil
+}
+
+// convertImage maps ImageContent to inline data, accepting data URLs as well as raw base64 (default
+// image/png). Remote URLs are rejected: the API only reads files it hosts.
+func convertImage(img types.ImageContent) (*inlineData, error) {
+	if strings.HasPrefix(img.Data, "http://") || strings.HasPrefix(img.Data, "https://") {
+		return nil, fmt.Errorf("gemini: image URLs are not supported, pass base64 data instead")
+	}
+	data := img.Data
+	mimeType := img.MimeType
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		if meta, payload, found := strings.Cut(rest, ","); found {
+			data = payload
+			if mimeType == "" {
+				mimeType = strings.TrimSuffix(meta, ";base64")
+			}
+		}
+	}
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return &inlineData{MimeType: mimeType, Data: data}, nil
+}
+
+// argumentsObject converts tool call arguments to the JSON object the API expects
+func argumentsObject(args interface{}) map[string]interface{} {
+	if m, ok := args.(map[string]interface{}); ok && m != nil {
+		return m
+	}
+	out := map[string]interface{}{}
+	if args != nil {
+		if j, err := json.Marshal(args); err == nil {
+			_ = json.Unmarshal(j, &out)
+		}
+	}
+	return out
+}
+
+// convertToolChoice maps a provider tool choice to the function calling mode; "required" is ANY, and a
+// forced tool is ANY limited to that function
+func convertToolChoice(c *provider.ToolChoice) *toolConfig {
+	if c == nil {
+		return nil
+	}
+	cfg := functionCallingConfig{Mode: strings.ToUpper(string(c.Mode))}
+	switch c.Mode {
+	case provider.ToolChoiceRequired:
+		cfg.Mode = "ANY"
+	case provider.ToolChoiceTool:
+		cfg.Mode = "ANY"
+		cfg.AllowedFunctionNames = []string{c.Name}
+	}
+	return &toolConfig{FunctionCallingConfig: cfg}
+}
+
+// convertTools converts provider tools to function declarations with Gemini-compatible schemas
+func convertTools(tools []provider.Tool) []toolDef {
+	if len(tools) == 0 {
+		return nil
+	}
+	decls := make([]functionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		params := convertSchema(t.Parameters)
+		// A function without arguments is declared without parameters (an empty OBJECT is rejected)
+		if props, _ := params["properties"].(map[string]interface{}); len(props) == 0 {
+			params = nil
+		}
+		decls = append(decls, functionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  params,
+		})
+	}
+	return []toolDef{{FunctionDeclarations: decls}}
+}
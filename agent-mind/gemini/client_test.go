@@ -0,0 +1,280 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// newTestProvider returns a provider pointed at an httptest server running handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewProviderFromConfig(provider.Config{APIKey: "test-key", BaseURL: srv.URL, Model: "gemini-test"})
+}
+
+func TestConvertMessagesFunctionRoundTrip(t *testing.T) {
+	msgs := []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2 and the time?"}}},
+		types.AssistantMessage{Content: []types.ContentBlock{
+			types.ThinkingContent{Thinking: "summaries are not replayed", Signature: "sig"},
+			types.TextContent{Text: "Checking."},
+			types.ToolCallContent{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
+			types.ToolCallContent{ID: "call_2", Name: "clock"},
+		}},
+		types.ToolResultMessage{ToolCallID: "call_1", ToolName: "calculator", Content: []types.ContentBlock{types.TextContent{Text: "4"}}},
+		types.ToolResultMessage{ToolCallID: "call_2", ToolName: "clock", IsError: true, Content: []types.ContentBlock{
+			types.TextContent{Text: "clock unavailable"},
+			types.ImageContent{Data: "data:image/jpeg;base64,/9j/4AAQ"},
+		}},
+	}
+
+	got, err := convertMessages(msgs)
+	if err != nil {
+		t.Fatalf("convertMessages: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 turns (user, model, merged function responses), got %d", len(got))
+	}
+	model := got[1]
+	if model.Role != "model" || len(model.Parts) != 3 {
+		t.Fatalf("unexpected model turn: %+v", model)
+	}
+	if model.Parts[0].Text != "Checking." || model.Parts[0].ThoughtSignature != "" {
+		t.Errorf("unexpected text part: %+v", model.Parts[0])
+	}
+	if fc := model.Parts[1].FunctionCall; fc == nil || fc.Name != "calculator" || fc.Args["expression"] != "2+2" ||
+		model.Parts[1].ThoughtSignature != "sig" {
+		t.Errorf("expected the signature on the first function call, got %+v", model.Parts[1])
+	}
+	if fc := model.Parts[2].FunctionCall; fc == nil || fc.Args == nil {
+		t.Errorf("expected empty args object for clock, got %+v", model.Parts[2])
+	}
+
+	user := got[2]
+	if user.Role != "user" || len(user.Parts) != 3 {
+		t.Fatalf("expected both function responses and the image in one user turn, got %+v", user)
+	}
+	if fr := user.Parts[0].FunctionResponse; fr == nil || fr.Name != "calculator" || fr.Response["content"] != "4" {
+		t.Errorf("unexpected function response: %+v", user.Parts[0].FunctionResponse)
+	}
+	if fr := user.Parts[1].FunctionResponse; fr == nil || fr.Response["error"] != "clock unavailable" {
+		t.Errorf("expected error response, got %+v", user.Parts[1].FunctionResponse)
+	}
+	if d := user.Parts[2].InlineData; d == nil || d.MimeType != "image/jpeg" || d.Data != "/9j/4AAQ" {
+		t.Errorf("unexpected inline data: %+v", user.Parts[2].InlineData)
+	}
+}
+
+func TestCompleteRequestAndResponse(t *testing.T) {
+	var body map[string]interface{}
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("missing API key header: %v", r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		fmt.Fprint(w, `{
+			"modelVersion":"gemini-test-001",
+			"candidates":[{"finishReason":"STOP","content":{"role":"model","parts":[
+				{"text":"need math","thought":true},
+				{"text":"Let me calculate."},
+				{"functionCall":{"name":"calculator","args":{"expression":"2+2"}},"thoughtSignature":"abc"}
+			]}}],
+			"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"thoughtsTokenCount":7,"cachedContentTokenCount":3,"totalTokenCount":22}
+		}`)
+	})
+	p.WithThinking(1024)
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		SystemPrompt: "be brief",
+		Messages:     []types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2?"}}}},
+		Temperature:  0.5,
+		MaxTokens:    100,
+		Tools: []provider.Tool{{Name: "calculator", Parameters: map[string]interface{}{
+			"type": "object", "additionalProperties": false,
+			"properties": map[string]interface{}{"expression": map[string]interface{}{"type": "string"}},
+		}}},
+		ToolChoice: provider.ForceTool("calculator"),
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	raw, _ := json.Marshal(body)
+	for _, want := range []string{
+		`"systemInstruction":{"parts":[{"text":"be brief"}]}`,
+		`"functionCallingConfig":{"allowedFunctionNames":["calculator"],"mode":"ANY"}`,
+		`"parameters":{"properties":{"expression":{"type":"STRING"}},"type":"OBJECT"}`,
+		`"thinkingConfig":{"includeThoughts":true,"thinkingBudget":1024}`,
+		`"maxOutputTokens":100`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("request missing %s:\n%s", want, raw)
+		}
+	}
+
+	if resp.Text != "Let me calculate." {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "need math" || resp.Reasoning[0].Signature != "abc" {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "calculator" || resp.ToolCalls[0].ID == "" ||
+		resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if resp.Model != "gemini-test-001" {
+		t.Errorf("unexpected model %q", resp.Model)
+	}
+	if u := resp.Usage; u.PromptTokens != 10 || u.CompletionTokens != 12 || u.TotalTokens != 22 || u.CacheReadTokens != 3 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+}
+
+func TestCompleteJSONSchemaAndBlockedPrompt(t *testing.T) {
+	var body generateRequest
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"promptFeedback":{"blockReason":"SAFETY"}}`)
+	})
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{
+		ResponseFormat: provider.JSONSchemaFormat("answer", map[string]interface{}{
+			"type": "object", "properties": map[string]interface{}{"n": map[string]interface{}{"type": []interface{}{"integer", "null"}}},
+		}),
+	})
+	if !errors.Is(err, provider.ErrContentFiltered) || !strings.Contains(err.Error(), "SAFETY") {
+		t.Errorf("expected blocked prompt error, got %v", err)
+	}
+	gen := body.GenerationConfig
+	if gen == nil || gen.ResponseMimeType != "application/json" {
+		t.Fatalf("expected JSON output requested, got %+v", gen)
+	}
+	n, _ := gen.ResponseSchema["properties"].(map[string]interface{})["n"].(map[string]interface{})
+	if n["type"] != "INTEGER" || n["nullable"] != true {
+		t.Errorf("expected translated response schema, got %v", gen.ResponseSchema)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":400,"message":"bad schema","status":"INVALID_ARGUMENT"}}`)
+	})
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	if err == nil || !strings.Contains(err.Error(), "API error 400") {
+		t.Errorf("expected API error 400, got %v", err)
+	}
+}
+
+const streamFixture = `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Adding.","thought":true}]}}],"modelVersion":"gemini-test-001"}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Let me "}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"check."}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"calculator","args":{"expression":"2+2"}},"thoughtSignature":"sig-1"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":30,"totalTokenCount":42}}
+
+`
+
+func TestStream(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("unexpected URL %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, streamFixture)
+	})
+
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var toolDeltas int
+	resp, err := provider.Collect(events, func(ev provider.StreamEvent) {
+		if ev.Type == provider.EventToolDelta {
+			toolDeltas++
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if resp.Text != "Let me check." {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "Adding." || resp.Reasoning[0].Signature != "sig-1" {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if toolDeltas != 1 || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls (%d deltas): %+v", toolDeltas, resp.ToolCalls)
+	}
+	if resp.Model != "gemini-test-001" {
+		t.Errorf("unexpected model %q", resp.Model)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 30 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	if resp.FinishReason != provider.FinishToolCalls {
+		t.Errorf("expected STOP after a function call to report tool_calls, got %q", resp.FinishReason)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"error object": "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hi\"}]}}]}\n\n" +
+			"data: {\"error\":{\"code\":503,\"message\":\"overloaded\",\"status\":\"UNAVAILABLE\"}}\n\n",
+		"cut off": "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hi\"}]}}]}\n\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, body)
+			})
+			events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			if _, err := provider.Collect(events, nil); err == nil {
+				t.Error("expected a stream error")
+			}
+		})
+	}
+}
+
+func TestToolChoiceMapping(t *testing.T) {
+	tests := []struct {
+		choice *provider.ToolChoice
+		want   string
+	}{
+		{nil, "null"},
+		{provider.AutoTools(), `{"functionCallingConfig":{"mode":"AUTO"}}`},
+		{provider.DisableTools(), `{"functionCallingConfig":{"mode":"NONE"}}`},
+		{provider.RequireTools(), `{"functionCallingConfig":{"mode":"ANY"}}`},
+		{provider.ForceTool("clock"), `{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["clock"]}}`},
+	}
+	for _, tt := range tests {
+		got, _ := json.Marshal(convertToolChoice(tt.choice))
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.choice, got, tt.want)
+		}
+	}
+
+	// A tool without arguments is declared without parameters
+	tools := convertTools([]provider.Tool{{Name: "clock", Parameters: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}}})
+	if tools[0].FunctionDeclarations[0].Parameters != nil {
+		t.Errorf("expected no parameters, got %v", tools[0].FunctionDeclarations[0].Parameters)
+	}
+}
@@ -0,0 +1,34 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// knownModels describes the models listed by Models(). All accept tools, images and JSON schemas and have
+// a 1M token context window. Prices are for prompts up to 200k tokens.
+var knownModels = provider.NewCatalog([]provider.ModelInfo{
+	{ID: "gemini-2.0-flash", Name: "Gemini 2.0 Flash", ContextWindow: 1048576, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true,
+		Pricing: &provider.ModelPrice{Input: 0.10, Output: 0.40, CacheRead: 0.025}},
+	{ID: "gemini-2.5-flash-lite", Name: "Gemini 2.5 Flash-Lite", ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, Reasoning: true, StructuredOutput: true,
+		Pricing: &provider.ModelPrice{Input: 0.10, Output: 0.40, CacheRead: 0.025}},
+	{ID: "gemini-2.5-flash", Name: "Gemini 2.5 Flash", ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, Reasoning: true, StructuredOutput: true,
+		Pricing: &provider.ModelPrice{Input: 0.30, Output: 2.50, CacheRead: 0.075}},
+	{ID: "gemini-2.5-pro", Name: "Gemini 2.5 Pro", ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, Reasoning: true, StructuredOutput: true,
+		Pricing: &provider.ModelPrice{Input: 1.25, Output: 10.00, CacheRead: 0.31}},
+})
+
+// ListModels implements provider.Cataloger
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	return knownModels.Models(), nil
+}
+
+// Capabilities implements provider.Cataloger
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	if m, ok := knownModels.Lookup(p.model); ok {
+		return m, nil
+	}
+	return provider.ModelInfo{}, fmt.Errorf("gemini: %s: %w", p.model, provider.ErrModelNotFound)
+}
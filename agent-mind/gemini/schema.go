@@ -0,0 +1,311 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxRefDepth bounds $ref expansion; recursive schemas are cut off below it.
+const maxRefDepth = 8
+
+// schemaKeys are the JSON Schema keywords Gemini's OpenAPI-style Schema accepts unchanged; everything
+// else is translated below or dropped.
+var schemaKeys = map[string]bool{
+	"title": true, "description": true, "nullable": true, "default": true, "example": true,
+	"minItems": true, "maxItems": true, "minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "minProperties": true, "maxProperties": true, "propertyOrdering": true,
+}
+
+// schemaFormats are the formats Gemini accepts for each type.
+var schemaFormats = map[string]map[string]bool{
+	"STRING":  {"enum": true, "date-time": true},
+	"INTEGER": {"int32": true, "int64": true},
+	"NUMBER":  {"float": true, "double": true},
+}
+
+// convertSchema translates a JSON Schema (as built for provider.Tool.Parameters or ResponseFormat.Schema)
+// into the subset Gemini accepts: types are upper-cased, ["x", "null"] becomes nullable, $ref is inlined,
+// allOf is merged, oneOf becomes anyOf, const and non-string enums are expressed another way, and
+// unsupported keywords (additionalProperties, $schema, ...) are dropped. Returns nil for an empty schema.
+func convertSchema(schema interface{}) map[string]interface{} {
+	if schema == nil {
+		return nil
+	}
+	// Work on plain JSON values whatever Go types the schema was built from
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil || len(root) == 0 {
+		return nil
+	}
+	defs := map[string]interface{}{}
+	for _, key := range []string{"definitions", "$defs"} {
+		if d, ok := root[key].(map[string]interface{}); ok {
+			for name, def := range d {
+				defs["#/"+key+"/"+name] = def
+			}
+		}
+	}
+	out := translateSchema(root, defs, 0)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// translateSchema converts one schema node. defs maps "#/$defs/Name" style references to their schemas.
+func translateSchema(node interface{}, defs map[string]interface{}, depth int) map[string]interface{} {
+	in, ok := node.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	if ref, ok := in["$ref"].(string); ok {
+		target, found := defs[ref]
+		if !found || depth >= maxRefDepth {
+			out := map[string]interface{}{"type": "OBJECT"}
+			if d := describe(in, ""); d != "" {
+				out["description"] = d
+			}
+			return out
+		}
+		// Keywords next to $ref (e.g. a description) apply on top of the target
+		siblings := make(map[string]interface{}, len(in))
+		for key, value := range in {
+			if key != "$ref" {
+				siblings[key] = value
+			}
+		}
+		return translateSchema(mergeSchemas([]interface{}{target}, siblings), defs, depth+1)
+	}
+	if all, ok := in["allOf"].([]interface{}); ok {
+		merged := mergeSchemas(all, in)
+		delete(merged, "allOf")
+		return translateSchema(merged, defs, depth)
+	}
+
+	out := map[string]interface{}{}
+	for key, value := range in {
+		if schemaKeys[key] {
+			out[key] = value
+		}
+	}
+	if d, ok := out["description"].(string); ok && d == "" {
+		delete(out, "description")
+	}
+	if examples, ok := in["examples"].([]interface{}); ok && len(examples) > 0 && out["example"] == nil {
+		out["example"] = examples[0]
+	}
+	// Draft 6+ exclusive bounds become inclusive ones (close enough for a hint to the model)
+	if v, ok := in["exclusiveMinimum"].(float64); ok && out["minimum"] == nil {
+		out["minimum"] = v
+	}
+	if v, ok := in["exclusiveMaximum"].(float64); ok && out["maximum"] == nil {
+		out["maximum"] = v
+	}
+
+	// Type: a list with "null" becomes nullable; several other types become anyOf
+	var types []string
+	switch t := in["type"].(type) {
+	case string:
+		if t != "" {
+			types = []string{t}
+		}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	var nonNull []string
+	for _, t := range types {
+		if t == "null" {
+			out["nullable"] = true
+		} else {
+			nonNull = append(nonNull, strings.ToUpper(t))
+		}
+	}
+	switch {
+	case len(nonNull) == 1:
+		out["type"] = nonNull[0]
+	case len(nonNull) > 1:
+		variants := make([]interface{}, 0, len(nonNull))
+		for _, t := range nonNull {
+			variants = append(variants, map[string]interface{}{"type": t})
+		}
+		out["anyOf"] = variants
+	}
+
+	// anyOf / oneOf: null members become nullable, a single remaining member is inlined
+	var union []interface{}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if list, ok := in[key].([]interface{}); ok {
+			union = append(union, list...)
+		}
+	}
+	if len(union) > 0 {
+		var variants []map[string]interface{}
+		for _, member := range union {
+			if m, ok := member.(map[string]interface{}); ok && m["type"] == "null" {
+				out["nullable"] = true
+				continue
+			}
+			variants = append(variants, translateSchema(member, defs, depth))
+		}
+		if len(variants) == 1 {
+			for key, value := range variants[0] {
+				if _, set := out[key]; !set {
+					out[key] = value
+				}
+			}
+		} else if len(variants) > 1 {
+			list := make([]interface{}, len(variants))
+			for i, v := range variants {
+				list[i] = v
+			}
+			out["anyOf"] = list
+		}
+	}
+
+	// Enums must be strings; other values are described instead
+	var enum []interface{}
+	if c, ok := in["const"]; ok {
+		enum = []interface{}{c}
+	} else if e, ok := in["enum"].([]interface{}); ok {
+		enum = e
+	}
+	if len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		allStrings := true
+		for _, v := range enum {
+			s, ok := v.(string)
+			if !ok {
+				allStrings = false
+				b, _ := json.Marshal(v)
+				s = string(b)
+			}
+			values = append(values, s)
+		}
+		if allStrings {
+			out["enum"] = enum
+			if out["type"] == nil {
+				out["type"] = "STRING"
+			}
+		} else {
+			out["description"] = describe(out, "Allowed values: "+strings.Join(values, ", ")+".")
+		}
+	}
+
+	if props, ok := in["properties"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(props))
+		for name, prop := range props {
+			converted[name] = translateSchema(prop, defs, depth)
+		}
+		out["properties"] = converted
+		// Gemini rejects required names that are not properties
+		var required []interface{}
+		if list, ok := in["required"].([]interface{}); ok {
+			for _, r := range list {
+				if name, ok := r.(string); ok && converted[name] != nil {
+					required = append(required, name)
+				}
+			}
+		}
+		if len(required) > 0 {
+			out["required"] = required
+		}
+		if out["type"] == nil && out["anyOf"] == nil {
+			out["type"] = "OBJECT"
+		}
+	}
+
+	switch items := in["items"].(type) {
+	case map[string]interface{}:
+		out["items"] = translateSchema(items, defs, depth)
+	case []interface{}:
+		// Tuple validation is not supported; describe the first position's schema
+		if len(items) > 0 {
+			out["items"] = translateSchema(items[0], defs, depth)
+		}
+	}
+	if out["items"] != nil && out["type"] == nil && out["anyOf"] == nil {
+		out["type"] = "ARRAY"
+	}
+
+	if format, ok := in["format"].(string); ok {
+		if t, _ := out["type"].(string); schemaFormats[t][format] {
+			out["format"] = format
+		}
+	}
+	return out
+}
+
+// mergeSchemas combines schemas (allOf members or a $ref target) with the keywords of base: properties
+// and required lists are unioned; other keywords take base's value, else the first member's.
+func mergeSchemas(members []interface{}, base map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	props := map[string]interface{}{}
+	required := map[string]bool{}
+	add := func(m map[string]interface{}, override bool) {
+		for key, value := range m {
+			switch key {
+			case "properties":
+				if p, ok := value.(map[string]interface{}); ok {
+					for name, schema := range p {
+						props[name] = schema
+					}
+				}
+			case "required":
+				if list, ok := value.([]interface{}); ok {
+					for _, r := range list {
+						if name, ok := r.(string); ok {
+							required[name] = true
+						}
+					}
+				}
+			default:
+				if _, set := out[key]; override || !set {
+					out[key] = value
+				}
+			}
+		}
+	}
+	for _, member := range members {
+		if m, ok := member.(map[string]interface{}); ok {
+			add(m, false)
+		}
+	}
+	add(base, true)
+	if len(props) > 0 {
+		out["properties"] = props
+	}
+	if len(required) > 0 {
+		names := make([]string, 0, len(required))
+		for name := range required {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]interface{}, len(names))
+		for i, name := range names {
+			list[i] = name
+		}
+		out["required"] = list
+	}
+	return out
+}
+
+// describe appends note to a schema's description
+func describe(schema map[string]interface{}, note string) string {
+	d, _ := schema["description"].(string)
+	switch {
+	case d == "":
+		return note
+	case note == "":
+		return d
+	}
+	return fmt.Sprintf("%s %s", d, note)
+}
@@ -0,0 +1,100 @@
+package gemini
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConvertSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "types upper-cased and unsupported keywords dropped",
+			in: `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","additionalProperties":false,
+				"properties":{"q":{"type":"string","description":"query","format":"uri"},"n":{"type":"integer","format":"int32"}},
+				"required":["q","missing"]}`,
+			want: `{"properties":{"n":{"format":"int32","type":"INTEGER"},"q":{"description":"query","type":"STRING"}},"required":["q"],"type":"OBJECT"}`,
+		},
+		{
+			name: "nullable type list",
+			in:   `{"type":["string","null"]}`,
+			want: `{"nullable":true,"type":"STRING"}`,
+		},
+		{
+			name: "several types become anyOf",
+			in:   `{"type":["string","integer"]}`,
+			want: `{"anyOf":[{"type":"STRING"},{"type":"INTEGER"}]}`,
+		},
+		{
+			name: "oneOf with null is inlined",
+			in:   `{"oneOf":[{"type":"number","minimum":0},{"type":"null"}],"description":"amount"}`,
+			want: `{"description":"amount","minimum":0,"nullable":true,"type":"NUMBER"}`,
+		},
+		{
+			name: "ref inlined from $defs",
+			in: `{"type":"object","properties":{"addr":{"$ref":"#/$defs/Address","description":"where"}},
+				"$defs":{"Address":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}}`,
+			want: `{"properties":{"addr":{"description":"where","properties":{"city":{"type":"STRING"}},"required":["city"],"type":"OBJECT"}},"type":"OBJECT"}`,
+		},
+		{
+			name: "allOf merged",
+			in:   `{"allOf":[{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]},{"properties":{"b":{"type":"boolean"}},"required":["b"]}]}`,
+			want: `{"properties":{"a":{"type":"STRING"},"b":{"type":"BOOLEAN"}},"required":["a","b"],"type":"OBJECT"}`,
+		},
+		{
+			name: "const and numeric enums",
+			in:   `{"type":"object","properties":{"kind":{"const":"circle"},"size":{"type":"integer","enum":[1,2,3]}}}`,
+			want: `{"properties":{"kind":{"enum":["circle"],"type":"STRING"},"size":{"description":"Allowed values: 1, 2, 3.","type":"INTEGER"}},"type":"OBJECT"}`,
+		},
+		{
+			name: "array items and examples",
+			in:   `{"type":"array","items":{"type":"string","examples":["x"]},"minItems":1}`,
+			want: `{"items":{"example":"x","type":"STRING"},"minItems":1,"type":"ARRAY"}`,
+		},
+		{
+			name: "recursive ref is cut off",
+			in:   `{"$ref":"#/definitions/Node","definitions":{"Node":{"type":"object","properties":{"next":{"$ref":"#/definitions/Node"}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.in), &in); err != nil {
+				t.Fatalf("bad input: %v", err)
+			}
+			got, err := json.Marshal(convertSchema(in))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if tt.want != "" && string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSchemaFromGoValues(t *testing.T) {
+	// Tool parameters as built by agent-core: []string required (possibly nil) and empty types
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"expression": map[string]interface{}{"type": "string", "description": ""},
+			"untyped":    map[string]interface{}{"type": "", "description": "anything"},
+		},
+		"required": []string{"expression"},
+	}
+	got, _ := json.Marshal(convertSchema(params))
+	want := `{"properties":{"expression":{"type":"STRING"},"untyped":{"description":"anything"}},"required":["expression"],"type":"OBJECT"}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	params["required"] = []string(nil)
+	got, _ = json.Marshal(convertSchema(params))
+	if want := `{"properties":{"expression":{"type":"STRING"},"untyped":{"description":"anything"}},"type":"OBJECT"}`; string(got) != want {
+		t.Errorf("nil required: got %s", got)
+	}
+}
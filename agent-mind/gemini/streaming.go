@@ -0,0 +1,156 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/sse"
+)
+
+// Stream creates a streaming request to streamGenerateContent (as SSE)
+func (c *Client) Stream(ctx context.Context, req provider.CompletionRequest, model string, thinkingBudget *int) (<-chan provider.StreamEvent, error) {
+	body, err := c.buildRequest(req, thinkingBudget)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := c.createRequest(ctx, "POST", modelPath(model, "streamGenerateContent")+"?alt=sse", body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, provider.NewHTTPError(resp.StatusCode, string(b), resp.Header)
+	}
+
+	events := make(chan provider.StreamEvent, 10)
+	go c.parseSSE(ctx, resp.Body, events, model)
+	return events, nil
+}
+
+// parseSSE parses streamGenerateContent events. Each event is a complete response holding the
+// next parts; function calls always arrive whole. The stream ends after the chunk with a finish reason.
+func (c *Client) parseSSE(ctx context.Context, body io.ReadCloser, events chan<- provider.StreamEvent, model string) {
+	defer close(events)
+	defer body.Close()
+
+	send := func(ev provider.StreamEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	reader := sse.NewReader(body)
+	started := false
+	toolIndex := 0
+	var usage *usageMetadata
+	var finishReason provider.FinishReason
+	modelUsed := model
+
+	for {
+		ev, err := reader.Next()
+		if ctx.Err() != nil {
+			events <- provider.StreamEvent{Type: provider.EventError, Error: ctx.Err()}
+			return
+		}
+		if errors.Is(err, io.EOF) && finishReason != "" {
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("stream error: %w", err)})
+			return
+		}
+
+		var chunk generateResponse
+		if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			status := chunk.Error.Code
+			if status < 400 {
+				status = http.StatusInternalServerError
+			}
+			send(provider.StreamEvent{Type: provider.EventError, Error: provider.NewHTTPError(status, ev.Data, nil)})
+			return
+		}
+		if err := chunk.blockedError(); err != nil {
+			send(provider.StreamEvent{Type: provider.EventError, Error: err})
+			return
+		}
+		if !started {
+			started = true
+			if !send(provider.StreamEvent{Type: provider.EventStart}) {
+				return
+			}
+		}
+		if chunk.ModelVersion != "" {
+			modelUsed = chunk.ModelVersion
+		}
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata
+		}
+		if len(chunk.Candidates) == 0 {
+			continue
+		}
+
+		cand := chunk.Candidates[0]
+		for _, p := range cand.Content.Parts {
+			var out []provider.StreamEvent
+			switch {
+			case p.FunctionCall != nil:
+				call := convertFunctionCall(p.FunctionCall)
+				out = append(out,
+					provider.StreamEvent{
+						Type:    provider.EventToolDelta,
+						Content: &provider.ToolCallStreamPayload{Index: toolIndex, ID: call.ID, Name: call.Name, Arguments: call.Arguments},
+					},
+					provider.StreamEvent{Type: provider.EventToolCall, Content: &call},
+				)
+				toolIndex++
+			case p.Thought:
+				if p.Text != "" {
+					out = append(out, provider.StreamEvent{Type: provider.EventReasoningDelta, Delta: p.Text, Content: &provider.ReasoningStreamPayload{}})
+				}
+			case p.Text != "":
+				out = append(out, provider.StreamEvent{Type: provider.EventTextDelta, Delta: p.Text})
+			}
+			if p.ThoughtSignature != "" {
+				out = append(out, provider.StreamEvent{
+					Type:    provider.EventReasoningDelta,
+					Content: &provider.ReasoningStreamPayload{Signature: p.ThoughtSignature},
+				})
+			}
+			for _, ev := range out {
+				if !send(ev) {
+					return
+				}
+			}
+		}
+		if cand.FinishReason != "" {
+			finishReason = convertFinishReason(cand.FinishReason, toolIndex > 0)
+		}
+	}
+
+	send(provider.StreamEvent{
+		Type:    provider.EventDone,
+		Content: &provider.StreamDonePayload{Model: modelUsed, Usage: convertUsage(usage), FinishReason: finishReason},
+	})
+}
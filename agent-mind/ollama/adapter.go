@@ -0,0 +1,164 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// Provider implements the provider.Provider interface for the native Ollama chat API
+type Provider struct {
+	client *Client
+	model  string
+	opts   chatOptions
+
+	mu        sync.Mutex
+	installed []string                      // from the last ListModels
+	infos     map[string]provider.ModelInfo // /api/show results by model
+}
+
+// NewProvider creates a provider for model on the local Ollama server (DefaultBaseURL)
+func NewProvider(model string) *Provider {
+	return &Provider{
+		client: NewClient(DefaultBaseURL, ""),
+		model:  model,
+		infos:  map[string]provider.ModelInfo{},
+	}
+}
+
+// NewProviderFromConfig creates a provider from a provider.Config. BaseURL defaults to DefaultBaseURL;
+// APIKey is only needed behind an authenticating proxy.
+func NewProviderFromConfig(cfg provider.Config) *Provider {
+	p := NewProvider(cfg.Model)
+	p.client = NewClient(cfg.BaseURL, cfg.APIKey)
+	return p
+}
+
+// WithModel sets the model requests are sent to. Returns p for chaining.
+func (p *Provider) WithModel(model string) *Provider {
+	p.model = model
+	return p
+}
+
+// WithKeepAlive sets how long the server keeps the model loaded after a request (negative keeps it
+// loaded indefinitely, 0 unloads it at once). Returns p for chaining.
+func (p *Provider) WithKeepAlive(d time.Duration) *Provider {
+	p.opts.KeepAlive = d.String()
+	return p
+}
+
+// WithOptions sets model options sent with every request (e.g. num_ctx, top_k, seed). They take precedence
+// over the request's Temperature and MaxTokens. Returns p for chaining.
+func (p *Provider) WithOptions(options map[string]interface{}) *Provider {
+	p.opts.Options = options
+	return p
+}
+
+// WithThinking turns thinking on or off for models that support it (default: the model's own).
+// Returns p for chaining.
+func (p *Provider) WithThinking(enabled bool) *Provider {
+	p.opts.Think = &enabled
+	return p
+}
+
+// WithHTTPClient replaces the HTTP client (e.g. for a proxy, custom TLS or instrumentation). Returns p for chaining.
+func (p *Provider) WithHTTPClient(hc *http.Client) *Provider {
+	p.client.SetHTTPClient(hc)
+	return p
+}
+
+// WithRetryPolicy sets the client's retry policy. Returns p for chaining.
+func (p *Provider) WithRetryPolicy(policy provider.RetryPolicy) *Provider {
+	p.client.SetRetryPolicy(policy)
+	return p
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	return p.client.Stream(ctx, req, p.model, p.opts)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	return p.client.Complete(ctx, req, p.model, p.opts)
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return "ollama"
+}
+
+// Model returns the model requests are sent to.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// Models implements provider.Provider. Returns the installed models once ListModels has run, otherwise
+// the configured model.
+func (p *Provider) Models() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.installed) > 0 {
+		return p.installed
+	}
+	return []string{p.model}
+}
+
+// ListModels implements provider.Cataloger with the installed models (/api/tags), each described by /api/show
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	names, err := p.client.Tags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	models := make([]provider.ModelInfo, 0, len(names))
+	for _, name := range names {
+		m, err := p.describe(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	p.mu.Lock()
+	p.installed = names
+	p.mu.Unlock()
+	return provider.NewCatalog(models).Models(), nil
+}
+
+// Capabilities implements provider.Cataloger for the configured model (/api/show, cached)
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	return p.describe(ctx, p.model)
+}
+
+// describe returns a model's details, asking the server once per model
+func (p *Provider) describe(ctx context.Context, model string) (provider.ModelInfo, error) {
+	p.mu.Lock()
+	m, ok := p.infos[model]
+	p.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+	m, err := p.client.Show(ctx, model)
+	if err != nil {
+		return provider.ModelInfo{}, fmt.Errorf("ollama: %w", err)
+	}
+	p.mu.Lock()
+	p.infos[model] = m
+	p.mu.Unlock()
+	return m, nil
+}
@@ -0,0 +1,379 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// DefaultBaseURL is where a local Ollama server listens.
+const DefaultBaseURL = "http://localhost:11434"
+
+// Client talks to the native Ollama API (/api/chat, /api/tags, /api/show).
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	retry      provider.RetryPolicy
+}
+
+// NewClient creates a client for the Ollama server at baseURL (default DefaultBaseURL). apiKey is only
+// needed when the server sits behind an authenticating proxy; it is sent as a bearer token.
+func NewClient(baseURL, apiKey string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+		retry:      provider.DefaultRetryPolicy(),
+	}
+}
+
+// SetRetryPolicy replaces the retry policy (default provider.DefaultRetryPolicy; use provider.NoRetry to disable).
+func (c *Client) SetRetryPolicy(policy provider.RetryPolicy) {
+	c.retry = policy
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. for a proxy, custom TLS or instrumentation).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// chatOptions are the per-request settings sent alongside /api/chat
+type chatOptions struct {
+	KeepAlive string                 // keep_alive; empty leaves the server default
+	Options   map[string]interface{} // model options (num_ctx, top_k, ...); override request values
+	Think     *bool                  // think; nil leaves the model default
+}
+
+// chatRequest is the body of POST /api/chat
+type chatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []chatMessage          `json:"messages"`
+	Tools     []toolDef              `json:"tools,omitempty"`
+	Stream    bool                   `json:"stream"`
+	Format    interface{}            `json:"format,omitempty"` // "json" or a JSON schema
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Think     *bool                  `json:"think,omitempty"`
+}
+
+// chatMessage is one /api/chat message. Images are raw base64; tool results have role "tool" and
+// name the tool they answer (Ollama tool calls carry no IDs).
+type chatMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+type toolCall struct {
+	ID       string           `json:"id,omitempty"` // only sent by recent servers
+	Function toolCallFunction `json:"function"`
+}
+
+// toolCallFunction holds a call's arguments as a JSON object (not a string as in OpenAI's API)
+type toolCallFunction struct {
+	Index     int                    `json:"index,omitempty"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+type toolDef struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+// chatResponse is the non-streaming reply, and also each line of a stream (with done set on the last)
+type chatResponse struct {
+	Model           string      `json:"model"`
+	Message         chatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	DoneReason      string      `json:"done_reason,omitempty"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// usage converts the eval counts of a final response
+func (r chatResponse) usage() provider.UsageInfo {
+	return provider.UsageInfo{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// finishReason converts done_reason. Ollama reports "stop" after tool calls too, so a reply that
+// called tools reports FinishToolCalls.
+func (r chatResponse) finishReason(calledTools bool) provider.FinishReason {
+	switch r.DoneReason {
+	case "":
+		return ""
+	case "stop":
+		if calledTools {
+			return provider.FinishToolCalls
+		}
+		return provider.FinishStop
+	case "length":
+		return provider.FinishLength
+	}
+	return provider.FinishReason(r.DoneReason)
+}
+
+// createRequest builds an HTTP request with a JSON body
+func (c *Client) createRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// doRequest sends a JSON request with retries and returns the 200 response (caller closes the body).
+// Non-200 responses become *provider.HTTPError; retryable ones are retried per the client's policy.
+// For streams this only covers failures before the first byte.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var resp *http.Response
+	err := c.retry.Do(ctx, func() error {
+		httpReq, err := c.createRequest(ctx, method, path, body)
+		if err != nil {
+			return err
+		}
+		r, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		if r.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			return provider.NewHTTPError(r.StatusCode, string(b), r.Header)
+		}
+		resp = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// buildChatRequest converts a provider request into an /api/chat request; the system prompt becomes the
+// first message. Temperature and MaxTokens go into options (temperature, num_predict) unless opts sets them.
+func buildChatRequest(req provider.CompletionRequest, model string, stream bool, opts chatOptions) (chatRequest, error) {
+	messages, err := convertMessages(req.Messages)
+	if err != nil {
+		return chatRequest{}, err
+	}
+	tools, instruction := convertTools(req.Tools, req.ToolChoice)
+	system := req.SystemPrompt
+	if instruction != "" {
+		system = strings.TrimSpace(system + "\n\n" + instruction)
+	}
+	if system != "" {
+		messages = append([]chatMessage{{Role: "system", Content: system}}, messages...)
+	}
+
+	options := map[string]interface{}{}
+	if req.Temperature != 0 {
+		options["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	for k, v := range opts.Options {
+		options[k] = v
+	}
+	if len(options) == 0 {
+		options = nil
+	}
+
+	return chatRequest{
+		Model:     model,
+		Messages:  messages,
+		Tools:     tools,
+		Stream:    stream,
+		Format:    convertResponseFormat(req.ResponseFormat),
+		Options:   options,
+		KeepAlive: opts.KeepAlive,
+		Think:     opts.Think,
+	}, nil
+}
+
+// convertResponseFormat maps a provider response format to format: "json" for any object, otherwise the schema
+func convertResponseFormat(f *provider.ResponseFormat) interface{} {
+	if !f.IsJSON() {
+		return nil
+	}
+	if f.Type == provider.ResponseFormatJSONObject || f.Schema == nil {
+		return "json"
+	}
+	return f.Schema
+}
+
+// convertTools converts provider tools to Ollama function tools. Ollama has no tool_choice, so the choice is
+// applied here: "none" drops the tools, a forced tool is the only one sent, and "required" and forced
+// choices return an instruction for the system prompt.
+func convertTools(tools []provider.Tool, choice *provider.ToolChoice) ([]toolDef, string) {
+	if len(tools) == 0 || !choice.AllowsTools() {
+		return nil, ""
+	}
+	instruction := ""
+	if choice != nil {
+		switch choice.Mode {
+		case provider.ToolChoiceRequired:
+			instruction = "You must call at least one of the available tools in this reply."
+		case provider.ToolChoiceTool:
+			instruction = fmt.Sprintf("You must call the %s tool in this reply.", choice.Name)
+		}
+	}
+
+	result := make([]toolDef, 0, len(tools))
+	for _, t := range tools {
+		if choice != nil && choice.Mode == provider.ToolChoiceTool && t.Name != choice.Name {
+			continue
+		}
+		result = append(result, toolDef{
+			Type: "function",
+			Function: toolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return result, instruction
+}
+
+// convertMessages converts agent-core messages to /api/chat messages
+func convertMessages(messages []types.Message) ([]chatMessage, error) {
+	result := make([]chatMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case types.UserMessage:
+			text, images, err := splitContent(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, chatMessage{Role: "user", Content: text, Images: images})
+		case types.AssistantMessage:
+			out := chatMessage{Role: "assistant"}
+			for _, block := range m.Content {
+				switch b := block.(type) {
+				case types.ThinkingContent:
+					out.Thinking += b.Thinking
+				case types.TextContent:
+					out.Content += b.Text
+				case types.ToolCallContent:
+					out.ToolCalls = append(out.ToolCalls, toolCall{
+						Function: toolCallFunction{Name: b.Name, Arguments: argumentsObject(b.Arguments)},
+					})
+				}
+			}
+			result = append(result, out)
+		case types.ToolResultMessage:
+			// Ollama links results to calls by tool name; images ride on the tool message itself
+			text, images, err := splitContent(m.Content)
+			if err != nil {
+				return nil, err
+			}
+			if text == "" && m.Details != nil {
+				if j, err := json.Marshal(m.Details); err == nil {
+					text = string(j)
+				}
+			}
+			result = append(result, chatMessage{Role: "tool", Content: text, Images: images, ToolName: m.ToolName})
+		}
+	}
+	return result, nil
+}
+
+// splitContent joins the text blocks and collects images as raw base64
+func splitContent(content []types.ContentBlock) (string, []string, error) {
+	text := ""
+	var images []string
+	for _, block := range content {
+		switch b := block.(type) {
+		case types.TextContent:
+			text += b.Text
+		case types.ImageContent:
+			data, err := imageBase64(b)
+			if err != nil {
+				return "", nil, err
+			}
+			images = append(images, data)
+		}
+	}
+	return text, images, nil
+}
+
+// imageBase64 returns an image as the raw base64 Ollama expects, stripping a data URL prefix.
+// Remote URLs are rejected: the server does not fetch them.
+func imageBase64(img types.ImageContent) (string, error) {
+	if strings.HasPrefix(img.Data, "http://") || strings.HasPrefix(img.Data, "https://") {
+		return "", fmt.Errorf("ollama: image URLs are not supported, pass base64 data instead")
+	}
+	if strings.HasPrefix(img.Data, "data:") {
+		if i := strings.Index(img.Data, ","); i >= 0 {
+			return img.Data[i+1:], nil
+		}
+	}
+	return img.Data, nil
+}
+
+// argumentsObject converts tool call arguments to the JSON object Ollama expects
+func argumentsObject(args interface{}) map[string]interface{} {
+	switch a := args.(type) {
+	case map[string]interface{}:
+		return a
+	case nil:
+		return map[string]interface{}{}
+	}
+	out := map[string]interface{}{}
+	if s, ok := args.(string); ok {
+		_ = json.Unmarshal([]byte(s), &out)
+		return out
+	}
+	if j, err := json.Marshal(args); err == nil {
+		_ = json.Unmarshal(j, &out)
+	}
+	return out
+}
+
+// toolCallID returns the server's call ID, or a fresh one since older servers do not issue them
+func toolCallID(tc toolCall) string {
+	if tc.ID != "" {
+		return tc.ID
+	}
+	return provider.NewToolCallID()
+}
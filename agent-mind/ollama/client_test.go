@@ -0,0 +1,304 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// newTestProvider returns a provider pointed at an httptest stand-in for the Ollama server running handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewProviderFromConfig(provider.Config{BaseURL: srv.URL, Model: "llama-test"}).WithRetryPolicy(provider.NoRetry())
+}
+
+func TestConvertMessages(t *testing.T) {
+	msgs := []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{
+			types.TextContent{Text: "what is this?"},
+			types.ImageContent{Data: "data:image/png;base64,iVBORw0KGgo=", MimeType: "image/png"},
+		}},
+		types.AssistantMessage{Content: []types.ContentBlock{
+			types.ThinkingContent{Thinking: "look it up"},
+			types.ToolCallContent{ID: "call_1", Name: "search", Arguments: map[string]interface{}{"q": "cat"}},
+		}},
+		types.ToolResultMessage{ToolCallID: "call_1", ToolName: "search", Content: []types.ContentBlock{types.TextContent{Text: "a cat"}}},
+	}
+
+	got, err := convertMessages(msgs)
+	if err != nil {
+		t.Fatalf("convertMessages: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(got))
+	}
+	if len(got[0].Images) != 1 || got[0].Images[0] != "iVBORw0KGgo=" {
+		t.Errorf("expected raw base64 image, got %v", got[0].Images)
+	}
+	if got[1].Thinking != "look it up" || len(got[1].ToolCalls) != 1 || got[1].ToolCalls[0].Function.Arguments["q"] != "cat" {
+		t.Errorf("unexpected assistant message: %+v", got[1])
+	}
+	if got[2].Role != "tool" || got[2].ToolName != "search" || got[2].Content != "a cat" {
+		t.Errorf("unexpected tool message: %+v", got[2])
+	}
+
+	_, err = convertMessages([]types.Message{types.UserMessage{Content: []types.ContentBlock{
+		types.ImageContent{Data: "https://example.com/cat.png"},
+	}}})
+	if err == nil {
+		t.Error("expected image URLs to be rejected")
+	}
+}
+
+func TestCompleteRequestAndResponse(t *testing.T) {
+	var raw map[string]interface{}
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		fmt.Fprint(w, `{
+			"model":"llama-test:latest","done":true,"done_reason":"stop",
+			"message":{"role":"assistant","content":"","thinking":"need math",
+				"tool_calls":[{"function":{"name":"calculator","arguments":{"expression":"2+2"}}}]},
+			"prompt_eval_count":26,"eval_count":9
+		}`)
+	})
+	p.WithKeepAlive(10 * time.Minute).WithOptions(map[string]interface{}{"num_ctx": 8192, "temperature": 0.1})
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		SystemPrompt:   "be brief",
+		Messages:       []types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2?"}}}},
+		Temperature:    0.7,
+		MaxTokens:      100,
+		Tools:          []provider.Tool{{Name: "calculator", Parameters: map[string]interface{}{"type": "object"}}},
+		ResponseFormat: provider.JSONObjectFormat(),
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if raw["stream"] != false || raw["keep_alive"] != "10m0s" || raw["format"] != "json" {
+		t.Errorf("unexpected request: %v", raw)
+	}
+	opts, _ := raw["options"].(map[string]interface{})
+	if opts["num_ctx"] != float64(8192) || opts["num_predict"] != float64(100) || opts["temperature"] != 0.1 {
+		t.Errorf("unexpected options (provider options should win): %v", opts)
+	}
+	msgs, _ := raw["messages"].([]interface{})
+	if len(msgs) != 2 || msgs[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("expected system prompt as first message, got %v", msgs)
+	}
+	if tools, _ := raw["tools"].([]interface{}); len(tools) != 1 {
+		t.Errorf("expected 1 tool, got %v", raw["tools"])
+	}
+
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "calculator" || resp.ToolCalls[0].ID == "" ||
+		resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "need math" {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if resp.Usage.PromptTokens != 26 || resp.Usage.CompletionTokens != 9 || resp.Usage.TotalTokens != 35 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	if resp.Model != "llama-test:latest" {
+		t.Errorf("unexpected model %q", resp.Model)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model 'llama-test' not found"}`)
+	})
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected HTTP 404 error, got %v", err)
+	}
+}
+
+const streamFixture = `{"model":"llama-test","message":{"role":"assistant","content":"","thinking":"Adding."},"done":false}
+{"model":"llama-test","message":{"role":"assistant","content":"Let me "},"done":false}
+{"model":"llama-test","message":{"role":"assistant","content":"check."},"done":false}
+{"model":"llama-test","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"calculator","arguments":{"expression":"2+2"}}},{"function":{"name":"clock","arguments":{}}}]},"done":false}
+{"model":"llama-test","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":30}
+`
+
+func TestStream(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var body chatRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			t.Error("expected stream=true")
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, streamFixture)
+	})
+
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var started bool
+	var indexes []int
+	resp, err := provider.Collect(events, func(ev provider.StreamEvent) {
+		switch ev.Type {
+		case provider.EventStart:
+			started = true
+		case provider.EventToolDelta:
+			indexes = append(indexes, ev.Content.(*provider.ToolCallStreamPayload).Index)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if !started {
+		t.Error("expected a start event")
+	}
+	if resp.Text != "Let me check." {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "Adding." {
+		t.Errorf("unexpected reasoning: %+v", resp.Reasoning)
+	}
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Name != "calculator" || resp.ToolCalls[1].Name != "clock" ||
+		resp.ToolCalls[0].ID == resp.ToolCalls[1].ID {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if fmt.Sprint(indexes) != "[0 1]" {
+		t.Errorf("expected tool deltas indexed 0 and 1, got %v", indexes)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 30 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	if resp.FinishReason != provider.FinishToolCalls {
+		t.Errorf("unexpected finish reason %q", resp.FinishReason)
+	}
+}
+
+func TestStreamLongLine(t *testing.T) {
+	long := strings.Repeat("x", 256*1024)
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"message":{"content":%q},"done":false}`+"\n", long)
+		fmt.Fprint(w, `{"message":{"content":""},"done":true}`+"\n")
+	})
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if resp.Text != long || resp.Model != "llama-test" {
+		t.Errorf("unexpected response: %d bytes of text, model %q", len(resp.Text), resp.Model)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"error line": `{"message":{"content":"Hi"},"done":false}` + "\n" + `{"error":"out of memory"}` + "\n",
+		"cut off":    `{"message":{"content":"Hi"},"done":false}` + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, body)
+			})
+			events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			if _, err := provider.Collect(events, nil); err == nil {
+				t.Error("expected a stream error")
+			}
+		})
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	tools := []provider.Tool{{Name: "search"}, {Name: "clock"}}
+	if got, _ := convertTools(tools, provider.DisableTools()); len(got) != 0 {
+		t.Errorf("expected no tools when disabled, got %d", len(got))
+	}
+	got, instruction := convertTools(tools, provider.ForceTool("clock"))
+	if len(got) != 1 || got[0].Function.Name != "clock" || !strings.Contains(instruction, "clock") {
+		t.Errorf("expected only the forced tool with an instruction, got %+v %q", got, instruction)
+	}
+	if got, instruction := convertTools(tools, provider.RequireTools()); len(got) != 2 || instruction == "" {
+		t.Errorf("expected all tools with an instruction, got %d %q", len(got), instruction)
+	}
+
+	p := NewProvider("llama-test")
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{Tools: tools, ToolChoice: provider.ForceTool("nope")})
+	if !errors.Is(err, provider.ErrInvalidToolChoice) {
+		t.Errorf("expected ErrInvalidToolChoice, got %v", err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	shows := 0
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama-test"},{"name":"old-model"}]}`)
+		case "/api/show":
+			shows++
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			switch body["model"] {
+			case "llama-test":
+				fmt.Fprint(w, `{"capabilities":["completion","tools"],
+					"model_info":{"general.architecture":"llama","llama.context_length":131072}}`)
+			case "old-model":
+				fmt.Fprint(w, `{"model_info":{}}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":"model not found"}`)
+			}
+		}
+	})
+
+	m, err := p.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+	if !m.Tools || m.Vision || m.ContextWindow != 131072 {
+		t.Errorf("unexpected capabilities: %+v", m)
+	}
+
+	models, err := p.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 2 || models[1].ID != "old-model" || !models[1].Tools || !models[1].Vision {
+		t.Errorf("expected models without reported capabilities to be assumed capable, got %+v", models)
+	}
+	if shows != 2 {
+		t.Errorf("expected model details to be cached, got %d /api/show calls", shows)
+	}
+	if fmt.Sprint(p.Models()) != "[llama-test old-model]" {
+		t.Errorf("unexpected Models(): %v", p.Models())
+	}
+
+	_, err = p.WithModel("missing").Capabilities(context.Background())
+	if !errors.Is(err, provider.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// tagsResponse is the reply of GET /api/tags (the locally installed models)
+type tagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
+// showResponse is the part of POST /api/show used to describe a model
+type showResponse struct {
+	// Capabilities lists "completion", "tools", "vision", "thinking", ... (missing on old servers)
+	Capabilities []string               `json:"capabilities"`
+	ModelInfo    map[string]interface{} `json:"model_info"`
+}
+
+// Tags returns the names of the installed models
+func (c *Client) Tags(ctx context.Context) ([]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tags tagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+	names := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		name := m.Name
+		if name == "" {
+			name = m.Model
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Show describes one model. A model the server does not have is ErrModelNotFound.
+func (c *Client) Show(ctx context.Context, model string) (provider.ModelInfo, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/show", map[string]string{"model": model})
+	if err != nil {
+		var httpErr *provider.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return provider.ModelInfo{}, fmt.Errorf("%s: %w", model, provider.ErrModelNotFound)
+		}
+		return provider.ModelInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	var show showResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return provider.ModelInfo{}, fmt.Errorf("failed to decode model details: %w", err)
+	}
+	return show.toProvider(model), nil
+}
+
+// toProvider converts model details. Servers that do not report capabilities are assumed capable, and
+// local models cost nothing.
+func (s showResponse) toProvider(model string) provider.ModelInfo {
+	info := provider.ModelInfo{
+		ID:               model,
+		Name:             model,
+		Tools:            true,
+		Vision:           true,
+		Reasoning:        false,
+		StructuredOutput: true,
+		Pricing:          &provider.ModelPrice{},
+	}
+	if s.Capabilities != nil {
+		caps := map[string]bool{}
+		for _, c := range s.Capabilities {
+			caps[c] = true
+		}
+		info.Tools = caps["tools"]
+		info.Vision = caps["vision"]
+		info.Reasoning = caps["thinking"]
+	}
+	// The context length is keyed by architecture, e.g. "llama.context_length"
+	if arch, ok := s.ModelInfo["general.architecture"].(string); ok {
+		if n, ok := s.ModelInfo[arch+".context_length"].(float64); ok {
+			info.ContextWindow = int(n)
+		}
+	}
+	return info
+}
@@ -0,0 +1,172 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// Stream sends a streaming request to /api/chat. The reply is newline-delimited JSON: one chatResponse
+// per line, the last with done set and the eval counts.
+func (c *Client) Stream(ctx context.Context, req provider.CompletionRequest, model string, opts chatOptions) (<-chan provider.StreamEvent, error) {
+	chatReq, err := buildChatRequest(req, model, true, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doRequest(ctx, "POST", "/api/chat", chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan provider.StreamEvent, 10)
+	go c.parseNDJSON(ctx, resp.Body, events, model)
+	return events, nil
+}
+
+// parseNDJSON reads stream lines and emits events. Lines are read whole, whatever their size.
+// model is reported on EventDone when the server does not name one.
+func (c *Client) parseNDJSON(ctx context.Context, body io.ReadCloser, events chan<- provider.StreamEvent, model string) {
+	defer close(events)
+	defer body.Close()
+
+	send := func(ev provider.StreamEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	reader := bufio.NewReader(body)
+	started := false
+	toolIndex := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var chunk chatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("ollama: invalid stream line: %w", err)})
+				return
+			}
+			if chunk.Error != "" {
+				send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("ollama: %s", chunk.Error)})
+				return
+			}
+			if !started {
+				started = true
+				if !send(provider.StreamEvent{Type: provider.EventStart}) {
+					return
+				}
+			}
+
+			msg := chunk.Message
+			if msg.Thinking != "" {
+				if !send(provider.StreamEvent{Type: provider.EventReasoningDelta, Delta: msg.Thinking, Content: &provider.ReasoningStreamPayload{}}) {
+					return
+				}
+			}
+			if msg.Content != "" {
+				if !send(provider.StreamEvent{Type: provider.EventTextDelta, Delta: msg.Content}) {
+					return
+				}
+			}
+			// Tool calls arrive complete, never split across lines
+			for _, tc := range msg.ToolCalls {
+				id := toolCallID(tc)
+				args := argumentsObject(tc.Function.Arguments)
+				if !send(provider.StreamEvent{
+					Type:    provider.EventToolDelta,
+					Content: &provider.ToolCallStreamPayload{Index: toolIndex, ID: id, Name: tc.Function.Name, Arguments: args},
+				}) {
+					return
+				}
+				if !send(provider.StreamEvent{
+					Type:    provider.EventToolCall,
+					Content: &provider.ToolCallResponse{ID: id, Name: tc.Function.Name, Arguments: args},
+				}) {
+					return
+				}
+				toolIndex++
+			}
+
+			if chunk.Done {
+				modelUsed := chunk.Model
+				if modelUsed == "" {
+					modelUsed = model
+				}
+				send(provider.StreamEvent{
+					Type:    provider.EventDone,
+					Content: &provider.StreamDonePayload{Model: modelUsed, Usage: chunk.usage(), FinishReason: chunk.finishReason(toolIndex > 0)},
+				})
+				return
+			}
+		}
+
+		if readErr != nil {
+			err := readErr
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			send(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("stream error: %w", err)})
+			return
+		}
+	}
+}
+
+// Complete sends a non-streaming request to /api/chat
+func (c *Client) Complete(ctx context.Context, req provider.CompletionRequest, model string, opts chatOptions) (*provider.CompletionResponse, error) {
+	chatReq, err := buildChatRequest(req, model, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doRequest(ctx, "POST", "/api/chat", chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if chatResp.Error != "" {
+		return nil, fmt.Errorf("ollama: %s", chatResp.Error)
+	}
+
+	var toolCalls []provider.ToolCallResponse
+	for _, tc := range chatResp.Message.ToolCalls {
+		toolCalls = append(toolCalls, provider.ToolCallResponse{
+			ID:        toolCallID(tc),
+			Name:      tc.Function.Name,
+			Arguments: argumentsObject(tc.Function.Arguments),
+		})
+	}
+	var reasoning []provider.ReasoningBlock
+	if chatResp.Message.Thinking != "" {
+		reasoning = []provider.ReasoningBlock{{Text: chatResp.Message.Thinking}}
+	}
+
+	modelUsed := chatResp.Model
+	if modelUsed == "" {
+		modelUsed = model
+	}
+	return &provider.CompletionResponse{
+		Text:         chatResp.Message.Content,
+		ToolCalls:    toolCalls,
+		Usage:        chatResp.usage(),
+		Model:        modelUsed,
+		Reasoning:    reasoning,
+		FinishReason: chatResp.finishReason(len(toolCalls) > 0),
+	}, nil
+}
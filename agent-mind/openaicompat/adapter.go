@@ -0,0 +1,217 @@
+package openaicompat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// DefaultName is the provider name reported by Name() unless set with WithName.
+const DefaultName = "openaicompat"
+
+// Provider implements the provider.Provider interface for any OpenAI-compatible chat completions API
+type Provider struct {
+	client   *Client
+	name     string
+	model    string
+	noVision bool
+	catalog  *modelCatalog
+	models   []string // reported by Models() until the catalog is loaded
+}
+
+// NewProvider creates a provider for the API at baseURL (the prefix of /chat/completions, e.g.
+// "http://localhost:1234/v1" for LM Studio). apiKey may be empty for servers without auth.
+func NewProvider(baseURL, apiKey, model string) *Provider {
+	return &Provider{
+		client:  NewClient(baseURL, apiKey),
+		name:    DefaultName,
+		model:   model,
+		catalog: &modelCatalog{ttl: DefaultModelCacheTTL},
+	}
+}
+
+// NewProviderFromConfig creates a provider from a provider.Config (BaseURL, APIKey and Model).
+func NewProviderFromConfig(cfg provider.Config) *Provider {
+	return NewProvider(cfg.BaseURL, cfg.APIKey, cfg.Model)
+}
+
+// WithName sets the name reported by Name() and recorded on assistant messages. Returns p for chaining.
+func (p *Provider) WithName(name string) *Provider {
+	p.name = name
+	p.client.SetName(name)
+	return p
+}
+
+// WithModel sets the model requests are sent to. Returns p for chaining.
+func (p *Provider) WithModel(model string) *Provider {
+	p.model = model
+	return p
+}
+
+// WithModels sets the model IDs reported by Models() until the /models catalog is loaded (default: the
+// configured model). Returns p for chaining.
+func (p *Provider) WithModels(ids ...string) *Provider {
+	p.models = ids
+	return p
+}
+
+// WithHeader sets a header sent with every request (an empty value removes it). Returns p for chaining.
+func (p *Provider) WithHeader(key, value string) *Provider {
+	p.client.SetHeader(key, value)
+	return p
+}
+
+// WithOrganization sets the OpenAI-Organization header. Returns p for chaining.
+func (p *Provider) WithOrganization(org string) *Provider {
+	return p.WithHeader("OpenAI-Organization", org)
+}
+
+// WithProject sets the OpenAI-Project header. Returns p for chaining.
+func (p *Provider) WithProject(project string) *Provider {
+	return p.WithHeader("OpenAI-Project", project)
+}
+
+// WithHTTPClient replaces the HTTP client (e.g. for a proxy, custom TLS or instrumentation). Returns p for chaining.
+func (p *Provider) WithHTTPClient(hc *http.Client) *Provider {
+	p.client.SetHTTPClient(hc)
+	return p
+}
+
+// WithTransport sets the round tripper of the HTTP client. Returns p for chaining.
+func (p *Provider) WithTransport(rt http.RoundTripper) *Provider {
+	hc := *p.client.httpClient
+	hc.Transport = rt
+	p.client.SetHTTPClient(&hc)
+	return p
+}
+
+// WithTimeout bounds a non-streaming request, and a streaming one until its response starts
+// (default DefaultTimeout; 0 disables). Returns p for chaining.
+func (p *Provider) WithTimeout(d time.Duration) *Provider {
+	p.client.SetTimeout(d)
+	return p
+}
+
+// WithModelCache sets where the /models list is cached on disk and for how long (default: in memory
+// for DefaultModelCacheTTL). An empty path keeps the list in memory only. Returns p for chaining.
+func (p *Provider) WithModelCache(path string, ttl time.Duration) *Provider {
+	p.catalog.cachePath = path
+	p.catalog.ttl = ttl
+	return p
+}
+
+// WithModelOverrides applies a local JSON file (a list of provider.ModelInfo) on top of the /models list,
+// e.g. to correct capabilities or add private models. Entries replace fetched ones whole. Returns p for chaining.
+func (p *Provider) WithModelOverrides(path string) *Provider {
+	p.catalog.overridePath = path
+	return p
+}
+
+// WithRetryPolicy sets the client's retry policy. Returns p for chaining.
+func (p *Provider) WithRetryPolicy(policy provider.RetryPolicy) *Provider {
+	p.client.SetRetryPolicy(policy)
+	return p
+}
+
+// WithVision declares whether the model accepts image input (default true). When false, requests
+// containing images fail with provider.ErrImagesNotSupported instead of being sent.
+func (p *Provider) WithVision(enabled bool) *Provider {
+	p.noVision = !enabled
+	return p
+}
+
+// checkRequest rejects image input for models without vision support (set with WithVision, or known from
+// an already loaded catalog) and tool choices the request's tools cannot satisfy
+func (p *Provider) checkRequest(req provider.CompletionRequest) error {
+	noVision := p.noVision
+	if m, ok := p.catalog.cached().Lookup(p.model); ok && !m.Vision {
+		noVision = true
+	}
+	if noVision && req.HasImages() {
+		return fmt.Errorf("%s: %s: %w", p.name, p.model, provider.ErrImagesNotSupported)
+	}
+	if err := req.ToolChoice.Validate(req.Tools); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	return nil
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	if p == nil || p.client == nil {
+		// Create error channel
+		errCh := make(chan provider.StreamEvent, 1)
+		errCh <- provider.StreamEvent{
+			Type:  provider.EventError,
+			Error: fmt.Errorf("provider not initialized"),
+		}
+		close(errCh)
+		return errCh, fmt.Errorf("provider not initialized")
+	}
+	if err := p.checkRequest(req); err != nil {
+		return nil, err
+	}
+	return p.client.Stream(ctx, req, p.model)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	if p == nil || p.client == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	if err := p.checkRequest(req); err != nil {
+		return nil, err
+	}
+	return p.client.Complete(ctx, req, p.model)
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// ListModels implements provider.Cataloger with the /models list (cached, with overrides applied)
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	cat, err := p.catalog.get(ctx, p.client.ListModels)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	return cat.Models(), nil
+}
+
+// Capabilities implements provider.Cataloger for the configured model
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	cat, err := p.catalog.get(ctx, p.client.ListModels)
+	if err != nil {
+		return provider.ModelInfo{}, fmt.Errorf("%s: %w", p.name, err)
+	}
+	m, ok := cat.Lookup(p.model)
+	if !ok {
+		return provider.ModelInfo{}, fmt.Errorf("%s: %s: %w", p.name, p.model, provider.ErrModelNotFound)
+	}
+	return m, nil
+}
+
+// Model returns the model requests are sent to.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// Models implements provider.Provider. Returns the catalog's model IDs once ListModels or Capabilities has
+// loaded it, otherwise those set with WithModels (or the configured model).
+func (p *Provider) Models() []string {
+	if cat := p.catalog.cached(); len(cat) > 0 {
+		ids := make([]string, 0, len(cat))
+		for _, m := range cat.Models() {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+	if len(p.models) > 0 {
+		return p.models
+	}
+	return []string{p.model}
+}
@@ -0,0 +1,562 @@
+package openaicompat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// DefaultTimeout bounds a non-streaming request, and a streaming one until its response starts.
+const DefaultTimeout = 5 * time.Minute
+
+// Client talks to an OpenAI-compatible chat completions API (OpenAI, OpenRouter, vLLM, LM Studio,
+// llama.cpp server, gateways, ...).
+type Client struct {
+	apiKey     string
+	baseURL    string
+	headers    http.Header
+	httpClient *http.Client
+	timeout    time.Duration
+	retry      provider.RetryPolicy
+	name       string // used in debug output
+}
+
+// NewClient creates a client for the API at baseURL, the prefix of /chat/completions
+// (e.g. "http://localhost:8000/v1"). apiKey may be empty for servers without auth.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		headers:    http.Header{},
+		httpClient: &http.Client{},
+		timeout:    DefaultTimeout,
+		retry:      provider.DefaultRetryPolicy(),
+		name:       DefaultName,
+	}
+}
+
+// SetRetryPolicy replaces the retry policy (default provider.DefaultRetryPolicy; use provider.NoRetry to disable).
+func (c *Client) SetRetryPolicy(policy provider.RetryPolicy) {
+	c.retry = policy
+}
+
+// SetHeader sets a header sent with every request (an empty value removes it).
+func (c *Client) SetHeader(key, value string) {
+	if value == "" {
+		c.headers.Del(key)
+		return
+	}
+	c.headers.Set(key, value)
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. for a proxy, custom TLS or instrumentation).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// SetTimeout bounds a non-streaming request, and a streaming one until its response starts; the stream
+// itself is bounded only by the caller's context. 0 disables it.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.timeout = d
+}
+
+// SetName sets the provider name shown in debug output.
+func (c *Client) SetName(name string) {
+	c.name = name
+}
+
+// (OpenAI-compatible)
+type chatRequest struct {
+	Model             string          `json:"model"`
+	Messages          []chatMessage   `json:"messages"`
+	Temperature       float64         `json:"temperature,omitempty"`
+	MaxTokens         int             `json:"max_tokens,omitempty"`
+	Stream            bool            `json:"stream"`
+	Tools             []toolDef       `json:"tools,omitempty"`
+	ParallelToolCalls bool            `json:"parallel_tool_calls,omitempty"`
+	StreamOptions     *streamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat    *responseFormat `json:"response_format,omitempty"`
+	ToolChoice        interface{}     `json:"tool_choice,omitempty"` // "auto", "none", "required" or toolChoiceFunction
+}
+
+// toolChoiceFunction is the tool_choice object naming a specific function
+type toolChoiceFunction struct {
+	Type     string `json:"type"` // "function"
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// responseFormat is the OpenAI-compatible response_format parameter
+type responseFormat struct {
+	Type       string      `json:"type"` // "json_object" or "json_schema"
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	Strict bool                   `json:"strict,omitempty"`
+}
+
+// streamOptions asks for a final chunk carrying usage when streaming
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatMessage is OpenAI-compatible: assistant may have tool_calls; tool result has role "tool" and tool_call_id.
+type chatMessage struct {
+	Role       string      `json:"role"`
+	Content    interface{} `json:"content"` // string, or omitempty when tool_calls present
+	ToolCalls  []toolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+	// ReasoningDetails replays signed reasoning on assistant messages (required by e.g. Anthropic and Gemini models with tools)
+	ReasoningDetails []reasoningDetail `json:"reasoning_details,omitempty"`
+}
+
+// reasoningDetail is one reasoning_details entry (OpenRouter); only "reasoning.text" entries are used
+type reasoningDetail struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Index     int    `json:"index"`
+}
+
+// contentBlock represents a content block in a message
+type contentBlock struct {
+	Type      string    `json:"type"`
+	Text      string    `json:"text,omitempty"`        // For text blocks
+	ImageURL  *imageURL `json:"image_url,omitempty"`   // For image_url blocks
+	ToolUseID string    `json:"tool_use_id,omitempty"` // For tool_result blocks
+	Content   string    `json:"content,omitempty"`     // For tool_result content
+}
+
+// imageURL is an image reference: a remote http(s) URL or a base64 data URL
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type toolDef struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+type chatResponse struct {
+	ID      string    `json:"id"`
+	Model   string    `json:"model"`
+	Choices []choice  `json:"choices"`
+	Usage   usage     `json:"usage"`
+	Error   *apiError `json:"error,omitempty"` // OpenRouter can answer 200 with an error object
+}
+
+// apiError is the error object some servers (notably OpenRouter) send in a 200 body or mid-stream
+type apiError struct {
+	Code    interface{} `json:"code"` // an HTTP status (OpenRouter) or a code string ("context_length_exceeded")
+	Message string      `json:"message"`
+	Type    string      `json:"type,omitempty"`
+}
+
+// toProvider classifies the error as if it had come with its HTTP status; raw is kept as the body
+func (e *apiError) toProvider(raw string) *provider.HTTPError {
+	return provider.NewHTTPError(e.status(), raw, nil)
+}
+
+// status returns the error's HTTP status, inferred from the code or type when the code is not one
+func (e *apiError) status() int {
+	if code, ok := e.Code.(float64); ok && code >= 400 {
+		return int(code)
+	}
+	kind := strings.ToLower(fmt.Sprint(e.Code) + " " + e.Type)
+	switch {
+	case strings.Contains(kind, "rate_limit"):
+		return http.StatusTooManyRequests
+	case strings.Contains(kind, "auth") || strings.Contains(kind, "permission"):
+		return http.StatusUnauthorized
+	case strings.Contains(kind, "invalid_request") || strings.Contains(kind, "context_length") || strings.Contains(kind, "content_filter"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+type choice struct {
+	Index        int     `json:"index"`
+	Message      message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+type message struct {
+	Role             string            `json:"role"`
+	Content          string            `json:"content"`
+	Reasoning        string            `json:"reasoning,omitempty"`
+	ReasoningContent string            `json:"reasoning_content,omitempty"` // vLLM, llama.cpp and DeepSeek name it this
+	ReasoningDetails []reasoningDetail `json:"reasoning_details,omitempty"`
+	ToolCalls        []toolCall        `json:"tool_calls,omitempty"`
+}
+
+type toolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function toolCallFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type usage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens     int `json:"cached_tokens"`
+		CacheWriteTokens int `json:"cache_write_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+}
+
+// toProvider converts API usage; prompt_tokens already includes cached tokens
+func (u usage) toProvider() provider.UsageInfo {
+	out := provider.UsageInfo{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		out.CacheReadTokens = u.PromptTokensDetails.CachedTokens
+		out.CacheWriteTokens = u.PromptTokensDetails.CacheWriteTokens
+	}
+	return out
+}
+
+// streamChunk represents one SSE chunk
+type streamChunk struct {
+	ID      string        `json:"id"`
+	Model   string        `json:"model"`
+	Choices []deltaChoice `json:"choices"`
+	Usage   *usage        `json:"usage,omitempty"` // only on the final chunk when include_usage is set
+	Error   *apiError     `json:"error,omitempty"` // a failure after the stream started
+}
+
+type deltaChoice struct {
+	Index        int    `json:"index"`
+	Delta        delta  `json:"delta"`
+	FinishReason string `json:"finish_reason"` // set on the choice's last chunk
+}
+
+type delta struct {
+	Role      string `json:"role,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Reasoning string `json:"reasoning,omitempty"` // For reasoning models (o1, DeepSeek-R1, etc.)
+	// ReasoningContent is the same as Reasoning on servers that name it reasoning_content
+	ReasoningContent string                `json:"reasoning_content,omitempty"`
+	ToolCalls        []streamToolCallDelta `json:"tool_calls,omitempty"`
+	// ReasoningDetails repeats the reasoning with provider metadata; only its signatures are used
+	ReasoningDetails []reasoningDetail `json:"reasoning_details,omitempty"`
+}
+
+// streamToolCallDelta is the OpenAI streaming delta for one tool call
+type streamToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Function *struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function,omitempty"`
+}
+
+// createRequest builds HTTP request with auth
+func (c *Client) createRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+
+	return req, nil
+}
+
+// doRequest sends a JSON request with retries and returns the 200 response (caller closes the body).
+// Non-200 responses become *provider.HTTPError; retryable ones (429, 5xx, ...) are retried per the
+// client's policy, honoring Retry-After. For streams this only covers failures before the first byte.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var resp *http.Response
+	err := c.retry.Do(ctx, func() error {
+		httpReq, err := c.createRequest(ctx, method, path, body)
+		if err != nil {
+			return err
+		}
+		r, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		if r.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			return provider.NewHTTPError(r.StatusCode, string(b), r.Header)
+		}
+		resp = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// buildChatRequest converts a provider request into a chat completions request; the system prompt
+// becomes the first message.
+func buildChatRequest(req provider.CompletionRequest, model string, stream bool) chatRequest {
+	convertedTools := convertTools(req.Tools)
+	chatReq := chatRequest{
+		Model:             model,
+		Messages:          convertMessages(req.Messages),
+		Temperature:       req.Temperature,
+		MaxTokens:         req.MaxTokens,
+		Stream:            stream,
+		Tools:             convertedTools,
+		ParallelToolCalls: len(convertedTools) > 0,
+		ResponseFormat:    convertResponseFormat(req.ResponseFormat),
+	}
+	if len(convertedTools) > 0 {
+		chatReq.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
+	if stream {
+		chatReq.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if req.SystemPrompt != "" {
+		chatReq.Messages = append([]chatMessage{
+			{Role: "system", Content: req.SystemPrompt},
+		}, chatReq.Messages...)
+	}
+	return chatReq
+}
+
+// convertToolChoice maps a provider tool choice to tool_choice; nil leaves the API default (auto)
+func convertToolChoice(c *provider.ToolChoice) interface{} {
+	if c == nil {
+		return nil
+	}
+	if c.Mode == provider.ToolChoiceTool {
+		fn := toolChoiceFunction{Type: "function"}
+		fn.Function.Name = c.Name
+		return fn
+	}
+	return string(c.Mode)
+}
+
+// convertResponseFormat maps a provider response format to response_format; nil for plain text
+func convertResponseFormat(f *provider.ResponseFormat) *responseFormat {
+	if !f.IsJSON() {
+		return nil
+	}
+	if f.Type == provider.ResponseFormatJSONObject || f.Schema == nil {
+		return &responseFormat{Type: "json_object"}
+	}
+	return &responseFormat{
+		Type:       "json_schema",
+		JSONSchema: &jsonSchema{Name: f.SchemaName(), Schema: f.Schema, Strict: f.Strict},
+	}
+}
+
+// convertMessages converts agent-core messages to chat completions messages
+func convertMessages(messages []types.Message) []chatMessage {
+	result := make([]chatMessage, 0, len(messages))
+
+	// Tool messages only accept text, so images returned by tools are sent in a user message
+	// after the run of tool results they belong to.
+	var toolImages []contentBlock
+	flushToolImages := func() {
+		if len(toolImages) == 0 {
+			return
+		}
+		parts := append([]contentBlock{{Type: "text", Text: "Images returned by the tool calls above:"}}, toolImages...)
+		result = append(result, chatMessage{Role: "user", Content: parts})
+		toolImages = nil
+	}
+
+	for _, msg := range messages {
+		if msg.Role() != "toolResult" {
+			flushToolImages()
+		}
+		switch msg.Role() {
+		case "user":
+			if userMsg, ok := msg.(types.UserMessage); ok {
+				result = append(result, chatMessage{
+					Role:    "user",
+					Content: userContent(userMsg.Content),
+				})
+			}
+		case "assistant":
+			if assistantMsg, ok := msg.(types.AssistantMessage); ok {
+				text := ""
+				var toolCalls []toolCall
+				var details []reasoningDetail
+				for _, block := range assistantMsg.Content {
+					switch b := block.(type) {
+					case types.ThinkingContent:
+						// Only signed reasoning is replayed; unsigned reasoning is not needed by any model
+						if b.Signature != "" {
+							details = append(details, reasoningDetail{Type: "reasoning.text", Text: b.Thinking, Signature: b.Signature, Index: len(details)})
+						}
+					case types.TextContent:
+						text += b.Text
+					case types.ToolCallContent:
+						argsStr := ""
+						if b.Arguments != nil {
+							if j, err := json.Marshal(b.Arguments); err == nil {
+								argsStr = string(j)
+							}
+						}
+						toolCalls = append(toolCalls, toolCall{
+							ID:   b.ID,
+							Type: "function",
+							Function: toolCallFunction{
+								Name:      b.Name,
+								Arguments: argsStr,
+							},
+						})
+					}
+				}
+				m := chatMessage{Role: "assistant", Content: text, ReasoningDetails: details}
+				if len(toolCalls) > 0 {
+					m.ToolCalls = toolCalls
+				}
+				result = append(result, m)
+			}
+		case "toolResult":
+			// OpenAI: role "tool", content = result string, tool_call_id links to assistant tool_calls
+			if toolResultMsg, ok := msg.(types.ToolResultMessage); ok {
+				text := ""
+				hasImage := false
+				for _, block := range toolResultMsg.Content {
+					switch b := block.(type) {
+					case types.TextContent:
+						text += b.Text
+					case types.ImageContent:
+						hasImage = true
+						toolImages = append(toolImages, contentBlock{Type: "image_url", ImageURL: &imageURL{URL: imageDataURL(b)}})
+					}
+				}
+				if text == "" && toolResultMsg.Details != nil {
+					if j, err := json.Marshal(toolResultMsg.Details); err == nil {
+						text = string(j)
+					}
+				}
+				if text == "" && hasImage {
+					text = "(image attached below)"
+				}
+				result = append(result, chatMessage{
+					Role:       "tool",
+					Content:    text,
+					ToolCallID: toolResultMsg.ToolCallID,
+				})
+			}
+		}
+	}
+	flushToolImages()
+
+	return result
+}
+
+// userContent returns plain text when the message has no images (the common case), otherwise
+// an array of text and image_url content parts in their original order.
+func userContent(content []types.ContentBlock) interface{} {
+	hasImage := false
+	text := ""
+	for _, block := range content {
+		switch b := block.(type) {
+		case types.TextContent:
+			text += b.Text
+		case types.ImageContent:
+			hasImage = true
+		}
+	}
+	if !hasImage {
+		return text
+	}
+	parts := make([]contentBlock, 0, len(content))
+	for _, block := range content {
+		switch b := block.(type) {
+		case types.TextContent:
+			if b.Text != "" {
+				parts = append(parts, contentBlock{Type: "text", Text: b.Text})
+			}
+		case types.ImageContent:
+			parts = append(parts, contentBlock{Type: "image_url", ImageURL: &imageURL{URL: imageDataURL(b)}})
+		}
+	}
+	return parts
+}
+
+// imageDataURL returns the URL sent for an image: http(s) and data URLs are passed through,
+// raw base64 is wrapped in a data URL using MimeType (default image/png).
+func imageDataURL(img types.ImageContent) string {
+	if strings.HasPrefix(img.Data, "http://") || strings.HasPrefix(img.Data, "https://") || strings.HasPrefix(img.Data, "data:") {
+		return img.Data
+	}
+	mimeType := img.MimeType
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return "data:" + mimeType + ";base64," + img.Data
+}
+
+// reasoningSignature returns the last signature found in reasoning details, if any
+func reasoningSignature(details []reasoningDetail) string {
+	sig := ""
+	for _, d := range details {
+		if d.Signature != "" {
+			sig = d.Signature
+		}
+	}
+	return sig
+}
+
+// convertTools converts provider tools to chat completions function tools
+func convertTools(tools []provider.Tool) []toolDef {
+	result := make([]toolDef, 0, len(tools))
+
+	for _, t := range tools {
+		result = append(result, toolDef{
+			Type: "function",
+			Function: toolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,482 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// newTestClient returns a client pointed at an httptest server with a fast retry policy.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *[]provider.RetryInfo) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	var retries []provider.RetryInfo
+	c := NewClient("http://unused", "test-key")
+	c.baseURL = srv.URL
+	policy := provider.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.OnRetry = func(info provider.RetryInfo) { retries = append(retries, info) }
+	c.SetRetryPolicy(policy)
+	return c, &retries
+}
+
+func TestCompleteRetriesRateLimit(t *testing.T) {
+	var calls int32
+	c, retries := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":"rate limited"}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+
+	resp, err := c.Complete(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("expected success after retry, got %+v, %v", resp, err)
+	}
+	if len(*retries) != 1 || (*retries)[0].Delay != 10*time.Millisecond {
+		t.Errorf("expected one retry honoring Retry-After, got %+v", *retries)
+	}
+}
+
+func TestCompleteDoesNotRetryClientError(t *testing.T) {
+	var calls int32
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `bad request`)
+	})
+
+	_, err := c.Complete(context.Background(), provider.CompletionRequest{}, "m")
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 400 {
+		t.Fatalf("expected HTTPError 400, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("400 must not be retried, got %d calls", calls)
+	}
+}
+
+func TestCompleteClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"context overflow", 400, `{"error":{"message":"This model's maximum context length is 8192 tokens","code":"context_length_exceeded"}}`, provider.ErrContextLength},
+		{"auth", 401, `{"error":{"message":"No auth credentials found","code":401}}`, provider.ErrAuth},
+		{"error object in a 200 body", 200, `{"error":{"message":"Input was flagged by moderation","code":403}}`, provider.ErrContentFiltered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			_, err := c.Complete(context.Background(), provider.CompletionRequest{}, "m")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestStreamReportsMidStreamError(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"error\":{\"code\":\"server_error\",\"message\":\"Provider disconnected\"}}\n\n")
+	})
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	_, err = provider.Collect(events, nil)
+	if !errors.Is(err, provider.ErrServer) || !strings.Contains(err.Error(), "Provider disconnected") {
+		t.Errorf("expected a classified server error, got %v", err)
+	}
+}
+
+func TestStreamRetriesBeforeFirstByte(t *testing.T) {
+	var calls int32
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+	})
+
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil || resp.Text != "hi" {
+		t.Fatalf("unexpected stream result %+v, %v", resp, err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestStreamGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	c, retries := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 503 {
+		t.Fatalf("expected HTTPError 503, got %v", err)
+	}
+	if calls != 3 || len(*retries) != 2 {
+		t.Errorf("expected 3 attempts and 2 retries, got %d and %d", calls, len(*retries))
+	}
+}
+
+func TestStreamReportsUsage(t *testing.T) {
+	var body map[string]interface{}
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, "data: {\"model\":\"m\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"model\":\"m\",\"choices\":[],\"usage\":{\"prompt_tokens\":100,\"completion_tokens\":5,\"total_tokens\":105,\"prompt_tokens_details\":{\"cached_tokens\":80}}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	want := provider.UsageInfo{PromptTokens: 100, CompletionTokens: 5, TotalTokens: 105, CacheReadTokens: 80}
+	if resp.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+	if opts, _ := body["stream_options"].(map[string]interface{}); opts["include_usage"] != true {
+		t.Errorf("expected stream_options.include_usage in request, got %v", body["stream_options"])
+	}
+}
+
+func TestStreamToolCallsInIndexOrder(t *testing.T) {
+	bigArg := strings.Repeat("x", 200*1024) // one chunk longer than a default bufio.Scanner line
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":%d,\"id\":\"call_%d\",\"function\":{\"name\":\"t%d\",\"arguments\":\"\"}}]}}]}\n\n", i, i, i)
+		}
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":4,\"function\":{\"arguments\":\"{\\\"q\\\":\\\"%s\\\"}\"}}]}}]}\n\n", bigArg)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(resp.ToolCalls) != 5 {
+		t.Fatalf("expected 5 tool calls, got %d", len(resp.ToolCalls))
+	}
+	for i, tc := range resp.ToolCalls {
+		if tc.ID != fmt.Sprintf("call_%d", i) {
+			t.Errorf("tool call %d has ID %q; calls must come in index order", i, tc.ID)
+		}
+	}
+	if resp.ToolCalls[4].Arguments["q"] != bigArg {
+		t.Errorf("expected the large argument chunk intact")
+	}
+	if resp.FinishReason != provider.FinishToolCalls {
+		t.Errorf("FinishReason = %q, want tool_calls", resp.FinishReason)
+	}
+}
+
+func TestStreamEndingWithoutDone(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"finished choice", "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"length\"}]}\n\n", false},
+		{"cut off", "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			})
+			events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			resp, err := provider.Collect(events, nil)
+			if tt.wantErr {
+				if !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+				}
+				return
+			}
+			if err != nil || resp.Text != "hi" || resp.FinishReason != provider.FinishLength {
+				t.Errorf("unexpected result %+v, %v", resp, err)
+			}
+		})
+	}
+}
+
+func TestConvertMessagesImages(t *testing.T) {
+	messages := []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{
+			types.TextContent{Text: "What is in these?"},
+			types.ImageContent{Data: "iVBORw0KGgo=", MimeType: "image/png"},
+			types.ImageContent{Data: "https://example.com/cat.jpg"},
+		}},
+		types.AssistantMessage{Content: []types.ContentBlock{
+			types.ToolCallContent{ID: "c1", Name: "screenshot"},
+			types.ToolCallContent{ID: "c2", Name: "screenshot"},
+		}},
+		types.ToolResultMessage{ToolCallID: "c1", Content: []types.ContentBlock{types.ImageContent{Data: "data:image/jpeg;base64,AAAA"}}},
+		types.ToolResultMessage{ToolCallID: "c2", Content: []types.ContentBlock{types.TextContent{Text: "no change"}}},
+	}
+
+	got := convertMessages(messages)
+	if len(got) != 5 {
+		t.Fatalf("expected user, assistant, 2 tool messages and an image message, got %d: %+v", len(got), got)
+	}
+
+	parts, ok := got[0].Content.([]contentBlock)
+	if !ok || len(parts) != 3 {
+		t.Fatalf("expected 3 content parts for the user message, got %#v", got[0].Content)
+	}
+	if parts[0].Type != "text" || parts[1].ImageURL == nil || parts[1].ImageURL.URL != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("unexpected text/base64 parts: %+v %+v", parts[0], parts[1])
+	}
+	if parts[2].ImageURL == nil || parts[2].ImageURL.URL != "https://example.com/cat.jpg" {
+		t.Errorf("remote URL should pass through, got %+v", parts[2])
+	}
+
+	if got[2].Role != "tool" || got[3].Role != "tool" {
+		t.Fatalf("tool results must directly follow the assistant message, got roles %s, %s", got[2].Role, got[3].Role)
+	}
+	if got[2].Content != "(image attached below)" {
+		t.Errorf("unexpected tool content %q", got[2].Content)
+	}
+	imgParts, ok := got[4].Content.([]contentBlock)
+	if got[4].Role != "user" || !ok || len(imgParts) != 2 || imgParts[1].ImageURL.URL != "data:image/jpeg;base64,AAAA" {
+		t.Errorf("expected tool images in a trailing user message, got %+v", got[4])
+	}
+}
+
+func TestConvertMessagesTextOnlyStaysString(t *testing.T) {
+	got := convertMessages([]types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "hi"}}}})
+	if got[0].Content != "hi" {
+		t.Errorf("text-only content should stay a plain string, got %#v", got[0].Content)
+	}
+}
+
+func TestProviderWithoutVisionRejectsImages(t *testing.T) {
+	p := NewProvider("http://unused", "key", "text-only-model").WithVision(false)
+	req := provider.CompletionRequest{Messages: []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{types.ImageContent{Data: "https://example.com/a.png"}}},
+	}}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, provider.ErrImagesNotSupported) {
+		t.Errorf("Complete: expected ErrImagesNotSupported, got %v", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, provider.ErrImagesNotSupported) {
+		t.Errorf("Stream: expected ErrImagesNotSupported, got %v", err)
+	}
+}
+
+func TestStreamSeparatesReasoning(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning\":\"think \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning\":\"hard\",\"reasoning_details\":[{\"type\":\"reasoning.text\",\"text\":\"hard\",\"signature\":\"sig\"}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"answer\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if resp.Text != "answer" {
+		t.Errorf("reasoning leaked into text: %q", resp.Text)
+	}
+	if len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "think hard" || resp.Reasoning[0].Signature != "sig" {
+		t.Errorf("unexpected reasoning %+v", resp.Reasoning)
+	}
+}
+
+func TestConvertMessagesReplaysSignedReasoning(t *testing.T) {
+	got := convertMessages([]types.Message{
+		types.AssistantMessage{Content: []types.ContentBlock{
+			types.ThinkingContent{Thinking: "unsigned"},
+			types.ThinkingContent{Thinking: "signed", Signature: "sig"},
+			types.TextContent{Text: "hi"},
+		}},
+	})
+	details := got[0].ReasoningDetails
+	if len(details) != 1 || details[0].Text != "signed" || details[0].Signature != "sig" || details[0].Type != "reasoning.text" {
+		t.Errorf("expected only signed reasoning to be replayed, got %+v", details)
+	}
+	if got[0].Content != "hi" {
+		t.Errorf("reasoning must not be mixed into content, got %#v", got[0].Content)
+	}
+}
+
+func TestBuildChatRequestResponseFormat(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	req := buildChatRequest(provider.CompletionRequest{ResponseFormat: provider.JSONSchemaFormat("plan", schema)}, "m", false)
+	raw, _ := json.Marshal(req)
+	var body map[string]interface{}
+	_ = json.Unmarshal(raw, &body)
+	rf, _ := body["response_format"].(map[string]interface{})
+	js, _ := rf["json_schema"].(map[string]interface{})
+	if rf["type"] != "json_schema" || js["name"] != "plan" || js["schema"] == nil {
+		t.Errorf("unexpected response_format %v", body["response_format"])
+	}
+
+	req = buildChatRequest(provider.CompletionRequest{ResponseFormat: provider.JSONObjectFormat()}, "m", false)
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_object" || req.ResponseFormat.JSONSchema != nil {
+		t.Errorf("unexpected json_object format %+v", req.ResponseFormat)
+	}
+	if buildChatRequest(provider.CompletionRequest{}, "m", false).ResponseFormat != nil {
+		t.Error("text requests must not send response_format")
+	}
+}
+
+func TestBuildChatRequestToolChoice(t *testing.T) {
+	tools := []provider.Tool{{Name: "get_weather", Parameters: map[string]interface{}{"type": "object"}}}
+	tests := []struct {
+		name   string
+		choice *provider.ToolChoice
+		want   string
+	}{
+		{"default", nil, `null`},
+		{"none", provider.DisableTools(), `"none"`},
+		{"required", provider.RequireTools(), `"required"`},
+		{"specific", provider.ForceTool("get_weather"), `{"type":"function","function":{"name":"get_weather"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := buildChatRequest(provider.CompletionRequest{Tools: tools, ToolChoice: tt.choice}, "m", false)
+			got, _ := json.Marshal(req.ToolChoice)
+			if string(got) != tt.want {
+				t.Errorf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if req := buildChatRequest(provider.CompletionRequest{ToolChoice: provider.DisableTools()}, "m", false); req.ToolChoice != nil {
+		t.Error("tool_choice must not be sent without tools")
+	}
+}
+
+func TestProviderRejectsUnknownForcedTool(t *testing.T) {
+	p := NewProvider("http://unused", "key", "m")
+	req := provider.CompletionRequest{ToolChoice: provider.ForceTool("missing")}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, provider.ErrInvalidToolChoice) {
+		t.Errorf("expected ErrInvalidToolChoice, got %v", err)
+	}
+}
+
+func TestProviderOptionsSetHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewProvider(srv.URL+"/v1/", "", "local-model").
+		WithName("vllm").
+		WithHeader("X-Gateway-Tenant", "team-a").
+		WithOrganization("org-1").
+		WithProject("proj-1")
+	if _, err := p.Complete(context.Background(), provider.CompletionRequest{}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if got.Get("Authorization") != "" {
+		t.Errorf("no Authorization header expected without an API key, got %q", got.Get("Authorization"))
+	}
+	if got.Get("X-Gateway-Tenant") != "team-a" || got.Get("OpenAI-Organization") != "org-1" || got.Get("OpenAI-Project") != "proj-1" {
+		t.Errorf("unexpected headers %v", got)
+	}
+	if p.Name() != "vllm" {
+		t.Errorf("Name() = %s", p.Name())
+	}
+}
+
+func TestTimeoutCoversWaitForResponseOnly(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	p := NewProvider(srv.URL, "key", "m").WithTimeout(50 * time.Millisecond).WithRetryPolicy(provider.NoRetry())
+	start := time.Now()
+	if _, err := p.Complete(context.Background(), provider.CompletionRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Complete: expected deadline exceeded, got %v", err)
+	}
+	if _, err := p.Stream(context.Background(), provider.CompletionRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stream: expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout not applied: %v", elapsed)
+	}
+}
+
+func TestStreamOutlivesTimeout(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"thinking\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"done\"}}]}\n\ndata: [DONE]\n\n")
+	})
+	c.SetTimeout(30 * time.Millisecond)
+
+	events, err := c.Stream(context.Background(), provider.CompletionRequest{}, "m")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("stream should not be cut by the timeout: %v", err)
+	}
+	if resp.Text != "done" || len(resp.Reasoning) != 1 || resp.Reasoning[0].Text != "thinking" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
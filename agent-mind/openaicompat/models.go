@@ -0,0 +1,230 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// DefaultModelCacheTTL is how long a fetched model list is reused before /models is called again.
+const DefaultModelCacheTTL = 24 * time.Hour
+
+// modelFetchRetry is how long a stale or override-only catalog is served after /models fails.
+const modelFetchRetry = 5 * time.Minute
+
+// modelsResponse is the body of GET /models
+type modelsResponse struct {
+	Data []apiModel `json:"data"`
+}
+
+type apiModel struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	Architecture  struct {
+		Modality        string   `json:"modality"` // e.g. "text+image->text"
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+	TopProvider struct {
+		ContextLength       int `json:"context_length"`
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+	// Pricing is USD per token, as decimal strings ("-1" when variable).
+	Pricing struct {
+		Prompt          string `json:"prompt"`
+		Completion      string `json:"completion"`
+		InputCacheRead  string `json:"input_cache_read"`
+		InputCacheWrite string `json:"input_cache_write"`
+	} `json:"pricing"`
+	SupportedParameters []string `json:"supported_parameters"`
+}
+
+// ListModels fetches the model list from GET /models
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body modelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+	models := make([]provider.ModelInfo, 0, len(body.Data))
+	for _, m := range body.Data {
+		models = append(models, m.toProvider())
+	}
+	return models, nil
+}
+
+// toProvider converts a /models entry to provider.ModelInfo (prices become per million tokens). Plain
+// OpenAI-compatible servers only list IDs; OpenRouter also reports limits, modalities, parameters and pricing.
+func (m apiModel) toProvider() provider.ModelInfo {
+	info := provider.ModelInfo{
+		ID:              m.ID,
+		Name:            m.Name,
+		ContextWindow:   m.ContextLength,
+		MaxOutputTokens: m.TopProvider.MaxCompletionTokens,
+	}
+	if info.ContextWindow == 0 {
+		info.ContextWindow = m.TopProvider.ContextLength
+	}
+	// Servers that do not report parameters or modalities are assumed capable, so requests are not
+	// rejected on missing data; correct them with WithModelOverrides.
+	if m.SupportedParameters == nil {
+		info.Tools, info.StructuredOutput = true, true
+	}
+	if m.Architecture.Modality == "" && m.Architecture.InputModalities == nil {
+		info.Vision = true
+	}
+	for _, p := range m.SupportedParameters {
+		switch p {
+		case "tools":
+			info.Tools = true
+		case "reasoning", "include_reasoning":
+			info.Reasoning = true
+		case "response_format", "structured_outputs":
+			info.StructuredOutput = true
+		}
+	}
+	for _, in := range m.Architecture.InputModalities {
+		if in == "image" {
+			info.Vision = true
+		}
+	}
+	if input, _, ok := strings.Cut(m.Architecture.Modality, "->"); ok && strings.Contains(input, "image") {
+		info.Vision = true
+	}
+	input, okIn := perMillion(m.Pricing.Prompt)
+	output, okOut := perMillion(m.Pricing.Completion)
+	if okIn && okOut {
+		cacheRead, _ := perMillion(m.Pricing.InputCacheRead)
+		cacheWrite, _ := perMillion(m.Pricing.InputCacheWrite)
+		info.Pricing = &provider.ModelPrice{Input: input, Output: output, CacheRead: cacheRead, CacheWrite: cacheWrite}
+	}
+	return info
+}
+
+// perMillion converts a USD-per-token decimal string to USD per million tokens; false when missing or variable
+func perMillion(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v * 1e6, true
+}
+
+// modelCatalog loads the model list from memory, the disk cache or /models, in that order, and applies
+// local overrides on top.
+type modelCatalog struct {
+	mu           sync.Mutex
+	cachePath    string // "" = no disk cache
+	ttl          time.Duration
+	overridePath string // "" = no overrides
+	models       provider.Catalog
+	loadedAt     time.Time
+}
+
+// DefaultModelCachePath returns <user cache dir>/biome/<name>-models.json, for use with WithModelCache,
+// or "" when there is no user cache dir.
+func DefaultModelCachePath(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "biome", name+"-models.json")
+}
+
+// get returns the merged catalog, fetching with fetch when neither memory nor the disk cache is fresh.
+// When the fetch fails a stale cache is used, then the overrides alone.
+func (c *modelCatalog) get(ctx context.Context, fetch func(context.Context) ([]provider.ModelInfo, error)) (provider.Catalog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.models != nil && time.Since(c.loadedAt) < c.ttl {
+		return c.models, nil
+	}
+
+	base, loadedAt, cacheErr := c.readCache()
+	if cacheErr != nil || time.Since(loadedAt) >= c.ttl {
+		models, err := fetch(ctx)
+		switch {
+		case err == nil:
+			base, loadedAt = provider.NewCatalog(models), time.Now()
+			c.writeCache(models)
+		case cacheErr != nil && c.overridePath == "":
+			return nil, fmt.Errorf("list models: %w", err)
+		default:
+			// Serve the stale cache (or the overrides alone) and try /models again shortly.
+			loadedAt = time.Now().Add(modelFetchRetry - c.ttl)
+		}
+	}
+
+	overrides, err := c.readOverrides()
+	if err != nil {
+		return nil, err
+	}
+	c.models = provider.Catalog{}.Merge(base).Merge(overrides)
+	c.loadedAt = loadedAt
+	return c.models, nil
+}
+
+// cached returns the in-memory catalog without loading it (nil when not loaded yet)
+func (c *modelCatalog) cached() provider.Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.models
+}
+
+func (c *modelCatalog) readCache() (provider.Catalog, time.Time, error) {
+	if c.cachePath == "" {
+		return nil, time.Time{}, os.ErrNotExist
+	}
+	st, err := os.Stat(c.cachePath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	cat, err := provider.LoadCatalog(c.cachePath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return cat, st.ModTime(), nil
+}
+
+// writeCache stores the fetched list; failures only cost a refetch next time, so they are ignored
+func (c *modelCatalog) writeCache(models []provider.ModelInfo) {
+	if c.cachePath == "" {
+		return
+	}
+	data, err := json.Marshal(models)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cachePath), 0o755); err != nil {
+		return
+	}
+	tmp := c.cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.cachePath)
+}
+
+func (c *modelCatalog) readOverrides() (provider.Catalog, error) {
+	if c.overridePath == "" {
+		return nil, nil
+	}
+	cat, err := provider.LoadCatalog(c.overridePath)
+	if err != nil {
+		return nil, fmt.Errorf("model overrides: %w", err)
+	}
+	return cat, nil
+}
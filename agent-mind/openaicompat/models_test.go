@@ -0,0 +1,122 @@
+package openaicompat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+const modelsBody = `{"data":[
+	{"id":"openai/gpt-4o","name":"GPT-4o","context_length":128000,
+	 "architecture":{"modality":"text+image->text","input_modalities":["text","image"]},
+	 "top_provider":{"max_completion_tokens":16384},
+	 "pricing":{"prompt":"0.0000025","completion":"0.00001","input_cache_read":"0.00000125"},
+	 "supported_parameters":["tools","tool_choice","response_format"]},
+	{"id":"meta-llama/llama-3-8b","name":"Llama 3 8B","context_length":8192,
+	 "architecture":{"modality":"text->text"},
+	 "pricing":{"prompt":"-1","completion":"-1"},
+	 "supported_parameters":["temperature"]}
+]}`
+
+// newCatalogProvider returns a provider whose /models endpoint is served by handler and cached in a temp dir.
+func newCatalogProvider(t *testing.T, model string, handler http.HandlerFunc) (*Provider, string) {
+	t.Helper()
+	client, _ := newTestClient(t, handler)
+	cachePath := filepath.Join(t.TempDir(), "models.json")
+	p := NewProvider("http://unused", "test-key", model).WithModelCache(cachePath, time.Hour)
+	p.client = client
+	return p, cachePath
+}
+
+func TestCapabilitiesFromModelsEndpoint(t *testing.T) {
+	var calls int32
+	p, cachePath := newCatalogProvider(t, "openai/gpt-4o", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, modelsBody)
+	})
+
+	info, err := p.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+	if !info.Tools || !info.Vision || !info.StructuredOutput || info.ContextWindow != 128000 || info.MaxOutputTokens != 16384 {
+		t.Errorf("unexpected capabilities %+v", info)
+	}
+	if info.Pricing == nil || info.Pricing.Input != 2.5 || info.Pricing.Output != 10 || info.Pricing.CacheRead != 1.25 {
+		t.Errorf("unexpected pricing %+v", info.Pricing)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Errorf("expected the list to be cached on disk: %v", err)
+	}
+
+	// A second provider sharing the cache file does not call /models.
+	p2, _ := newCatalogProvider(t, "meta-llama/llama-3-8b", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("fresh cache should be used")
+	})
+	p2.WithModelCache(cachePath, time.Hour)
+	llama, err := p2.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities from cache: %v", err)
+	}
+	if llama.Tools || llama.Vision || llama.Pricing != nil {
+		t.Errorf("unexpected llama capabilities %+v", llama)
+	}
+	if got := p2.Models(); len(got) != 2 || got[0] != "meta-llama/llama-3-8b" {
+		t.Errorf("Models() should list the catalog once loaded, got %v", got)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected one /models call, got %d", calls)
+	}
+}
+
+func TestModelOverridesAndUnknownModel(t *testing.T) {
+	overrides := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(overrides, []byte(`[{"id":"private/model","tools":true,"context_window":4096}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := newCatalogProvider(t, "private/model", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	p.WithModelOverrides(overrides)
+
+	// /models fails, so the overrides alone are served.
+	info, err := p.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+	if !info.Tools || info.ContextWindow != 4096 {
+		t.Errorf("override not applied: %+v", info)
+	}
+
+	p.model = "unknown/model"
+	if _, err := p.Capabilities(context.Background()); !errors.Is(err, provider.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestLoadedCatalogRejectsImagesForTextModel(t *testing.T) {
+	p, _ := newCatalogProvider(t, "meta-llama/llama-3-8b", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, modelsBody)
+	})
+	if _, err := p.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	req := provider.CompletionRequest{Messages: []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{types.ImageContent{Data: "https://example.com/a.png"}}},
+	}}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, provider.ErrImagesNotSupported) {
+		t.Errorf("expected ErrImagesNotSupported, got %v", err)
+	}
+}
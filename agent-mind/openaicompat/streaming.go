@@ -0,0 +1,361 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/sse"
+)
+
+const ansiYellow = "\033[33m"
+const ansiReset = "\033[0m"
+
+// label is the client name as shown in debug output
+func (c *Client) label() string {
+	return strings.ToUpper(c.name)
+}
+
+// Stream creates a streaming request to /chat/completions
+func (c *Client) Stream(ctx context.Context, req provider.CompletionRequest, model string) (<-chan provider.StreamEvent, error) {
+	convertedTools := convertTools(req.Tools)
+	fmt.Printf("%s[%s] Stream tools: %d – ", ansiYellow, c.label(), len(convertedTools))
+	if len(convertedTools) == 0 {
+		fmt.Println("(none)" + ansiReset)
+	} else {
+		for i, t := range convertedTools {
+			if i > 0 {
+				fmt.Print(", ")
+			}
+			fmt.Print(t.Function.Name)
+		}
+		fmt.Println(ansiReset)
+	}
+	chatReq := buildChatRequest(req, model, true)
+
+	// Make request (retried until the response starts). The timeout only covers waiting for the
+	// response; once it starts, the stream runs until done or the caller's ctx ends.
+	ctx, cancel := context.WithCancelCause(ctx)
+	timeoutErr := fmt.Errorf("%w: no response within %s", context.DeadlineExceeded, c.timeout)
+	stopTimer := func() bool { return true }
+	if c.timeout > 0 {
+		stopTimer = time.AfterFunc(c.timeout, func() { cancel(timeoutErr) }).Stop
+	}
+	resp, err := c.doRequest(ctx, "POST", "/chat/completions", chatReq)
+	if !stopTimer() {
+		cancel(timeoutErr)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, timeoutErr
+	}
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	// Create event channel
+	events := make(chan provider.StreamEvent, 10)
+
+	// Start SSE parser goroutine
+	go func() {
+		defer cancel(nil)
+		c.parseSSE(ctx, resp.Body, events, model)
+	}()
+
+	return events, nil
+}
+
+// streamToolCallState holds accumulated tool call for one index during streaming
+type streamToolCallState struct {
+	ID   string
+	Name string
+	Args provider.PartialJSON
+}
+
+// parseSSE parses Server-Sent Events from response. model is reported on EventDone when chunks do not name one.
+// Completed tool calls are emitted in index order when the stream ends.
+func (c *Client) parseSSE(ctx context.Context, body io.ReadCloser, events chan<- provider.StreamEvent, model string) {
+	defer close(events)
+	defer body.Close()
+
+	send := func(ev provider.StreamEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	reader := sse.NewReader(body)
+	// Accumulate tool calls by index (OpenAI/OpenRouter stream tool_calls with index)
+	toolCallByIndex := make(map[int]*streamToolCallState)
+	modelUsed := model
+	var usageInfo provider.UsageInfo
+	var finishReason provider.FinishReason
+
+	// finish emits the completed tool calls in index order, then done
+	finish := func() {
+		indices := make([]int, 0, len(toolCallByIndex))
+		for idx := range toolCallByIndex {
+			indices = append(indices, idx)
+		}
+		sort.Ints(indices)
+		for _, idx := range indices {
+			state := toolCallByIndex[idx]
+			if state.ID == "" && state.Name == "" && state.Args.String() == "" {
+				continue
+			}
+			ok := send(provider.StreamEvent{
+				Type: provider.EventToolCall,
+				Content: &provider.ToolCallResponse{
+					ID:        state.ID,
+					Name:      state.Name,
+					Arguments: state.Args.Object(),
+				},
+			})
+			if !ok {
+				return
+			}
+		}
+		send(provider.StreamEvent{
+			Type:    provider.EventDone,
+			Content: &provider.StreamDonePayload{Model: modelUsed, Usage: usageInfo, FinishReason: finishReason},
+		})
+	}
+
+	for {
+		ev, err := reader.Next()
+		if ctx.Err() != nil {
+			events <- provider.StreamEvent{
+				Type:  provider.EventError,
+				Error: ctx.Err(),
+			}
+			return
+		}
+		if errors.Is(err, io.EOF) && finishReason != "" {
+			// Some servers close the stream without [DONE] once the choice has finished
+			finish()
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			send(provider.StreamEvent{
+				Type:  provider.EventError,
+				Error: fmt.Errorf("stream error: %w", err),
+			})
+			return
+		}
+
+		// End of stream: emit completed tool calls then done
+		if ev.Data == "[DONE]" {
+			finish()
+			return
+		}
+
+		// Parse JSON chunk
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			send(provider.StreamEvent{
+				Type:  provider.EventError,
+				Error: chunk.Error.toProvider(ev.Data),
+			})
+			return
+		}
+		if chunk.Model != "" {
+			modelUsed = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usageInfo = chunk.Usage.toProvider()
+		}
+
+		// Extract delta
+		if len(chunk.Choices) > 0 {
+			delta := chunk.Choices[0].Delta
+			if reason := chunk.Choices[0].FinishReason; reason != "" {
+				finishReason = convertFinishReason(reason)
+			}
+
+			// Text delta (regular content)
+			if delta.Content != "" {
+				if !send(provider.StreamEvent{Type: provider.EventTextDelta, Delta: delta.Content}) {
+					return
+				}
+			}
+
+			// Reasoning delta (for reasoning models like o1, DeepSeek-R1), kept apart from answer text
+			reasoningText := delta.Reasoning + delta.ReasoningContent
+			if sig := reasoningSignature(delta.ReasoningDetails); reasoningText != "" || sig != "" {
+				ok := send(provider.StreamEvent{
+					Type:    provider.EventReasoningDelta,
+					Delta:   reasoningText,
+					Content: &provider.ReasoningStreamPayload{Signature: sig},
+				})
+				if !ok {
+					return
+				}
+			}
+
+			// Tool call deltas: accumulate by index and emit incremental payloads (streaming tool-call parsing)
+			if len(delta.ToolCalls) > 0 {
+				fmt.Printf("%s[%s] Tool call delta: %d chunk(s)\n%s", ansiYellow, c.label(), len(delta.ToolCalls), ansiReset)
+			}
+			for _, tc := range delta.ToolCalls {
+				if tc.Index < 0 {
+					continue
+				}
+				if toolCallByIndex[tc.Index] == nil {
+					toolCallByIndex[tc.Index] = &streamToolCallState{}
+				}
+				st := toolCallByIndex[tc.Index]
+				if tc.ID != "" {
+					st.ID = tc.ID
+				}
+				if tc.Function != nil {
+					if tc.Function.Name != "" {
+						st.Name = tc.Function.Name
+					}
+					if tc.Function.Arguments != "" {
+						st.Args.Append(tc.Function.Arguments)
+					}
+				}
+				ok := send(provider.StreamEvent{
+					Type: provider.EventToolDelta,
+					Content: &provider.ToolCallStreamPayload{
+						Index:     tc.Index,
+						ID:        st.ID,
+						Name:      st.Name,
+						Arguments: st.Args.Object(),
+					},
+				})
+				if !ok {
+					return
+				}
+			}
+		}
+	}
+}
+
+// convertFinishReason maps a finish_reason to a provider.FinishReason (unknown values pass through)
+func convertFinishReason(reason string) provider.FinishReason {
+	switch reason {
+	case "stop", "end_turn", "stop_sequence":
+		return provider.FinishStop
+	case "length", "max_tokens":
+		return provider.FinishLength
+	case "tool_calls", "function_call", "tool_use":
+		return provider.FinishToolCalls
+	case "content_filter":
+		return provider.FinishContentFilter
+	}
+	return provider.FinishReason(reason)
+}
+
+// Complete makes a non-streaming request
+func (c *Client) Complete(ctx context.Context, req provider.CompletionRequest, model string) (*provider.CompletionResponse, error) {
+	// Build request
+	chatReq := buildChatRequest(req, model, false)
+	convertedTools := chatReq.Tools
+	// Verify tools sent to the API
+	fmt.Printf("%s[%s] Tools in request: %d – ", ansiYellow, c.label(), len(convertedTools))
+	if len(convertedTools) == 0 {
+		fmt.Println("(none)" + ansiReset)
+	} else {
+		names := make([]string, len(convertedTools))
+		for i, t := range convertedTools {
+			names[i] = t.Function.Name
+		}
+		fmt.Println(names, ansiReset)
+	}
+
+	// DEBUG: Print messages being sent
+	// fmt.Println("\n[DEBUG] Messages being sent to API:")
+	// for i, msg := range chatReq.Messages {
+	// 	msgJSON, _ := json.MarshalIndent(msg, "  ", "  ")
+	// 	fmt.Printf("  Message %d:\n%s\n", i, string(msgJSON))
+	// }
+	// fmt.Println()
+
+	// Make request (with retries, all within the timeout)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	resp, err := c.doRequest(ctx, "POST", "/chat/completions", chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var chatResp chatResponse
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	if err := json.Unmarshal(bodyBytes, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if chatResp.Error != nil {
+		return nil, chatResp.Error.toProvider(string(bodyBytes))
+	}
+
+	// Extract response
+	text := ""
+	var toolCalls []provider.ToolCallResponse
+	var reasoning []provider.ReasoningBlock
+	var finishReason provider.FinishReason
+
+	if len(chatResp.Choices) > 0 {
+		choice := chatResp.Choices[0]
+		text = choice.Message.Content
+		finishReason = convertFinishReason(choice.FinishReason)
+		reasoningText := choice.Message.Reasoning + choice.Message.ReasoningContent
+		if sig := reasoningSignature(choice.Message.ReasoningDetails); reasoningText != "" || sig != "" {
+			reasoning = []provider.ReasoningBlock{{Text: reasoningText, Signature: sig}}
+		}
+
+		// Parse tool calls if present
+		if len(choice.Message.ToolCalls) > 0 {
+			toolCalls = make([]provider.ToolCallResponse, 0, len(choice.Message.ToolCalls))
+			for _, tc := range choice.Message.ToolCalls {
+				// Parse arguments JSON
+				var args map[string]interface{}
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+					// If parsing fails, skip this tool call
+					continue
+				}
+
+				toolCalls = append(toolCalls, provider.ToolCallResponse{
+					ID:        tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: args,
+				})
+			}
+		}
+	}
+
+	modelUsed := chatResp.Model
+	if modelUsed == "" {
+		modelUsed = model
+	}
+	return &provider.CompletionResponse{
+		Text:         text,
+		ToolCalls:    toolCalls,
+		Usage:        chatResp.Usage.toProvider(),
+		Model:        modelUsed,
+		Reasoning:    reasoning,
+		FinishReason: finishReason,
+	}, nil
+}
@@ -0,0 +1,44 @@
+// Package openrouter is the OpenRouter preset of the openaicompat provider: base URL, attribution
+// header, provider name and an on-disk cache of the /models catalog.
+package openrouter
+
+import (
+	"github.com/biome/agent-mind/openaicompat"
+)
+
+const (
+	DefaultBaseURL = "https://openrouter.ai/api/v1"
+	// Referer is sent as HTTP-Referer, which OpenRouter uses for app attribution.
+	Referer = "https://github.com/biome/agent-mind"
+)
+
+// Provider is an openaicompat.Provider configured for OpenRouter; all its options (WithVision,
+// WithRetryPolicy, WithModelOverrides, WithTimeout, ...) apply.
+type Provider = openaicompat.Provider
+
+// Client is an openaicompat.Client configured for OpenRouter.
+type Client = openaicompat.Client
+
+// NewProvider creates an OpenRouter provider. The /models catalog is cached in the user cache dir.
+func NewProvider(apiKey, model string) *Provider {
+	return openaicompat.NewProvider(DefaultBaseURL, apiKey, model).
+		WithName("openrouter").
+		WithHeader("HTTP-Referer", Referer).
+		WithModelCache(openaicompat.DefaultModelCachePath("openrouter"), openaicompat.DefaultModelCacheTTL).
+		WithModels(
+			"openai/gpt-4o-mini",
+			"openai/gpt-4o",
+			"anthropic/claude-3.5-sonnet",
+			"google/gemini-2.0-flash-exp:free",
+			"meta-llama/llama-3.3-70b-instruct",
+			"qwen/qwen-2.5-72b-instruct",
+		)
+}
+
+// NewClient creates a client for the OpenRouter API.
+func NewClient(apiKey string) *Client {
+	c := openaicompat.NewClient(DefaultBaseURL, apiKey)
+	c.SetName("openrouter")
+	c.SetHeader("HTTP-Referer", Referer)
+	return c
+}
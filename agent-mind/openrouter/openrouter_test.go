@@ -0,0 +1,42 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// roundTripFunc captures requests instead of sending them.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNewProviderPreset(t *testing.T) {
+	var got *http.Request
+	p := NewProvider("or-key", "openai/gpt-4o").WithTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		body := `{"model":"openai/gpt-4o","choices":[{"message":{"role":"assistant","content":"hi"}}]}`
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "hi" || p.Name() != "openrouter" {
+		t.Errorf("unexpected response %+v from %s", resp, p.Name())
+	}
+	if want := DefaultBaseURL + "/chat/completions"; got.URL.String() != want {
+		t.Errorf("URL = %s, want %s", got.URL, want)
+	}
+	if got.Header.Get("Authorization") != "Bearer or-key" || got.Header.Get("HTTP-Referer") != Referer {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	if models := p.Models(); len(models) == 0 || models[0] != "openai/gpt-4o-mini" {
+		t.Errorf("expected the preset model list before the catalog loads, got %v", models)
+	}
+}
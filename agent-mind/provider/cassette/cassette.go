@@ -0,0 +1,578 @@
+// Package cassette records provider interactions to a file and replays them deterministically.
+//
+// Wrap a real provider in ModeRecord to capture a session, then use ModeReplay (no inner provider,
+// no network) in regression tests. Requests are matched by a hash of their normalized content;
+// see MatchMode. API keys and other secrets are scrubbed from everything written to disk.
+package cassette
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// Version is the cassette file format version.
+const Version = 1
+
+// Mode selects whether calls go to the inner provider, the cassette, or both.
+type Mode string
+
+const (
+	// ModeRecord always calls the inner provider and records the interaction.
+	ModeRecord Mode = "record"
+	// ModeReplay answers only from the cassette; unmatched requests fail.
+	ModeReplay Mode = "replay"
+	// ModeAuto replays when a recorded interaction matches and records otherwise.
+	ModeAuto Mode = "auto"
+)
+
+// MatchMode selects how a request is matched against recorded interactions.
+type MatchMode string
+
+const (
+	// MatchStrict requires the system prompt, messages, tools and all parameters to be identical.
+	MatchStrict MatchMode = "strict"
+	// MatchLenient compares only message roles/content and tool names, ignoring the system prompt
+	// and sampling parameters. When nothing matches, the next unused interaction is replayed in order.
+	MatchLenient MatchMode = "lenient"
+)
+
+// redacted replaces scrubbed secrets in the cassette.
+const redacted = "[REDACTED]"
+
+// defaultSecretPatterns match common API key and auth header formats.
+var defaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._\-]{16,}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{30,}`),
+}
+
+// File is the on-disk cassette.
+type File struct {
+	Version      int           `json:"version"`
+	Provider     string        `json:"provider,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded call. Exactly one of Response, Events or Error is set.
+type Interaction struct {
+	Call       string                       `json:"call"` // "complete" or "stream"
+	StrictKey  string                       `json:"strict_key"`
+	LenientKey string                       `json:"lenient_key"`
+	Request    recordedRequest              `json:"request"`
+	Response   *provider.CompletionResponse `json:"response,omitempty"`
+	Events     []recordedEvent              `json:"events,omitempty"`
+	Error      string                       `json:"error,omitempty"`
+	// ErrorDetail describes Error so replay returns an error of the same type and kind.
+	ErrorDetail *ErrorDetail `json:"error_detail,omitempty"`
+}
+
+// ErrorDetail records what replay needs to rebuild a recorded error: the status, body and Retry-After
+// of a *provider.HTTPError, and the kind (provider.ErrorKind) of any classified error.
+type ErrorDetail struct {
+	StatusCode   int    `json:"status_code,omitempty"`
+	Body         string `json:"body,omitempty"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+}
+
+// Provider wraps a provider.Provider with record/replay. Safe for concurrent use.
+type Provider struct {
+	mu       sync.Mutex
+	inner    provider.Provider
+	path     string
+	mode     Mode
+	match    MatchMode
+	file     File
+	used     []bool
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// New opens (or, when recording, creates) the cassette at path. inner may be nil in ModeReplay.
+func New(inner provider.Provider, path string, mode Mode) (*Provider, error) {
+	p := &Provider{
+		inner:    inner,
+		path:     path,
+		mode:     mode,
+		match:    MatchStrict,
+		file:     File{Version: Version},
+		patterns: defaultSecretPatterns,
+	}
+	if inner != nil {
+		p.file.Provider = inner.Name()
+	}
+	if mode != ModeReplay && inner == nil {
+		return nil, fmt.Errorf("cassette: %s mode requires an inner provider", mode)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil && mode != ModeRecord:
+		if err := json.Unmarshal(data, &p.file); err != nil {
+			return nil, fmt.Errorf("cassette: decode %s: %w", path, err)
+		}
+		if p.file.Version != Version {
+			return nil, fmt.Errorf("cassette: %s has version %d, want %d", path, p.file.Version, Version)
+		}
+	case err != nil && mode == ModeReplay:
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	p.used = make([]bool, len(p.file.Interactions))
+	return p, nil
+}
+
+// WithMatch sets the match mode (default MatchStrict). Returns p for chaining.
+func (p *Provider) WithMatch(m MatchMode) *Provider {
+	p.match = m
+	return p
+}
+
+// WithSecrets adds literal values (e.g. the API key in use) to scrub in addition to the built-in
+// key patterns. Returns p for chaining.
+func (p *Provider) WithSecrets(secrets ...string) *Provider {
+	for _, s := range secrets {
+		if s != "" {
+			p.secrets = append(p.secrets, s)
+		}
+	}
+	return p
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	rec, strictKey, lenientKey := p.normalize(req)
+	if it, ok := p.lookup(strictKey, lenientKey); ok {
+		if it.Error != "" {
+			return nil, decodeError(it.Error, it.ErrorDetail)
+		}
+		if it.Response == nil {
+			return replayCollect(it.Events)
+		}
+		resp := *it.Response
+		return &resp, nil
+	}
+	if p.mode == ModeReplay {
+		return nil, p.missError(strictKey)
+	}
+
+	resp, err := p.inner.Complete(ctx, req)
+	it := Interaction{Call: "complete", StrictKey: strictKey, LenientKey: lenientKey, Request: rec}
+	if err != nil {
+		it.Error, it.ErrorDetail = encodeError(err)
+	} else {
+		it.Response = resp
+	}
+	if saveErr := p.record(it); saveErr != nil {
+		return nil, saveErr
+	}
+	return resp, err
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	rec, strictKey, lenientKey := p.normalize(req)
+	if it, ok := p.lookup(strictKey, lenientKey); ok {
+		if it.Error != "" {
+			return nil, decodeError(it.Error, it.ErrorDetail)
+		}
+		events := it.Events
+		if events == nil && it.Response != nil {
+			events = eventsFromResponse(it.Response)
+		}
+		return replayStream(ctx, events), nil
+	}
+	if p.mode == ModeReplay {
+		return nil, p.missError(strictKey)
+	}
+
+	it := Interaction{Call: "stream", StrictKey: strictKey, LenientKey: lenientKey, Request: rec}
+	src, err := p.inner.Stream(ctx, req)
+	if err != nil {
+		it.Error, it.ErrorDetail = encodeError(err)
+		if saveErr := p.record(it); saveErr != nil {
+			return nil, saveErr
+		}
+		return nil, err
+	}
+
+	out := make(chan provider.StreamEvent, 10)
+	go func() {
+		defer close(out)
+		// once ctx ends nothing more is forwarded, but the source is still read to its end and recorded
+		forwarding := true
+		for ev := range src {
+			it.Events = append(it.Events, encodeEvent(ev))
+			if forwarding {
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					forwarding = false
+				}
+			}
+		}
+		if err := p.record(it); err != nil && forwarding {
+			select {
+			case out <- provider.StreamEvent{Type: provider.EventError, Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	if p.inner != nil {
+		return p.inner.Name()
+	}
+	return p.file.Provider
+}
+
+// Models implements provider.Provider
+func (p *Provider) Models() []string {
+	if p.inner != nil {
+		return p.inner.Models()
+	}
+	return nil
+}
+
+// Unused returns the number of recorded interactions not replayed yet (useful to assert a test
+// exercised the whole cassette).
+func (p *Provider) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, u := range p.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// lookup returns the first unused interaction matching the request, marking it used. A Complete
+// recording can answer a Stream call and vice versa.
+func (p *Provider) lookup(strictKey, lenientKey string) (Interaction, bool) {
+	if p.mode == ModeRecord {
+		return Interaction{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, it := range p.file.Interactions {
+		if p.used[i] {
+			continue
+		}
+		if it.StrictKey == strictKey || (p.match == MatchLenient && it.LenientKey == lenientKey) {
+			p.used[i] = true
+			return it, true
+		}
+	}
+	if p.match == MatchLenient && p.mode == ModeReplay {
+		for i, it := range p.file.Interactions {
+			if !p.used[i] {
+				p.used[i] = true
+				return it, true
+			}
+		}
+	}
+	return Interaction{}, false
+}
+
+func (p *Provider) missError(strictKey string) error {
+	return fmt.Errorf("cassette: no recorded interaction matches request %s (%s match) in %s", strictKey[:12], p.match, p.path)
+}
+
+// record appends an interaction and rewrites the cassette file.
+func (p *Provider) record(it Interaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.file.Interactions = append(p.file.Interactions, it)
+	p.used = append(p.used, true)
+
+	data, err := json.MarshalIndent(p.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette: encode: %w", err)
+	}
+	data = []byte(p.scrub(string(data)))
+
+	if dir := filepath.Dir(p.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cassette: %w", err)
+		}
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("cassette: write: %w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("cassette: write: %w", err)
+	}
+	return nil
+}
+
+// scrub replaces configured secrets and anything that looks like an API key.
+func (p *Provider) scrub(s string) string {
+	for _, secret := range p.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	for _, re := range p.patterns {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// --- Request normalization ---
+
+type recordedRequest struct {
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	Messages     []recordedMessage `json:"messages"`
+	Tools        []string          `json:"tools,omitempty"`
+	// Params holds every other CompletionRequest field (temperature, max tokens, ...).
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type recordedMessage struct {
+	Role       string          `json:"role"`
+	Content    []recordedBlock `json:"content,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	IsError    bool            `json:"is_error,omitempty"`
+}
+
+type recordedBlock struct {
+	Type      string      `json:"type"`
+	Text      string      `json:"text,omitempty"`
+	MimeType  string      `json:"mime_type,omitempty"`
+	Data      string      `json:"data,omitempty"`
+	ID        string      `json:"id,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+// normalize returns the recorded form of req plus its strict and lenient match keys.
+// Keys are computed after scrubbing so recorded and live requests hash identically.
+func (p *Provider) normalize(req provider.CompletionRequest) (recordedRequest, string, string) {
+	rec := recordedRequest{SystemPrompt: req.SystemPrompt}
+	for _, m := range req.Messages {
+		rec.Messages = append(rec.Messages, normalizeMessage(m))
+	}
+	for _, t := range req.Tools {
+		rec.Tools = append(rec.Tools, t.Name)
+	}
+
+	// Everything except messages and system prompt, plus full tool schemas, goes into params.
+	params := req
+	params.Messages = nil
+	params.SystemPrompt = ""
+	if raw, err := json.Marshal(params); err == nil {
+		rec.Params = raw
+	}
+
+	strict, _ := json.Marshal(rec)
+	lenient, _ := json.Marshal(struct {
+		Messages []recordedMessage `json:"messages"`
+		Tools    []string          `json:"tools"`
+	}{rec.Messages, rec.Tools})
+
+	if scrubbed, err := p.scrubRequest(rec); err == nil {
+		rec = scrubbed
+	}
+	return rec, hash(p.scrub(string(strict))), hash(p.scrub(string(lenient)))
+}
+
+// scrubRequest returns rec with secrets scrubbed from every string field.
+func (p *Provider) scrubRequest(rec recordedRequest) (recordedRequest, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return rec, err
+	}
+	var out recordedRequest
+	err = json.Unmarshal([]byte(p.scrub(string(data))), &out)
+	return out, err
+}
+
+func normalizeMessage(m types.Message) recordedMessage {
+	out := recordedMessage{Role: m.Role()}
+	var content []types.ContentBlock
+	switch msg := m.(type) {
+	case types.UserMessage:
+		content = msg.Content
+	case types.AssistantMessage:
+		content = msg.Content
+	case types.ToolCallMessage:
+		content = msg.Content
+		out.ToolCallID, out.ToolName = msg.ToolCallID, msg.ToolName
+	case types.ToolResultMessage:
+		content = msg.Content
+		out.ToolCallID, out.ToolName, out.IsError = msg.ToolCallID, msg.ToolName, msg.IsError
+	case types.ControlMessage:
+		content = msg.Content
+	}
+	for _, block := range content {
+		switch b := block.(type) {
+		case types.TextContent:
+			out.Content = append(out.Content, recordedBlock{Type: "text", Text: b.Text})
+		case types.ImageContent:
+			out.Content = append(out.Content, recordedBlock{Type: "image", MimeType: b.MimeType, Data: b.Data})
+		case types.ThinkingContent:
+			out.Content = append(out.Content, recordedBlock{Type: "thinking", Text: b.Thinking})
+		case types.ToolCallContent:
+			out.Content = append(out.Content, recordedBlock{Type: "toolCall", ID: b.ID, Name: b.Name, Arguments: b.Arguments})
+		default:
+			out.Content = append(out.Content, recordedBlock{Type: block.ContentType()})
+		}
+	}
+	return out
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// --- Stream events ---
+
+// recordedEvent is the on-disk form of provider.StreamEvent; Content is split by payload type.
+type recordedEvent struct {
+	Type      string                           `json:"type"`
+	Delta     string                           `json:"delta,omitempty"`
+	ToolCall  *provider.ToolCallResponse       `json:"tool_call,omitempty"`
+	ToolDelta *provider.ToolCallStreamPayload  `json:"tool_delta,omitempty"`
+	Reasoning *provider.ReasoningStreamPayload `json:"reasoning,omitempty"`
+	Done      *provider.StreamDonePayload      `json:"done,omitempty"`
+	Error     string                           `json:"error,omitempty"`
+	// ErrorDetail describes Error (see Interaction.ErrorDetail).
+	ErrorDetail *ErrorDetail `json:"error_detail,omitempty"`
+}
+
+func encodeEvent(ev provider.StreamEvent) recordedEvent {
+	out := recordedEvent{Type: ev.Type, Delta: ev.Delta}
+	switch c := ev.Content.(type) {
+	case *provider.ToolCallResponse:
+		out.ToolCall = c
+	case *provider.ToolCallStreamPayload:
+		out.ToolDelta = c
+	case *provider.ReasoningStreamPayload:
+		out.Reasoning = c
+	case *provider.StreamDonePayload:
+		out.Done = c
+	}
+	if ev.Error != nil {
+		out.Error, out.ErrorDetail = encodeError(ev.Error)
+	}
+	return out
+}
+
+func decodeEvent(r recordedEvent) provider.StreamEvent {
+	ev := provider.StreamEvent{Type: r.Type, Delta: r.Delta}
+	switch {
+	case r.ToolCall != nil:
+		tc := *r.ToolCall
+		ev.Content = &tc
+	case r.ToolDelta != nil:
+		td := *r.ToolDelta
+		ev.Content = &td
+	case r.Reasoning != nil:
+		rs := *r.Reasoning
+		ev.Content = &rs
+	case r.Done != nil:
+		d := *r.Done
+		ev.Content = &d
+	}
+	if r.Error != "" {
+		ev.Error = decodeError(r.Error, r.ErrorDetail)
+	}
+	return ev
+}
+
+func encodeError(err error) (string, *ErrorDetail) {
+	var httpErr *provider.HTTPError
+	if errors.As(err, &httpErr) {
+		return err.Error(), &ErrorDetail{
+			StatusCode:   httpErr.StatusCode,
+			Body:         httpErr.Body,
+			RetryAfterMs: httpErr.RetryAfter.Milliseconds(),
+			Kind:         provider.ErrorKind(err),
+		}
+	}
+	if kind := provider.ErrorKind(err); kind != "" {
+		return err.Error(), &ErrorDetail{Kind: kind}
+	}
+	return err.Error(), nil
+}
+
+// decodeError rebuilds a recorded error. Cassettes recorded without a detail replay a plain error.
+func decodeError(msg string, d *ErrorDetail) error {
+	if d == nil {
+		return errors.New(msg)
+	}
+	kind := provider.ParseErrorKind(d.Kind)
+	if d.StatusCode == 0 {
+		return &replayedError{msg: msg, err: kind}
+	}
+	if kind == nil {
+		kind = provider.ClassifyHTTPError(d.StatusCode, d.Body)
+	}
+	return &replayedError{msg: msg, err: &provider.HTTPError{
+		StatusCode: d.StatusCode,
+		Body:       d.Body,
+		RetryAfter: time.Duration(d.RetryAfterMs) * time.Millisecond,
+		Kind:       kind,
+	}}
+}
+
+// replayedError keeps the recorded message and unwraps to the rebuilt *provider.HTTPError or error
+// kind, so errors.As and errors.Is see what they saw when recording.
+type replayedError struct {
+	msg string
+	err error
+}
+
+func (e *replayedError) Error() string { return e.msg }
+
+func (e *replayedError) Unwrap() error { return e.err }
+
+// eventsFromResponse turns a recorded Complete response into a minimal stream (for a Stream call
+// answered by a Complete recording in lenient replay).
+func eventsFromResponse(resp *provider.CompletionResponse) []recordedEvent {
+	var out []recordedEvent
+	if resp.Text != "" {
+		out = append(out, recordedEvent{Type: provider.EventTextDelta, Delta: resp.Text})
+	}
+	for i := range resp.ToolCalls {
+		out = append(out, recordedEvent{Type: provider.EventToolCall, ToolCall: &resp.ToolCalls[i]})
+	}
+	return append(out, recordedEvent{Type: provider.EventDone, Done: &provider.StreamDonePayload{Model: resp.Model, Provider: resp.Provider, Usage: resp.Usage, FinishReason: resp.FinishReason}})
+}
+
+func replayStream(ctx context.Context, events []recordedEvent) <-chan provider.StreamEvent {
+	out := make(chan provider.StreamEvent, 10)
+	go func() {
+		defer close(out)
+		for _, r := range events {
+			select {
+			case out <- decodeEvent(r):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func replayCollect(events []recordedEvent) (*provider.CompletionResponse, error) {
+	return provider.Collect(replayStream(context.Background(), events), nil)
+}
@@ -0,0 +1,201 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+func request(text string, temperature float64) provider.CompletionRequest {
+	return provider.CompletionRequest{
+		SystemPrompt: "system",
+		Messages:     []types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: text}}}},
+		Temperature:  temperature,
+		Tools:        []provider.Tool{{Name: "calculator", Parameters: map[string]interface{}{"type": "object"}}},
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	inner := fake.New(
+		fake.Text("recorded complete"),
+		fake.ToolCalls(fake.ToolCall("c1", "calculator", map[string]interface{}{"expression": "2+2"})),
+	)
+
+	rec, err := New(inner, path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := rec.Complete(context.Background(), request("first", 0.5)); err != nil {
+		t.Fatalf("record Complete: %v", err)
+	}
+	events, err := rec.Stream(context.Background(), request("second", 0.5))
+	if err != nil {
+		t.Fatalf("record Stream: %v", err)
+	}
+	if _, err := provider.Collect(events, nil); err != nil {
+		t.Fatalf("record Collect: %v", err)
+	}
+
+	replay, err := New(nil, path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New replay: %v", err)
+	}
+	if replay.Name() != "fake" {
+		t.Errorf("expected recorded provider name, got %q", replay.Name())
+	}
+	// Replay in reverse order: matching is by request content, not position.
+	events, err = replay.Stream(context.Background(), request("second", 0.5))
+	if err != nil {
+		t.Fatalf("replay Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Fatalf("unexpected replayed stream: %+v, %v", resp, err)
+	}
+	resp, err = replay.Complete(context.Background(), request("first", 0.5))
+	if err != nil || resp.Text != "recorded complete" {
+		t.Fatalf("unexpected replayed completion: %+v, %v", resp, err)
+	}
+	if replay.Unused() != 0 {
+		t.Errorf("expected all interactions replayed, %d unused", replay.Unused())
+	}
+	if inner.Calls() != 2 {
+		t.Errorf("inner provider should only be called while recording, got %d calls", inner.Calls())
+	}
+}
+
+func TestReplaysTypedErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.json")
+	limited := &provider.HTTPError{StatusCode: 429, Body: "slow down", RetryAfter: 3 * time.Second, Kind: provider.ErrRateLimited}
+	inner := fake.New(
+		fake.Error(fmt.Errorf("openai: %w", limited)),
+		fake.Events(provider.StreamEvent{Type: provider.EventError, Error: fmt.Errorf("stream: %w", provider.ErrContextLength)}),
+	)
+	rec, err := New(inner, path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	rec.Complete(context.Background(), request("limited", 0))
+	if events, err := rec.Stream(context.Background(), request("overflow", 0)); err == nil {
+		provider.Collect(events, nil)
+	}
+
+	replay, err := New(nil, path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New replay: %v", err)
+	}
+	_, err = replay.Complete(context.Background(), request("limited", 0))
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 429 || httpErr.RetryAfter != 3*time.Second || !errors.Is(err, provider.ErrRateLimited) {
+		t.Errorf("expected the rate limit error rebuilt, got %#v", err)
+	}
+	if err.Error() != "openai: API error 429: slow down" {
+		t.Errorf("expected the recorded message, got %q", err)
+	}
+	events, err := replay.Stream(context.Background(), request("overflow", 0))
+	if err != nil {
+		t.Fatalf("replay Stream: %v", err)
+	}
+	if _, err := provider.Collect(events, nil); !errors.Is(err, provider.ErrContextLength) {
+		t.Errorf("expected the stream error's kind kept, got %v", err)
+	}
+}
+
+func TestStrictAndLenientMatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	rec, _ := New(fake.New(fake.Text("hello")), path, ModeRecord)
+	if _, err := rec.Complete(context.Background(), request("hi", 0.5)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	strict, _ := New(nil, path, ModeReplay)
+	if _, err := strict.Complete(context.Background(), request("hi", 0.9)); err == nil {
+		t.Error("strict match should reject a request with a different temperature")
+	}
+
+	lenient, _ := New(nil, path, ModeReplay)
+	lenient.WithMatch(MatchLenient)
+	resp, err := lenient.Complete(context.Background(), request("hi", 0.9))
+	if err != nil || resp.Text != "hello" {
+		t.Errorf("lenient match should ignore parameters, got %+v, %v", resp, err)
+	}
+}
+
+func TestRecordsAbandonedStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	script := make([]provider.StreamEvent, 50)
+	for i := range script {
+		script[i] = provider.StreamEvent{Type: provider.EventTextDelta, Delta: "word "}
+	}
+	rec, _ := New(fake.New(fake.Events(script...)), path, ModeRecord)
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := rec.Stream(ctx, request("abandoned", 0)); err != nil {
+		t.Fatalf("record Stream: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // let the buffers fill
+	cancel()                          // the stream is abandoned unread
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the abandoned stream to be recorded once its source closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAutoModeRecordsMisses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	inner := fake.New(fake.Text("one"), fake.Text("two"))
+	auto, _ := New(inner, path, ModeAuto)
+	auto.Complete(context.Background(), request("a", 0))
+
+	auto2, _ := New(inner, path, ModeAuto)
+	resp, _ := auto2.Complete(context.Background(), request("a", 0))
+	if resp.Text != "one" || inner.Calls() != 1 {
+		t.Errorf("expected replay from cassette, got %q after %d inner calls", resp.Text, inner.Calls())
+	}
+	resp, _ = auto2.Complete(context.Background(), request("b", 0))
+	if resp.Text != "two" || inner.Calls() != 2 {
+		t.Errorf("expected miss to be recorded from inner, got %q after %d inner calls", resp.Text, inner.Calls())
+	}
+}
+
+func TestScrubsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	rec, _ := New(fake.New(fake.Text("your key is sk-or-v1-0123456789abcdef0123")), path, ModeRecord)
+	rec.WithSecrets("hunter2-secret")
+	if _, err := rec.Complete(context.Background(), request("my password is hunter2-secret", 0)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2-secret") || strings.Contains(string(data), "sk-or-v1-0123") {
+		t.Errorf("cassette contains secrets:\n%s", data)
+	}
+	if !strings.Contains(string(data), redacted) {
+		t.Error("expected redaction marker in cassette")
+	}
+
+	// Scrubbed requests still match on replay.
+	replay, _ := New(nil, path, ModeReplay)
+	replay.WithSecrets("hunter2-secret")
+	if _, err := replay.Complete(context.Background(), request("my password is hunter2-secret", 0)); err != nil {
+		t.Errorf("replay of scrubbed request: %v", err)
+	}
+}
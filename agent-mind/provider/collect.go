@@ -0,0 +1,75 @@
+package provider
+
+import "fmt"
+
+// Collect drains a stream into a CompletionResponse: text deltas are concatenated, reasoning deltas
+// are grouped into blocks and completed tool calls are gathered in the order they arrive. If onEvent is non-nil it is
+// called for every event before it is accumulated (e.g. to forward deltas to a UI).
+// An EventError ends collection with that error; remaining events are drained in the background
+// so the producer goroutine is not left blocked.
+func Collect(events <-chan StreamEvent, onEvent func(StreamEvent)) (*CompletionResponse, error) {
+	c := newCollector()
+	for ev := range events {
+		if onEvent != nil {
+			onEvent(ev)
+		}
+		c.add(ev)
+		if ev.Type == EventError {
+			go func() {
+				for range events {
+				}
+			}()
+			if ev.Error == nil {
+				return nil, fmt.Errorf("stream error")
+			}
+			return nil, ev.Error
+		}
+	}
+	return c.resp, nil
+}
+
+// collector accumulates stream events into a response
+type collector struct {
+	resp             *CompletionResponse
+	reasoningByIndex map[int]int // reasoning block index -> position in resp.Reasoning
+}
+
+func newCollector() *collector {
+	return &collector{resp: &CompletionResponse{}, reasoningByIndex: make(map[int]int)}
+}
+
+// add accumulates one event; errors are left to the caller
+func (c *collector) add(ev StreamEvent) {
+	resp := c.resp
+	switch ev.Type {
+	case EventTextDelta:
+		resp.Text += ev.Delta
+	case EventToolCall:
+		if tc, ok := ev.Content.(*ToolCallResponse); ok && tc != nil {
+			resp.ToolCalls = append(resp.ToolCalls, *tc)
+		}
+	case EventReasoningDelta:
+		idx := 0
+		sig := ""
+		if p, ok := ev.Content.(*ReasoningStreamPayload); ok && p != nil {
+			idx, sig = p.Index, p.Signature
+		}
+		pos, ok := c.reasoningByIndex[idx]
+		if !ok {
+			pos = len(resp.Reasoning)
+			c.reasoningByIndex[idx] = pos
+			resp.Reasoning = append(resp.Reasoning, ReasoningBlock{})
+		}
+		resp.Reasoning[pos].Text += ev.Delta
+		if sig != "" {
+			resp.Reasoning[pos].Signature = sig
+		}
+	case EventDone:
+		if done, ok := ev.Content.(*StreamDonePayload); ok && done != nil {
+			resp.Model = done.Model
+			resp.Provider = done.Provider
+			resp.Usage = done.Usage
+			resp.FinishReason = done.FinishReason
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrImagesNotSupported is returned (wrapped) when a request contains images and the provider or
+// model cannot accept image input.
+var ErrImagesNotSupported = errors.New("model does not support image input")
+
+// Error kinds. Providers classify failed calls into one of these (see HTTPError.Kind); test for them
+// with errors.Is.
+var (
+	// ErrRateLimited: too many requests (429); retry after HTTPError.RetryAfter.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrContextLength: the prompt does not fit the model's context window; shorten the history.
+	ErrContextLength = errors.New("context length exceeded")
+	// ErrAuth: the API key is missing, invalid, lacks access to the model or has no credits.
+	ErrAuth = errors.New("authentication failed")
+	// ErrContentFiltered: the input or output was blocked by moderation.
+	ErrContentFiltered = errors.New("content filtered")
+	// ErrInvalidRequest: the request was rejected as malformed (unknown model, bad parameters, ...).
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrServer: the provider failed or is overloaded (5xx, 408).
+	ErrServer = errors.New("server error")
+)
+
+// errorKinds maps each error kind to its ErrorKind name
+var errorKinds = []struct {
+	err  error
+	name string
+}{
+	{ErrRateLimited, "rate_limited"},
+	{ErrContextLength, "context_length"},
+	{ErrAuth, "auth"},
+	{ErrContentFiltered, "content_filtered"},
+	{ErrInvalidRequest, "invalid_request"},
+	{ErrServer, "server"},
+}
+
+// ErrorKind names the kind of err for logs and events: "rate_limited", "context_length", "auth",
+// "content_filtered", "invalid_request" or "server". Returns "" when err is not classified.
+func ErrorKind(err error) string {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return k.name
+		}
+	}
+	return ""
+}
+
+// ParseErrorKind returns the error kind named name (as returned by ErrorKind), or nil for an unknown name.
+func ParseErrorKind(name string) error {
+	for _, k := range errorKinds {
+		if k.name == name {
+			return k.err
+		}
+	}
+	return nil
+}
+
+// HTTPError is returned by HTTP-based providers when the API answers with a non-200 status.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the server-requested wait from the Retry-After header (0 if absent).
+	RetryAfter time.Duration
+	// Kind is the error kind (ErrRateLimited, ErrContextLength, ...); nil when unclassified.
+	Kind error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the error kind, so errors.Is(err, ErrRateLimited) etc. work on an HTTPError.
+func (e *HTTPError) Unwrap() error {
+	return e.Kind
+}
+
+// NewHTTPError builds an HTTPError from a response status, body and headers; Kind is set by ClassifyHTTPError.
+func NewHTTPError(statusCode int, body string, header http.Header) *HTTPError {
+	return &HTTPError{
+		StatusCode: statusCode,
+		Body:       body,
+		RetryAfter: ParseRetryAfter(header.Get("Retry-After"), time.Now()),
+		Kind:       ClassifyHTTPError(statusCode, body),
+	}
+}
+
+// Body fragments (lower-cased) that identify a client error more precisely than its status: providers
+// report context overflow and moderation as a plain 400 (or 403/413/422) with a descriptive message.
+var (
+	contextLengthMarkers = []string{
+		"context_length", "context length", "context window", "maximum context", "prompt is too long",
+		"too many tokens", "input is too long", "maximum number of tokens", "reduce the length",
+	}
+	contentFilterMarkers = []string{
+		"content_filter", "content filter", "content_policy", "content policy", "content management policy",
+		"moderation", "flagged", "safety",
+	}
+)
+
+// ClassifyHTTPError returns the error kind for a failed response from its status and body, or nil for
+// statuses that are not errors.
+func ClassifyHTTPError(statusCode int, body string) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized:
+		return ErrAuth
+	case statusCode == http.StatusRequestTimeout || statusCode >= 500:
+		return ErrServer
+	case statusCode < 400:
+		return nil
+	}
+	msg := strings.ToLower(body)
+	switch {
+	case statusCode == http.StatusRequestEntityTooLarge || containsAny(msg, contextLengthMarkers):
+		return ErrContextLength
+	case containsAny(msg, contentFilterMarkers):
+		return ErrContentFiltered
+	case statusCode == http.StatusPaymentRequired || statusCode == http.StatusForbidden:
+		return ErrAuth
+	}
+	return ErrInvalidRequest
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRetryAfter parses a Retry-After header value (delay in seconds or an HTTP date).
+// Returns 0 when the value is empty, invalid or in the past.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package provider_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+func TestClassifyHTTPError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{429, `{"error":"slow down"}`, provider.ErrRateLimited},
+		{401, `invalid x-api-key`, provider.ErrAuth},
+		{402, `Insufficient credits`, provider.ErrAuth},
+		{403, `{"error":{"message":"Input was flagged by moderation"}}`, provider.ErrContentFiltered},
+		{400, `{"error":{"code":"context_length_exceeded"}}`, provider.ErrContextLength},
+		{400, `prompt is too long: 210000 tokens > 200000 maximum`, provider.ErrContextLength},
+		{413, `request too large`, provider.ErrContextLength},
+		{400, `{"error":{"code":"content_filter"}}`, provider.ErrContentFiltered},
+		{400, `unknown parameter: foo`, provider.ErrInvalidRequest},
+		{404, `model not found`, provider.ErrInvalidRequest},
+		{500, `context length of the worker pool exceeded`, provider.ErrServer},
+		{529, `overloaded`, provider.ErrServer},
+		{200, ``, nil},
+	}
+	for _, tt := range tests {
+		if got := provider.ClassifyHTTPError(tt.status, tt.body); got != tt.want {
+			t.Errorf("%d %q: got %v, want %v", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPErrorKind(t *testing.T) {
+	err := fmt.Errorf("steering: %w", provider.NewHTTPError(400, "maximum context length exceeded", nil))
+	if !errors.Is(err, provider.ErrContextLength) || errors.Is(err, provider.ErrInvalidRequest) {
+		t.Errorf("expected only ErrContextLength to match, got %v", err)
+	}
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 400 {
+		t.Errorf("expected the HTTPError to stay reachable, got %v", err)
+	}
+	if kind := provider.ErrorKind(err); kind != "context_length" {
+		t.Errorf("unexpected kind %q", kind)
+	}
+	if kind := provider.ErrorKind(errors.New("boom")); kind != "" {
+		t.Errorf("expected no kind for an unclassified error, got %q", kind)
+	}
+	if provider.ParseErrorKind("context_length") != provider.ErrContextLength || provider.ParseErrorKind("boom") != nil {
+		t.Error("expected ParseErrorKind to invert ErrorKind")
+	}
+}
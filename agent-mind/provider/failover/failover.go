@@ -0,0 +1,399 @@
+// Package failover provides a composite provider.Provider that tries an ordered list of backends,
+// moving to the next one when a backend fails, and can optionally hedge slow requests by starting
+// the next backend in parallel.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+// Name is the value returned by Provider.Name.
+const Name = "failover"
+
+// Provider implements provider.Provider over an ordered list of backends.
+type Provider struct {
+	backends   []provider.Provider
+	models     []string // model each backend was built with; empty when unknown
+	shouldFail func(error) bool
+	hedgeAfter time.Duration
+	onFailover func(Info)
+}
+
+// Info describes a backend that failed (or lost a hedge) and the backend tried next.
+type Info struct {
+	From  string // backend that failed, as "name:model"
+	To    string // backend started next; empty if none was left
+	Err   error
+	Hedge bool // true when To was started because From was slow, not because it failed
+}
+
+// New returns a provider that tries backends in order. A backend with a Model() string method (as the
+// provider adapters have) is labelled and stamped with that model.
+func New(backends ...provider.Provider) *Provider {
+	models := make([]string, len(backends))
+	for i, b := range backends {
+		if m, ok := b.(interface{ Model() string }); ok {
+			models[i] = m.Model()
+		}
+	}
+	return &Provider{backends: backends, models: models, shouldFail: ShouldFailover}
+}
+
+// FromModels builds one backend per model with newProvider, e.g. to fall back across OpenRouter models:
+//
+//	failover.FromModels(func(m string) provider.Provider { return openrouter.NewProvider(key, m) }, primary, backup)
+func FromModels(newProvider func(model string) provider.Provider, models ...string) *Provider {
+	backends := make([]provider.Provider, 0, len(models))
+	for _, m := range models {
+		backends = append(backends, newProvider(m))
+	}
+	p := New(backends...)
+	copy(p.models, models)
+	return p
+}
+
+// WithClassifier replaces ShouldFailover as the rule deciding which errors move to the next backend.
+func (p *Provider) WithClassifier(fn func(error) bool) *Provider {
+	p.shouldFail = fn
+	return p
+}
+
+// WithHedge starts the next backend in parallel when the current one has not answered (Complete)
+// or produced its first event (Stream) within after. The first success wins and the others are cancelled.
+// Zero disables hedging.
+func (p *Provider) WithHedge(after time.Duration) *Provider {
+	p.hedgeAfter = after
+	return p
+}
+
+// OnFailover sets a callback invoked whenever another backend is started.
+func (p *Provider) OnFailover(fn func(Info)) *Provider {
+	p.onFailover = fn
+	return p
+}
+
+// ShouldFailover is the default classifier. Cancellation and malformed requests (400, 422) are
+// returned as-is since another backend would fail the same way; every other error fails over.
+func ShouldFailover(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr *provider.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode != http.StatusBadRequest && httpErr.StatusCode != http.StatusUnprocessableEntity
+	}
+	return true
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return Name
+}
+
+// Models implements provider.Provider; returns every backend's models in order.
+func (p *Provider) Models() []string {
+	var models []string
+	for _, b := range p.backends {
+		models = append(models, b.Models()...)
+	}
+	return models
+}
+
+// ListModels implements provider.Cataloger; returns every backend's models in order, without
+// duplicates. Backends that are not Catalogers are listed by ID.
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var models []provider.ModelInfo
+	seen := map[string]bool{}
+	for i, b := range p.backends {
+		var list []provider.ModelInfo
+		if c, ok := b.(provider.Cataloger); ok {
+			var err error
+			if list, err = c.ListModels(ctx); err != nil {
+				return nil, fmt.Errorf("failover: %s: %w", p.label(i), err)
+			}
+		} else {
+			for _, id := range b.Models() {
+				list = append(list, provider.ModelInfo{ID: id})
+			}
+		}
+		for _, m := range list {
+			if !seen[m.ID] {
+				seen[m.ID] = true
+				models = append(models, m)
+			}
+		}
+	}
+	return models, nil
+}
+
+// Capabilities implements provider.Cataloger with the primary (first) backend's capabilities.
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	if len(p.backends) > 0 {
+		if c, ok := p.backends[0].(provider.Cataloger); ok {
+			return c.Capabilities(ctx)
+		}
+	}
+	return provider.ModelInfo{}, fmt.Errorf("failover: no catalog for the primary backend: %w", provider.ErrModelNotFound)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	resp, idx, cancel, err := run(ctx, p, func(ctx context.Context, b provider.Provider) (*provider.CompletionResponse, error) {
+		return b.Complete(ctx, req)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	cancel()
+	out := *resp
+	p.stamp(idx, &out.Provider, &out.Model)
+	return &out, nil
+}
+
+// streamStart is a stream whose first event has been read, so a failure before any output can
+// still fail over.
+type streamStart struct {
+	events <-chan provider.StreamEvent
+	first  provider.StreamEvent
+	ok     bool
+}
+
+// Stream implements provider.Provider. Failover and hedging apply until a backend produces its
+// first event; after that the stream is committed to that backend and errors pass through.
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	start, idx, cancel, err := run(ctx, p, func(ctx context.Context, b provider.Provider) (streamStart, error) {
+		events, err := b.Stream(ctx, req)
+		if err != nil {
+			return streamStart{}, err
+		}
+		var s streamStart
+		select {
+		case s.first, s.ok = <-events:
+		case <-ctx.Done():
+			go drain(events)
+			return streamStart{}, ctx.Err()
+		}
+		if s.ok && s.first.Type == provider.EventError {
+			go drain(events)
+			if s.first.Error == nil {
+				return streamStart{}, fmt.Errorf("stream error")
+			}
+			return streamStart{}, s.first.Error
+		}
+		s.events = events
+		return s, nil
+	}, func(s streamStart) { drain(s.events) })
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan provider.StreamEvent, 10)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if !start.ok {
+			return
+		}
+		// forward sends ev on; once ctx ends it stops and drains the backend's stream in the background
+		forward := func(ev provider.StreamEvent) bool {
+			if ev.Type == provider.EventDone {
+				done := &provider.StreamDonePayload{}
+				if d, ok := ev.Content.(*provider.StreamDonePayload); ok && d != nil {
+					*done = *d
+				}
+				p.stamp(idx, &done.Provider, &done.Model)
+				ev.Content = done
+			}
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				go drain(start.events)
+				return false
+			}
+		}
+		if !forward(start.first) {
+			return
+		}
+		for ev := range start.events {
+			if !forward(ev) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// stamp fills in the serving backend's name and (if unreported) its model.
+func (p *Provider) stamp(idx int, providerName, model *string) {
+	if *providerName == "" {
+		*providerName = p.backends[idx].Name()
+	}
+	if *model == "" {
+		*model = p.models[idx]
+	}
+}
+
+type outcome[T any] struct {
+	idx int
+	val T
+	err error
+}
+
+// run calls fn on backends in order until one succeeds. Each attempt gets its own context; the
+// winner's cancel func is returned for the caller to release when done with the result, and the
+// others are cancelled. Late successes from losing hedges are passed to discard.
+func run[T any](ctx context.Context, p *Provider, fn func(context.Context, provider.Provider) (T, error), discard func(T)) (T, int, context.CancelFunc, error) {
+	var zero T
+	if len(p.backends) == 0 {
+		return zero, 0, nil, fmt.Errorf("failover: no backends configured")
+	}
+
+	results := make(chan outcome[T], len(p.backends))
+	cancels := make([]context.CancelFunc, len(p.backends))
+	next, inflight := 0, 0
+	launch := func() {
+		i := next
+		next++
+		inflight++
+		actx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		go func() {
+			v, err := fn(actx, p.backends[i])
+			results <- outcome[T]{idx: i, val: v, err: err}
+		}()
+	}
+	// abandon collects the n attempts still running in the background and discards any that succeed.
+	abandon := func(n int) {
+		if n == 0 {
+			return
+		}
+		go func() {
+			for ; n > 0; n-- {
+				if late := <-results; late.err == nil && discard != nil {
+					discard(late.val)
+				}
+			}
+		}()
+	}
+	notify := func(from int, err error, hedge bool) {
+		if p.onFailover == nil {
+			return
+		}
+		info := Info{From: p.label(from), Err: err, Hedge: hedge}
+		if next < len(p.backends) {
+			info.To = p.label(next)
+		}
+		p.onFailover(info)
+	}
+
+	var hedge *time.Timer
+	var hedgeC <-chan time.Time
+	armHedge := func() {
+		if p.hedgeAfter <= 0 || next >= len(p.backends) {
+			hedgeC = nil
+			return
+		}
+		if hedge == nil {
+			hedge = time.NewTimer(p.hedgeAfter)
+		} else {
+			hedge.Reset(p.hedgeAfter)
+		}
+		hedgeC = hedge.C
+	}
+	defer func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}()
+
+	launch()
+	armHedge()
+	var errs []error
+	stopLaunching := false
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				for i, cancel := range cancels {
+					if i != r.idx && cancel != nil {
+						cancel()
+					}
+				}
+				abandon(inflight)
+				return r.val, r.idx, cancels[r.idx], nil
+			}
+			cancels[r.idx]()
+			errs = append(errs, fmt.Errorf("%s: %w", p.label(r.idx), r.err))
+			if !p.shouldFail(r.err) {
+				stopLaunching = true
+			}
+			if !stopLaunching && next < len(p.backends) {
+				notify(r.idx, r.err, false)
+				launch()
+				armHedge()
+			} else if inflight == 0 {
+				if len(errs) == 1 {
+					return zero, r.idx, nil, r.err
+				}
+				return zero, r.idx, nil, &Error{Errs: errs}
+			}
+		case <-hedgeC:
+			if stopLaunching {
+				hedgeC = nil
+				continue
+			}
+			notify(next-1, nil, true)
+			launch()
+			armHedge()
+		case <-ctx.Done():
+			for _, cancel := range cancels {
+				if cancel != nil {
+					cancel()
+				}
+			}
+			abandon(inflight)
+			return zero, 0, nil, ctx.Err()
+		}
+	}
+}
+
+// Error is returned when more than one backend was tried and all of them failed. Errs holds each
+// backend's error, prefixed with "name:model", in the order they failed.
+type Error struct {
+	Errs []error
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "all backends failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap lets errors.Is and errors.As inspect every backend's error.
+func (e *Error) Unwrap() []error {
+	return e.Errs
+}
+
+// label identifies backend idx as "name:model" so backends built by FromModels are distinguishable.
+func (p *Provider) label(idx int) string {
+	if m := p.models[idx]; m != "" {
+		return p.backends[idx].Name() + ":" + m
+	}
+	return p.backends[idx].Name()
+}
+
+func drain(events <-chan provider.StreamEvent) {
+	for range events {
+	}
+}
@@ -0,0 +1,208 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+func TestCompleteFailsOverAndReportsBackend(t *testing.T) {
+	primary := fake.New(fake.Error(&provider.HTTPError{StatusCode: 503})).WithName("primary").WithModel("model-a")
+	backup := fake.New(fake.Text("from backup")).WithName("backup").WithModel("model-b")
+	var infos []Info
+	p := New(primary, backup).OnFailover(func(i Info) { infos = append(infos, i) })
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "from backup" || resp.Model != "model-b" || resp.Provider != "backup" {
+		t.Errorf("expected backup to serve the response, got %+v", resp)
+	}
+	if len(infos) != 1 || infos[0].From != "primary:model-a" || infos[0].To != "backup:model-b" || infos[0].Hedge {
+		t.Errorf("unexpected failover notifications: %+v", infos)
+	}
+}
+
+func TestCompleteDoesNotFailOverBadRequest(t *testing.T) {
+	primary := fake.New(fake.Error(&provider.HTTPError{StatusCode: 400}))
+	backup := fake.New(fake.Text("unused"))
+
+	_, err := New(primary, backup).Complete(context.Background(), provider.CompletionRequest{})
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 400 {
+		t.Fatalf("expected the 400 to be returned, got %v", err)
+	}
+	if backup.Calls() != 0 {
+		t.Errorf("backup should not be called for a bad request")
+	}
+}
+
+func TestCompleteAllBackendsFail(t *testing.T) {
+	p := New(
+		fake.New(fake.Error(&provider.HTTPError{StatusCode: 502})).WithName("a"),
+		fake.New(fake.Error(&provider.HTTPError{StatusCode: 429})).WithName("b"),
+	)
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	var all *Error
+	if !errors.As(err, &all) || len(all.Errs) != 2 {
+		t.Fatalf("expected *Error with both failures, got %v", err)
+	}
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 502 {
+		t.Errorf("expected backend errors to be inspectable, got %v", httpErr)
+	}
+}
+
+func TestCompleteHedgesSlowBackend(t *testing.T) {
+	slow := fake.New(fake.Response{Completion: &provider.CompletionResponse{Text: "slow"}, Delay: time.Second}).WithName("slow")
+	fast := fake.New(fake.Text("fast")).WithName("fast")
+	var infos []Info
+	p := New(slow, fast).WithHedge(10 * time.Millisecond).OnFailover(func(i Info) { infos = append(infos, i) })
+
+	start := time.Now()
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "fast" || resp.Provider != "fast" {
+		t.Errorf("expected hedged backend to win, got %+v", resp)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("hedge did not cut latency: %v", elapsed)
+	}
+	if len(infos) != 1 || !infos[0].Hedge {
+		t.Errorf("expected one hedge notification, got %+v", infos)
+	}
+}
+
+func TestStreamFailsOverBeforeFirstEvent(t *testing.T) {
+	primary := fake.New(fake.Events(provider.StreamEvent{Type: provider.EventError, Error: &provider.HTTPError{StatusCode: 500}})).WithName("primary")
+	backup := fake.New(fake.Text("streamed")).WithName("backup").WithModel("model-b")
+
+	events, err := New(primary, backup).Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if resp.Text != "streamed" || resp.Provider != "backup" || resp.Model != "model-b" {
+		t.Errorf("expected backup stream, got %+v", resp)
+	}
+}
+
+func TestStreamDoesNotFailOverMidStream(t *testing.T) {
+	primary := fake.New(fake.Events(
+		provider.StreamEvent{Type: provider.EventTextDelta, Delta: "partial"},
+		provider.StreamEvent{Type: provider.EventError, Error: &provider.HTTPError{StatusCode: 500}},
+	))
+	backup := fake.New(fake.Text("unused"))
+
+	events, err := New(primary, backup).Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if _, err := provider.Collect(events, nil); err == nil {
+		t.Error("expected the mid-stream error to pass through")
+	}
+	if backup.Calls() != 0 {
+		t.Errorf("backup should not be called once output has started")
+	}
+}
+
+func TestFromModels(t *testing.T) {
+	p := FromModels(func(m string) provider.Provider { return fake.New().WithModel(m) }, "a", "b")
+	if got := p.Models(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Models() = %v", got)
+	}
+}
+
+// catalogBackend reports a whole catalog from Models(), as openaicompat does once it is loaded
+type catalogBackend struct {
+	*fake.Provider
+}
+
+func (catalogBackend) Models() []string {
+	return []string{"other-1", "other-2", "a", "b"}
+}
+
+func TestFromModelsLabelsAndStampsBuiltModel(t *testing.T) {
+	responses := map[string]fake.Response{
+		"a": fake.Error(&provider.HTTPError{StatusCode: 503}),
+		"b": fake.Events( // a stream whose done event reports no model
+			provider.StreamEvent{Type: provider.EventTextDelta, Delta: "from b"},
+			provider.StreamEvent{Type: provider.EventDone, Content: &provider.StreamDonePayload{}},
+		),
+	}
+	var infos []Info
+	p := FromModels(func(m string) provider.Provider {
+		return catalogBackend{fake.New(responses[m]).WithName("router")}
+	}, "a", "b").OnFailover(func(i Info) { infos = append(infos, i) })
+
+	events, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if resp.Model != "b" || resp.Provider != "router" {
+		t.Errorf("expected the response stamped router/b, got %s/%s", resp.Provider, resp.Model)
+	}
+	if len(infos) != 1 || infos[0].From != "router:a" || infos[0].To != "router:b" {
+		t.Errorf("unexpected failover notifications: %+v", infos)
+	}
+}
+
+// chanBackend streams whatever the test sends on events
+type chanBackend struct {
+	*fake.Provider
+	events chan provider.StreamEvent
+}
+
+func (b chanBackend) Stream(context.Context, provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	return b.events, nil
+}
+
+func TestStreamDrainsBackendWhenCancelled(t *testing.T) {
+	backend := chanBackend{fake.New(), make(chan provider.StreamEvent)}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { backend.events <- provider.StreamEvent{Type: provider.EventTextDelta, Delta: "first"} }()
+	if _, err := New(backend).Stream(ctx, provider.CompletionRequest{}); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	cancel() // the stream is abandoned unread
+
+	for i := 0; i < 50; i++ {
+		select {
+		case backend.events <- provider.StreamEvent{Type: provider.EventTextDelta, Delta: "more"}:
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: the backend's stream was not drained after cancellation", i)
+		}
+	}
+	close(backend.events)
+}
+
+func TestCatalogFollowsPrimary(t *testing.T) {
+	primary := fake.New().WithModel("a").WithCatalog(provider.ModelInfo{ID: "a", Vision: true})
+	plain := struct{ provider.Provider }{fake.New().WithModel("b")}
+	p := New(primary, plain, fake.New().WithModel("a"))
+
+	if m, err := p.Capabilities(context.Background()); err != nil || !m.Vision {
+		t.Errorf("expected the primary backend's capabilities, got %+v %v", m, err)
+	}
+	models, err := p.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[0].ID != "a" || !models[0].Vision || models[1].ID != "b" {
+		t.Errorf("expected each backend's models once, got %+v %v", models, err)
+	}
+	if _, err := New(plain).Capabilities(context.Background()); !errors.Is(err, provider.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound without a primary catalog, got %v", err)
+	}
+}
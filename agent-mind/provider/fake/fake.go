@@ -0,0 +1,375 @@
+// Package fake provides a scripted provider.Provider for deterministic tests and offline demos.
+//
+// A Provider holds a queue of Responses. Each Complete or Stream call records the request and
+// consumes the first queued Response whose Match accepts it. When nothing matches, the fallback
+// (if set) answers; otherwise the call fails.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+// Response is one scripted answer.
+type Response struct {
+	// Completion is returned by Complete. For Stream it is replayed as events when Events is nil.
+	Completion *provider.CompletionResponse
+	// Events, when set, is the exact stream sent by Stream (Complete collects it into a response).
+	Events []provider.StreamEvent
+	// Err is returned by the call instead of a response.
+	Err error
+	// Delay is waited before answering (respecting ctx); ChunkDelay is waited between stream events.
+	Delay      time.Duration
+	ChunkDelay time.Duration
+	// Match restricts which requests this response answers. Nil matches any request.
+	Match func(req provider.CompletionRequest) bool
+}
+
+// Provider is a scripted provider.Provider. Safe for concurrent use.
+type Provider struct {
+	mu       sync.Mutex
+	name     string
+	model    string
+	queue    []Response
+	requests []provider.CompletionRequest
+	fallback func(req provider.CompletionRequest) Response
+	catalog  provider.Catalog
+}
+
+// New returns a fake provider that answers with responses in order.
+func New(responses ...Response) *Provider {
+	return &Provider{
+		name:  "fake",
+		model: "fake-model",
+		queue: append([]Response(nil), responses...),
+	}
+}
+
+// WithName sets the name reported by Name(). Returns p for chaining.
+func (p *Provider) WithName(name string) *Provider {
+	p.name = name
+	return p
+}
+
+// WithModel sets the model reported on responses that do not set one. Returns p for chaining.
+func (p *Provider) WithModel(model string) *Provider {
+	p.model = model
+	return p
+}
+
+// WithFallback sets the answer used when no queued response matches. Returns p for chaining.
+func (p *Provider) WithFallback(fn func(req provider.CompletionRequest) Response) *Provider {
+	p.fallback = fn
+	return p
+}
+
+// Enqueue appends responses to the script.
+func (p *Provider) Enqueue(responses ...Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, responses...)
+}
+
+// Requests returns a copy of every request received, in call order.
+func (p *Provider) Requests() []provider.CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]provider.CompletionRequest(nil), p.requests...)
+}
+
+// Calls returns the number of Complete and Stream calls received.
+func (p *Provider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// Remaining returns the number of queued responses not yet consumed.
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// next records req and pops the first matching response. The fallback runs without the lock held,
+// so it may call back into p.
+func (p *Provider) next(req provider.CompletionRequest) (Response, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	n := len(p.requests)
+	for i, r := range p.queue {
+		if r.Match == nil || r.Match(req) {
+			p.queue = append(p.queue[:i:i], p.queue[i+1:]...)
+			p.mu.Unlock()
+			return r, nil
+		}
+	}
+	fallback := p.fallback
+	p.mu.Unlock()
+	if fallback != nil {
+		return fallback(req), nil
+	}
+	return Response{}, fmt.Errorf("fake: no scripted response for request %d", n)
+}
+
+// Complete implements provider.Provider
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	r, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Events != nil {
+		ch := make(chan provider.StreamEvent, len(r.Events))
+		for _, ev := range r.Events {
+			ch <- ev
+		}
+		close(ch)
+		return provider.Collect(ch, nil)
+	}
+	return p.completion(r), nil
+}
+
+// Stream implements provider.Provider
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	r, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	script := r.Events
+	if script == nil {
+		script = EventsFor(p.completion(r))
+	}
+
+	events := make(chan provider.StreamEvent, 10)
+	go func() {
+		defer close(events)
+		for i, ev := range script {
+			if i > 0 {
+				if err := sleep(ctx, r.ChunkDelay); err != nil {
+					select {
+					case events <- provider.StreamEvent{Type: provider.EventError, Error: err}:
+					case <-ctx.Done():
+					}
+					return
+				}
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// WithCatalog describes the provider's models for ListModels and Capabilities (the configured model
+// is looked up by ID). Without one, ListModels lists the configured model by ID alone and Capabilities
+// returns provider.ErrModelNotFound. Returns p for chaining.
+func (p *Provider) WithCatalog(models ...provider.ModelInfo) *Provider {
+	p.catalog = provider.NewCatalog(models)
+	return p
+}
+
+// ListModels implements provider.Cataloger
+func (p *Provider) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	if p.catalog == nil {
+		return []provider.ModelInfo{{ID: p.model}}, nil
+	}
+	return p.catalog.Models(), nil
+}
+
+// Capabilities implements provider.Cataloger
+func (p *Provider) Capabilities(ctx context.Context) (provider.ModelInfo, error) {
+	if m, ok := p.catalog.Lookup(p.model); ok {
+		return m, nil
+	}
+	return provider.ModelInfo{}, fmt.Errorf("fake: %s: %w", p.model, provider.ErrModelNotFound)
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Model returns the model requests are sent to.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// Models implements provider.Provider
+func (p *Provider) Models() []string {
+	return []string{p.model}
+}
+
+// completion returns a copy of r.Completion with the default model filled in.
+func (p *Provider) completion(r Response) *provider.CompletionResponse {
+	out := provider.CompletionResponse{}
+	if r.Completion != nil {
+		out = *r.Completion
+	}
+	if out.Model == "" {
+		out.Model = p.model
+	}
+	return &out
+}
+
+// EventsFor converts a completion into the stream a real provider would send: reasoning and text
+// split into word-sized deltas, one delta and one final event per tool call, then done.
+func EventsFor(resp *provider.CompletionResponse) []provider.StreamEvent {
+	var events []provider.StreamEvent
+	for i, rb := range resp.Reasoning {
+		for _, chunk := range chunks(rb.Text) {
+			events = append(events, provider.StreamEvent{
+				Type:    provider.EventReasoningDelta,
+				Delta:   chunk,
+				Content: &provider.ReasoningStreamPayload{Index: i},
+			})
+		}
+		if rb.Signature != "" {
+			events = append(events, provider.StreamEvent{
+				Type:    provider.EventReasoningDelta,
+				Content: &provider.ReasoningStreamPayload{Index: i, Signature: rb.Signature},
+			})
+		}
+	}
+	for _, chunk := range chunks(resp.Text) {
+		events = append(events, provider.StreamEvent{Type: provider.EventTextDelta, Delta: chunk})
+	}
+	for i := range resp.ToolCalls {
+		tc := resp.ToolCalls[i]
+		events = append(events,
+			provider.StreamEvent{
+				Type:    provider.EventToolDelta,
+				Content: &provider.ToolCallStreamPayload{Index: i, ID: tc.ID, Name: tc.Name, Arguments: tc.Arguments},
+			},
+			provider.StreamEvent{Type: provider.EventToolCall, Content: &tc},
+		)
+	}
+	events = append(events, provider.StreamEvent{
+		Type:    provider.EventDone,
+		Content: &provider.StreamDonePayload{Model: resp.Model, Provider: resp.Provider, Usage: resp.Usage, FinishReason: resp.FinishReason},
+	})
+	return events
+}
+
+// chunks splits s into word-sized pieces that concatenate back to s.
+func chunks(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for len(s) > 0 {
+		i := strings.IndexByte(s, ' ')
+		if i < 0 {
+			out = append(out, s)
+			break
+		}
+		out = append(out, s[:i+1])
+		s = s[i+1:]
+	}
+	return out
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// --- Response helpers ---
+
+// Text returns a response with the given answer text.
+func Text(text string) Response {
+	return Response{Completion: &provider.CompletionResponse{Text: text}}
+}
+
+// ToolCalls returns a response requesting the given tool calls.
+func ToolCalls(calls ...provider.ToolCallResponse) Response {
+	return Response{Completion: &provider.CompletionResponse{ToolCalls: calls}}
+}
+
+// ToolCall returns a single tool call (for use with ToolCalls).
+func ToolCall(id, name string, args map[string]interface{}) provider.ToolCallResponse {
+	return provider.ToolCallResponse{ID: id, Name: name, Arguments: args}
+}
+
+// Error returns a response that fails the call with err.
+func Error(err error) Response {
+	return Response{Err: err}
+}
+
+// Events returns a response that streams exactly the given events.
+func Events(events ...provider.StreamEvent) Response {
+	return Response{Events: events}
+}
+
+// --- Matchers ---
+
+// LastUserContains matches requests whose last user message contains substr.
+func LastUserContains(substr string) func(provider.CompletionRequest) bool {
+	return func(req provider.CompletionRequest) bool {
+		return strings.Contains(LastUserText(req), substr)
+	}
+}
+
+// SystemContains matches requests whose system prompt contains substr.
+func SystemContains(substr string) func(provider.CompletionRequest) bool {
+	return func(req provider.CompletionRequest) bool {
+		return strings.Contains(req.SystemPrompt, substr)
+	}
+}
+
+// HasToolResult matches requests that include a tool result for the named tool.
+func HasToolResult(toolName string) func(provider.CompletionRequest) bool {
+	return func(req provider.CompletionRequest) bool {
+		for _, m := range req.Messages {
+			if tr, ok := m.(types.ToolResultMessage); ok && tr.ToolName == toolName {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LastUserText returns the text of the last user message in req (empty if none).
+func LastUserText(req provider.CompletionRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if um, ok := req.Messages[i].(types.UserMessage); ok {
+			var text string
+			for _, block := range um.Content {
+				if tc, ok := block.(types.TextContent); ok {
+					text += tc.Text
+				}
+			}
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+func userRequest(text string) provider.CompletionRequest {
+	return provider.CompletionRequest{
+		Messages: []types.Message{types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: text}}}},
+	}
+}
+
+func TestQueueOrderAndRecording(t *testing.T) {
+	p := New(Text("first"), ToolCalls(ToolCall("c1", "calculator", map[string]interface{}{"expression": "1+1"})))
+
+	resp, err := p.Complete(context.Background(), userRequest("a"))
+	if err != nil || resp.Text != "first" || resp.Model != "fake-model" {
+		t.Fatalf("unexpected first response %+v, %v", resp, err)
+	}
+	resp, err = p.Complete(context.Background(), userRequest("b"))
+	if err != nil || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "calculator" {
+		t.Fatalf("unexpected second response %+v, %v", resp, err)
+	}
+	if _, err := p.Complete(context.Background(), userRequest("c")); err == nil {
+		t.Error("expected error when script is exhausted")
+	}
+
+	reqs := p.Requests()
+	if len(reqs) != 3 || p.Calls() != 3 {
+		t.Fatalf("expected 3 recorded requests, got %d", len(reqs))
+	}
+	if LastUserText(reqs[1]) != "b" {
+		t.Errorf("unexpected recorded request: %+v", reqs[1])
+	}
+}
+
+func TestMatchAndFallback(t *testing.T) {
+	p := New(
+		Response{Completion: &provider.CompletionResponse{Text: "weather"}, Match: LastUserContains("weather")},
+		Text("anything"),
+	).WithFallback(func(req provider.CompletionRequest) Response {
+		return Text("echo: " + LastUserText(req))
+	})
+
+	resp, _ := p.Complete(context.Background(), userRequest("what's the weather?"))
+	if resp.Text != "weather" {
+		t.Errorf("expected matching response, got %q", resp.Text)
+	}
+	resp, _ = p.Complete(context.Background(), userRequest("hi"))
+	if resp.Text != "anything" {
+		t.Errorf("expected unconditional response, got %q", resp.Text)
+	}
+	resp, _ = p.Complete(context.Background(), userRequest("hello"))
+	if resp.Text != "echo: hello" {
+		t.Errorf("expected fallback, got %q", resp.Text)
+	}
+
+	// the fallback may call back into the provider
+	var calls int
+	p.WithFallback(func(req provider.CompletionRequest) Response {
+		calls = p.Calls()
+		return Text("counted")
+	})
+	if resp, err := p.Complete(context.Background(), userRequest("again")); err != nil || resp.Text != "counted" || calls != 4 {
+		t.Errorf("expected the fallback to see 4 calls, got %v %v %d", resp, err, calls)
+	}
+}
+
+func TestStreamFromCompletion(t *testing.T) {
+	p := New(Response{Completion: &provider.CompletionResponse{
+		Text:      "The answer is 4",
+		ToolCalls: []provider.ToolCallResponse{ToolCall("c1", "calculator", map[string]interface{}{"expression": "2+2"})},
+		Usage:     provider.UsageInfo{TotalTokens: 7},
+	}})
+
+	events, err := p.Stream(context.Background(), userRequest("x"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var textDeltas int
+	resp, err := provider.Collect(events, func(ev provider.StreamEvent) {
+		if ev.Type == provider.EventTextDelta {
+			textDeltas++
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if textDeltas != 4 || resp.Text != "The answer is 4" {
+		t.Errorf("expected 4 word deltas forming the text, got %d %q", textDeltas, resp.Text)
+	}
+	if len(resp.ToolCalls) != 1 || resp.Usage.TotalTokens != 7 || resp.Model != "fake-model" {
+		t.Errorf("unexpected collected response: %+v", resp)
+	}
+}
+
+func TestErrorsAndLatency(t *testing.T) {
+	boom := errors.New("boom")
+	p := New(Error(boom), Response{Completion: &provider.CompletionResponse{Text: "slow"}, Delay: time.Second})
+
+	if _, err := p.Stream(context.Background(), userRequest("x")); !errors.Is(err, boom) {
+		t.Errorf("expected scripted error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Complete(ctx, userRequest("x")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded during delay, got %v", err)
+	}
+}
+
+func TestScriptedEvents(t *testing.T) {
+	p := New(Events(
+		provider.StreamEvent{Type: provider.EventTextDelta, Delta: "partial"},
+		provider.StreamEvent{Type: provider.EventError, Error: errors.New("mid-stream")},
+	))
+	events, err := p.Stream(context.Background(), userRequest("x"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if _, err := provider.Collect(events, nil); err == nil || err.Error() != "mid-stream" {
+		t.Errorf("expected mid-stream error, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// Middleware adds behavior around a provider's calls, such as logging, retries, caching, rate limiting
+// or redaction. It returns a Provider wrapping next; write one with Intercept to handle Complete and
+// Stream alike.
+type Middleware func(next Provider) Provider
+
+// Chain wraps p with mws. The first middleware is the outermost: it sees each request first and each
+// response last.
+func Chain(p Provider, mws ...Middleware) Provider {
+	for i := len(mws) - 1; i >= 0; i-- {
+		p = mws[i](p)
+	}
+	return p
+}
+
+// Call describes one call passing through an Intercept middleware.
+type Call struct {
+	// Stream is true for Stream and false for Complete.
+	Stream bool
+	// Request is the request passed on to the wrapped provider; Before may change it.
+	Request CompletionRequest
+	// Start is when the call entered the middleware.
+	Start time.Time
+}
+
+// Hooks define a middleware once for both call shapes. Every hook is optional.
+type Hooks struct {
+	// Before runs before the wrapped provider is called and may change call.Request. An error fails
+	// the call without calling the provider (and without calling After).
+	Before func(ctx context.Context, call *Call) error
+	// Event runs on each streamed event before it is forwarded and returns the event to forward. An
+	// event with an empty Type is dropped. Complete responses do not pass through Event.
+	Event func(ctx context.Context, call *Call, ev StreamEvent) StreamEvent
+	// After runs once when a call ends, with its response or error, and returns the response and error
+	// to report. For a stream the response is collected from the forwarded events and only the error
+	// is used: an error replaces the final EventDone (or the EventError's error) with an EventError.
+	After func(ctx context.Context, call *Call, resp *CompletionResponse, err error) (*CompletionResponse, error)
+}
+
+// Intercept returns a middleware that runs h around every Complete and Stream call. Streams must be
+// drained, or their context ended, for After to run; a stream whose context ends gets After with the
+// context's error. The result is a Cataloger only when next is one.
+func Intercept(h Hooks) Middleware {
+	return func(next Provider) Provider {
+		p := &interceptProvider{Provider: next, hooks: h}
+		if c, ok := next.(Cataloger); ok {
+			return &interceptCatalogProvider{interceptProvider: p, Cataloger: c}
+		}
+		return p
+	}
+}
+
+type interceptProvider struct {
+	Provider
+	hooks Hooks
+}
+
+// interceptCatalogProvider is an interceptProvider whose wrapped provider is a Cataloger; catalog
+// calls are forwarded without passing through the hooks
+type interceptCatalogProvider struct {
+	*interceptProvider
+	Cataloger
+}
+
+func (p *interceptProvider) begin(ctx context.Context, req CompletionRequest, stream bool) (*Call, error) {
+	call := &Call{Stream: stream, Request: req, Start: time.Now()}
+	if p.hooks.Before != nil {
+		if err := p.hooks.Before(ctx, call); err != nil {
+			return nil, err
+		}
+	}
+	return call, nil
+}
+
+func (p *interceptProvider) after(ctx context.Context, call *Call, resp *CompletionResponse, err error) (*CompletionResponse, error) {
+	if p.hooks.After == nil {
+		return resp, err
+	}
+	return p.hooks.After(ctx, call, resp, err)
+}
+
+func (p *interceptProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	call, err := p.begin(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Complete(ctx, call.Request)
+	return p.after(ctx, call, resp, err)
+}
+
+func (p *interceptProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamEvent, error) {
+	call, err := p.begin(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	events, err := p.Provider.Stream(ctx, call.Request)
+	if err != nil {
+		if _, hookErr := p.after(ctx, call, nil, err); hookErr != nil {
+			err = hookErr
+		}
+		return nil, err
+	}
+	out := make(chan StreamEvent, 10)
+	go func() {
+		defer close(out)
+		c := newCollector()
+		ended := false
+		// abandon ends a stream whose context is done: After runs with the context's error (unless it
+		// already ran) and the wrapped stream is drained in the background.
+		abandon := func() {
+			if !ended {
+				p.after(ctx, call, nil, ctx.Err())
+			}
+			go func() {
+				for range events {
+				}
+			}()
+		}
+		for {
+			var ev StreamEvent
+			var ok bool
+			select {
+			case ev, ok = <-events:
+			case <-ctx.Done():
+				abandon()
+				return
+			}
+			if !ok {
+				break
+			}
+			if p.hooks.Event != nil {
+				if ev = p.hooks.Event(ctx, call, ev); ev.Type == "" {
+					continue
+				}
+			}
+			if !ended {
+				c.add(ev)
+				switch ev.Type {
+				case EventDone:
+					ended = true
+					if _, err := p.after(ctx, call, c.resp, nil); err != nil {
+						ev = StreamEvent{Type: EventError, Error: err}
+					}
+				case EventError:
+					ended = true
+					if _, err := p.after(ctx, call, nil, ev.Error); err != nil {
+						ev.Error = err
+					}
+				}
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				abandon()
+				return
+			}
+		}
+		if !ended {
+			if _, err := p.after(ctx, call, c.resp, nil); err != nil {
+				select {
+				case out <- StreamEvent{Type: EventError, Error: err}:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Retry is WithRetry as a middleware.
+func Retry(policy RetryPolicy) Middleware {
+	return func(next Provider) Provider {
+		return WithRetry(next, policy)
+	}
+}
+
+// Validation is WithValidation as a middleware.
+func Validation() Middleware {
+	return WithValidation
+}
+
+// PromptTools is WithPromptTools as a middleware.
+func PromptTools() Middleware {
+	return WithPromptTools
+}
+
+// RewriteRequest returns a middleware that passes every request through fn, e.g. to redact secrets
+// from messages or to fill in defaults.
+func RewriteRequest(fn func(CompletionRequest) CompletionRequest) Middleware {
+	return Intercept(Hooks{
+		Before: func(_ context.Context, call *Call) error {
+			call.Request = fn(call.Request)
+			return nil
+		},
+	})
+}
+
+// Observe returns a middleware that reports every finished call to fn, e.g. for logging or metrics.
+// resp is nil when err is set; for a stream it is collected from the events.
+func Observe(fn func(call *Call, resp *CompletionResponse, err error)) Middleware {
+	return Intercept(Hooks{
+		After: func(_ context.Context, call *Call, resp *CompletionResponse, err error) (*CompletionResponse, error) {
+			fn(call, resp, err)
+			return resp, err
+		},
+	})
+}
+
+// MaxConcurrent returns a middleware that allows at most n calls in flight at once; a stream counts
+// until it ends or its context does. Callers over the limit wait, or fail with the context's error.
+// n <= 0 means no limit.
+func MaxConcurrent(n int) Middleware {
+	if n <= 0 {
+		return func(next Provider) Provider { return next }
+	}
+	slots := make(chan struct{}, n)
+	return Intercept(Hooks{
+		Before: func(ctx context.Context, _ *Call) error {
+			select {
+			case slots <- struct{}{}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		After: func(_ context.Context, _ *Call, resp *CompletionResponse, err error) (*CompletionResponse, error) {
+			<-slots
+			return resp, err
+		},
+	})
+}
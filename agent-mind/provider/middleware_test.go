@@ -0,0 +1,153 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+func appendSystem(s string) provider.Middleware {
+	return provider.RewriteRequest(func(req provider.CompletionRequest) provider.CompletionRequest {
+		req.SystemPrompt += s
+		return req
+	})
+}
+
+func TestChainOrder(t *testing.T) {
+	inner := fake.New(fake.Text("ok"))
+	p := provider.Chain(inner, appendSystem("a"), appendSystem("b"))
+	if _, err := p.Complete(context.Background(), provider.CompletionRequest{SystemPrompt: ">"}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if got := inner.Requests()[0].SystemPrompt; got != ">ab" {
+		t.Errorf("expected the first middleware to run first, got %q", got)
+	}
+	if provider.Chain(inner) != provider.Provider(inner) {
+		t.Error("expected Chain without middlewares to return the provider")
+	}
+}
+
+func TestInterceptStream(t *testing.T) {
+	errRejected := errors.New("rejected")
+	var seen string
+	p := provider.Chain(fake.New(fake.Text("hello world")), provider.Intercept(provider.Hooks{
+		Event: func(_ context.Context, _ *provider.Call, ev provider.StreamEvent) provider.StreamEvent {
+			ev.Delta = strings.ToUpper(ev.Delta)
+			return ev
+		},
+		After: func(_ context.Context, call *provider.Call, resp *provider.CompletionResponse, err error) (*provider.CompletionResponse, error) {
+			if !call.Stream || err != nil {
+				t.Errorf("unexpected call %+v, err %v", call, err)
+			}
+			seen = resp.Text
+			return resp, errRejected
+		},
+	}))
+
+	stream, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var text string
+	_, err = provider.Collect(stream, func(ev provider.StreamEvent) { text += ev.Delta })
+	if !errors.Is(err, errRejected) {
+		t.Errorf("expected After's error to end the stream, got %v", err)
+	}
+	if text != "HELLO WORLD" || seen != "HELLO WORLD" {
+		t.Errorf("expected rewritten events forwarded and collected, got %q and %q", text, seen)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	type observed struct {
+		stream bool
+		text   string
+		err    error
+	}
+	var calls []observed
+	boom := errors.New("boom")
+	p := provider.Chain(fake.New(fake.Text("one"), fake.Text("two"), fake.Error(boom)),
+		provider.Observe(func(call *provider.Call, resp *provider.CompletionResponse, err error) {
+			o := observed{stream: call.Stream, err: err}
+			if resp != nil {
+				o.text = resp.Text
+			}
+			calls = append(calls, o)
+		}))
+
+	ctx := context.Background()
+	p.Complete(ctx, provider.CompletionRequest{})
+	stream, _ := p.Stream(ctx, provider.CompletionRequest{})
+	provider.Collect(stream, nil)
+	p.Complete(ctx, provider.CompletionRequest{})
+
+	want := []observed{{false, "one", nil}, {true, "two", nil}, {false, "", boom}}
+	if len(calls) != len(want) {
+		t.Fatalf("expected %d observed calls, got %+v", len(want), calls)
+	}
+	for i := range want {
+		if calls[i].stream != want[i].stream || calls[i].text != want[i].text || !errors.Is(calls[i].err, want[i].err) {
+			t.Errorf("call %d: got %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	p := provider.Chain(fake.New(fake.Text("a"), fake.Text("b"), fake.Text("c")), provider.MaxConcurrent(1))
+
+	stream, err := p.Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Complete(ctx, provider.CompletionRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the second call to wait for the open stream, got %v", err)
+	}
+
+	provider.Collect(stream, nil)
+	if resp, err := p.Complete(context.Background(), provider.CompletionRequest{}); err != nil || resp.Text != "b" {
+		t.Errorf("expected the slot freed once the stream ended, got %v %v", resp, err)
+	}
+}
+
+func TestMaxConcurrentReleasesCancelledStream(t *testing.T) {
+	long := fake.Text(strings.Repeat("word ", 50)) // more events than the stream buffers
+	p := provider.Chain(fake.New(long, fake.Text("next")), provider.MaxConcurrent(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := p.Stream(ctx, provider.CompletionRequest{}); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	cancel() // the stream is abandoned unread
+
+	waitCtx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if resp, err := p.Complete(waitCtx, provider.CompletionRequest{}); err != nil || resp.Text != "next" {
+		t.Errorf("expected the slot freed once the stream's context ended, got %v %v", resp, err)
+	}
+
+	unlimited := provider.Chain(fake.New(fake.Text("a")), provider.MaxConcurrent(0))
+	if _, err := unlimited.Complete(waitCtx, provider.CompletionRequest{}); err != nil {
+		t.Errorf("expected MaxConcurrent(0) to allow calls, got %v", err)
+	}
+}
+
+func TestInterceptKeepsCatalog(t *testing.T) {
+	inner := fake.New().WithModel("m").WithCatalog(provider.ModelInfo{ID: "m", Vision: true})
+	p := provider.Chain(inner, provider.Observe(func(*provider.Call, *provider.CompletionResponse, error) {}))
+	m, err := p.(provider.Cataloger).Capabilities(context.Background())
+	if err != nil || !m.Vision {
+		t.Errorf("expected capabilities from the wrapped provider, got %+v %v", m, err)
+	}
+
+	plain := provider.Chain(struct{ provider.Provider }{inner}, provider.MaxConcurrent(1))
+	if _, ok := plain.(provider.Cataloger); ok {
+		t.Error("expected no Cataloger when the wrapped provider has no catalog")
+	}
+}
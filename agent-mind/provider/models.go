@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ModelInfo describes a model: its limits, what it accepts and what it costs.
+type ModelInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// ContextWindow is the maximum prompt plus completion tokens (0 = unknown).
+	ContextWindow int `json:"context_window,omitempty"`
+	// MaxOutputTokens is the maximum completion tokens (0 = unknown).
+	MaxOutputTokens  int         `json:"max_output_tokens,omitempty"`
+	Tools            bool        `json:"tools"`
+	Vision           bool        `json:"vision"`
+	Reasoning        bool        `json:"reasoning"`
+	StructuredOutput bool        `json:"structured_output"`
+	Pricing          *ModelPrice `json:"pricing,omitempty"`
+}
+
+// ErrModelNotFound is returned (wrapped) when a catalog has no entry for a model.
+var ErrModelNotFound = errors.New("model not found in catalog")
+
+// ErrToolsNotSupported is returned (wrapped) when tools are sent to a model that cannot call them.
+var ErrToolsNotSupported = errors.New("model does not support tool calling")
+
+// Cataloger is implemented by providers that can describe their models.
+type Cataloger interface {
+	// ListModels returns the models the provider can serve.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+	// Capabilities describes the model requests are sent to; ErrModelNotFound when it is not listed.
+	Capabilities(ctx context.Context) (ModelInfo, error)
+}
+
+// Catalog is a set of model descriptions keyed by ID. It is also a Pricer.
+type Catalog map[string]ModelInfo
+
+// NewCatalog builds a catalog from a list of models.
+func NewCatalog(models []ModelInfo) Catalog {
+	c := make(Catalog, len(models))
+	for _, m := range models {
+		c[m.ID] = m
+	}
+	return c
+}
+
+// LoadCatalog reads a catalog from a JSON file holding a list of ModelInfo.
+func LoadCatalog(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var models []ModelInfo
+	if err := json.Unmarshal(data, &models); err != nil {
+		return nil, fmt.Errorf("parse model catalog %s: %w", path, err)
+	}
+	return NewCatalog(models), nil
+}
+
+// Lookup finds a model. IDs are matched exactly first, then without a vendor prefix, then a bare ID
+// against vendor-prefixed entries; a dated snapshot falls back to its undated model or alias (like
+// PriceTable).
+func (c Catalog) Lookup(model string) (ModelInfo, bool) {
+	return lookupModel(c, model)
+}
+
+// Price implements Pricer with the catalog's pricing.
+func (c Catalog) Price(model string) (ModelPrice, bool) {
+	m, ok := c.Lookup(model)
+	if !ok || m.Pricing == nil {
+		return ModelPrice{}, false
+	}
+	return *m.Pricing, true
+}
+
+// Merge returns a copy of c with the entries of overrides replacing (or adding to) its own.
+// Entries are replaced whole, so an override must describe the model completely.
+func (c Catalog) Merge(overrides Catalog) Catalog {
+	out := make(Catalog, len(c)+len(overrides))
+	for id, m := range c {
+		out[id] = m
+	}
+	for id, m := range overrides {
+		out[id] = m
+	}
+	return out
+}
+
+// Models returns the catalog's entries sorted by ID.
+func (c Catalog) Models() []ModelInfo {
+	out := make([]ModelInfo, 0, len(c))
+	for _, m := range c {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// CheckRequest reports whether the model can serve req: tools need tool support (unless the tool
+// choice disables them) and images need vision. Errors wrap ErrToolsNotSupported or ErrImagesNotSupported.
+func (m ModelInfo) CheckRequest(req CompletionRequest) error {
+	if len(req.Tools) > 0 && req.ToolChoice.AllowsTools() && !m.Tools {
+		return fmt.Errorf("%s: %w", m.ID, ErrToolsNotSupported)
+	}
+	if !m.Vision && req.HasImages() {
+		return fmt.Errorf("%s: %w", m.ID, ErrImagesNotSupported)
+	}
+	return nil
+}
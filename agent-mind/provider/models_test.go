@@ -0,0 +1,78 @@
+package provider_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+)
+
+func TestCatalogLookupMergeAndPrice(t *testing.T) {
+	cat := provider.NewCatalog([]provider.ModelInfo{
+		{ID: "gpt-4o", Tools: true, Pricing: &provider.ModelPrice{Input: 2.5, Output: 10}},
+		{ID: "vendor/text-only"},
+	})
+	if m, ok := cat.Lookup("openai/gpt-4o"); !ok || !m.Tools {
+		t.Errorf("expected vendor-prefixed lookup to fall back, got %+v, %v", m, ok)
+	}
+	if p, ok := cat.Price("gpt-4o"); !ok || p.Output != 10 {
+		t.Errorf("Price() = %+v, %v", p, ok)
+	}
+	if _, ok := cat.Lookup("text-only"); !ok {
+		t.Error("expected a bare ID to find its vendor-prefixed entry")
+	}
+	if p, ok := cat.Price("gpt-4o-2024-08-06"); !ok || p.Output != 10 {
+		t.Errorf("expected a dated snapshot to fall back to its model, got %+v, %v", p, ok)
+	}
+	if _, ok := cat.Price("vendor/text-only"); ok {
+		t.Error("models without pricing must not be priced")
+	}
+
+	merged := cat.Merge(provider.NewCatalog([]provider.ModelInfo{{ID: "vendor/text-only", Vision: true}}))
+	if m, _ := merged.Lookup("vendor/text-only"); !m.Vision {
+		t.Error("override should replace the entry")
+	}
+	if m, _ := cat.Lookup("vendor/text-only"); m.Vision {
+		t.Error("Merge must not modify the receiver")
+	}
+	if got := merged.Models(); len(got) != 2 || got[0].ID != "gpt-4o" {
+		t.Errorf("Models() = %+v", got)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(path, []byte(`[{"id":"local/model","tools":true,"context_window":32000}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cat, err := provider.LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	if m, ok := cat.Lookup("local/model"); !ok || m.ContextWindow != 32000 {
+		t.Errorf("unexpected entry %+v", m)
+	}
+}
+
+func TestModelInfoCheckRequest(t *testing.T) {
+	textOnly := provider.ModelInfo{ID: "text-only"}
+	tools := []provider.Tool{{Name: "search"}}
+	if err := textOnly.CheckRequest(provider.CompletionRequest{Tools: tools}); !errors.Is(err, provider.ErrToolsNotSupported) {
+		t.Errorf("expected ErrToolsNotSupported, got %v", err)
+	}
+	if err := textOnly.CheckRequest(provider.CompletionRequest{Tools: tools, ToolChoice: provider.DisableTools()}); err != nil {
+		t.Errorf("disabled tools should pass, got %v", err)
+	}
+	withImage := provider.CompletionRequest{Messages: []types.Message{
+		types.UserMessage{Content: []types.ContentBlock{types.ImageContent{Data: "https://example.com/a.png"}}},
+	}}
+	if err := textOnly.CheckRequest(withImage); !errors.Is(err, provider.ErrImagesNotSupported) {
+		t.Errorf("expected ErrImagesNotSupported, got %v", err)
+	}
+	if err := (provider.ModelInfo{Vision: true}).CheckRequest(withImage); err != nil {
+		t.Errorf("vision model should pass, got %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package provider
+
+import "encoding/json"
+
+// PartialJSON accumulates a JSON object or array that arrives in pieces, such as tool-call arguments
+// streamed a few characters at a time, and decodes the best available value at any point. Open
+// strings, arrays and objects are closed; a member whose key or value is cut off is left out; a
+// truncated true, false or null is completed and a number cut after "-", "." or an exponent is
+// trimmed. Each piece is scanned once, when it is appended.
+//
+// The zero value is ready to use. A PartialJSON is not safe for concurrent use.
+type PartialJSON struct {
+	buf     []byte
+	stack   []jsonFrame
+	scalar  byte // scalar being read: '"' (string), '0' (number), 't' (true, false or null), or 0
+	start   int  // offset where the scalar began
+	escape  int  // offset of the backslash of an unfinished escape in a string, or 0
+	end     int  // offset after the top-level value once it is complete
+	invalid bool // the input is not JSON; only the raw text is decoded
+	last    map[string]interface{}
+}
+
+// jsonFrame is an open object or array
+type jsonFrame struct {
+	close byte // '}' or ']'
+	state jsonState
+	keep  int // offset after the last complete member; a cut-off member is dropped back to here
+}
+
+type jsonState int
+
+const (
+	jsonExpectKey    jsonState = iota // after '{' or ',' in an object
+	jsonReadingKey                    // inside a key string
+	jsonExpectColon                   // after a key
+	jsonExpectValue                   // after ':' in an object, after '[' or ',' in an array
+	jsonReadingValue                  // inside a scalar or a nested object or array
+	jsonAfterValue                    // after a complete member
+)
+
+// Append adds the next piece of input.
+func (p *PartialJSON) Append(s string) {
+	from := len(p.buf)
+	p.buf = append(p.buf, s...)
+	for i := from; i < len(p.buf); i++ {
+		if p.end > 0 || p.invalid {
+			return
+		}
+		p.scan(i)
+	}
+}
+
+// String returns the input appended so far.
+func (p *PartialJSON) String() string {
+	return string(p.buf)
+}
+
+// Complete returns the input completed into a JSON document. Input that is not JSON is returned as is.
+func (p *PartialJSON) Complete() string {
+	if p.end > 0 {
+		return string(p.buf[:p.end])
+	}
+	if p.invalid || len(p.stack) == 0 {
+		return string(p.buf)
+	}
+	out := make([]byte, len(p.buf), len(p.buf)+len(p.stack)+4)
+	copy(out, p.buf)
+	f := p.stack[len(p.stack)-1]
+	switch {
+	case p.scalar == '"' && f.state == jsonReadingValue:
+		if p.escape > 0 {
+			out = out[:p.escape]
+		}
+		out = append(out, '"')
+	case p.scalar == '0':
+		n := len(out)
+		for n > p.start && isNumberSuffix(out[n-1]) {
+			n--
+		}
+		if n == p.start {
+			n = f.keep
+		}
+		out = out[:n]
+	case p.scalar == 't':
+		word, completed := string(out[p.start:]), false
+		for _, lit := range []string{"true", "false", "null"} {
+			if len(word) <= len(lit) && lit[:len(word)] == word {
+				out, completed = append(out, lit[len(word):]...), true
+				break
+			}
+		}
+		if !completed {
+			out = out[:f.keep]
+		}
+	case f.state != jsonAfterValue:
+		// A key, colon or value is missing, or a comma was the last thing read
+		out = out[:f.keep]
+	}
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		out = append(out, p.stack[i].close)
+	}
+	return string(out)
+}
+
+// Object decodes the completed input as an object. While it does not decode (no input yet, or input
+// that is not an object) the last object that did is returned; it never returns nil.
+func (p *PartialJSON) Object() map[string]interface{} {
+	var out map[string]interface{}
+	if json.Unmarshal([]byte(p.Complete()), &out) == nil && out != nil {
+		p.last = out
+	}
+	if p.last == nil {
+		p.last = map[string]interface{}{}
+	}
+	return p.last
+}
+
+// ParsePartialJSON decodes a possibly truncated JSON object as PartialJSON does. It returns an empty
+// map, never nil, when nothing decodes.
+func ParsePartialJSON(s string) map[string]interface{} {
+	var p PartialJSON
+	p.Append(s)
+	return p.Object()
+}
+
+// CompleteJSON completes a possibly truncated JSON object or array as PartialJSON does.
+func CompleteJSON(s string) string {
+	var p PartialJSON
+	p.Append(s)
+	return p.Complete()
+}
+
+// scan advances the parser over p.buf[i]
+func (p *PartialJSON) scan(i int) {
+	c := p.buf[i]
+	switch p.scalar {
+	case '"':
+		p.scanString(i, c)
+		return
+	case '0':
+		if isNumberByte(c) {
+			return
+		}
+		p.endValue(i)
+	case 't':
+		if c >= 'a' && c <= 'z' {
+			return
+		}
+		p.endValue(i)
+	}
+	if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+		return
+	}
+	if len(p.stack) == 0 {
+		if c == '{' || c == '[' {
+			p.push(c, i)
+		} else {
+			p.invalid = true
+		}
+		return
+	}
+
+	f := &p.stack[len(p.stack)-1]
+	switch f.state {
+	case jsonExpectKey:
+		switch c {
+		case '"':
+			p.scalar, p.start, f.state = '"', i, jsonReadingKey
+		case '}':
+			p.pop(i)
+		default:
+			p.invalid = true
+		}
+	case jsonExpectColon:
+		if c == ':' {
+			f.state = jsonExpectValue
+		} else {
+			p.invalid = true
+		}
+	case jsonExpectValue:
+		switch {
+		case c == '{' || c == '[':
+			f.state = jsonReadingValue
+			p.push(c, i)
+		case c == '"':
+			p.scalar, p.start, f.state = '"', i, jsonReadingValue
+		case c == '-' || c >= '0' && c <= '9':
+			p.scalar, p.start, f.state = '0', i, jsonReadingValue
+		case c == 't' || c == 'f' || c == 'n':
+			p.scalar, p.start, f.state = 't', i, jsonReadingValue
+		case c == ']' && f.close == ']':
+			p.pop(i)
+		default:
+			p.invalid = true
+		}
+	case jsonAfterValue:
+		switch {
+		case c == ',' && f.close == '}':
+			f.state = jsonExpectKey
+		case c == ',':
+			f.state = jsonExpectValue
+		case c == f.close:
+			p.pop(i)
+		default:
+			p.invalid = true
+		}
+	default:
+		p.invalid = true
+	}
+}
+
+// scanString advances over a byte inside a string
+func (p *PartialJSON) scanString(i int, c byte) {
+	switch {
+	case p.escape > 0:
+		// \uXXXX runs four bytes past the u; every other escape is one byte
+		if p.buf[p.escape+1] != 'u' || i == p.escape+5 {
+			p.escape = 0
+		}
+	case c == '\\':
+		p.escape = i
+	case c == '"':
+		f := &p.stack[len(p.stack)-1]
+		if f.state == jsonReadingKey {
+			p.scalar, f.state = 0, jsonExpectColon
+		} else {
+			p.endValue(i + 1)
+		}
+	}
+}
+
+// push opens an object or array at offset i
+func (p *PartialJSON) push(c byte, i int) {
+	f := jsonFrame{close: ']', state: jsonExpectValue, keep: i + 1}
+	if c == '{' {
+		f.close, f.state = '}', jsonExpectKey
+	}
+	p.stack = append(p.stack, f)
+}
+
+// pop closes the innermost object or array at offset i
+func (p *PartialJSON) pop(i int) {
+	p.stack = p.stack[:len(p.stack)-1]
+	if len(p.stack) == 0 {
+		p.end = i + 1
+		return
+	}
+	p.endValue(i + 1)
+}
+
+// endValue records that the innermost container's current value ends before offset end
+func (p *PartialJSON) endValue(end int) {
+	p.scalar = 0
+	f := &p.stack[len(p.stack)-1]
+	f.state, f.keep = jsonAfterValue, end
+}
+
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || isNumberSuffix(c)
+}
+
+// isNumberSuffix reports whether c cannot end a number
+func isNumberSuffix(c byte) bool {
+	return c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
@@ -0,0 +1,98 @@
+package provider_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantKeys int
+	}{
+		{"empty", "", 0},
+		{"whitespace", "  \n  ", 0},
+		{"valid object", `{"a":1,"b":"x"}`, 2},
+		{"partial JSON", `{"a":1`, 1},
+		{"invalid", `not json`, 0},
+		{"empty object", `{}`, 0},
+		{"array", `[1,2`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := provider.ParsePartialJSON(tt.input)
+			if got == nil {
+				t.Fatal("ParsePartialJSON must not return nil")
+			}
+			if len(got) != tt.wantKeys {
+				t.Errorf("ParsePartialJSON(%q) keys = %d, want %d", tt.input, len(got), tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCompleteJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"a":"hel`, `{"a":"hel"}`},
+		{`{"a":1,`, `{"a":1}`},
+		{`{"a":1,"b`, `{"a":1}`},
+		{`{"a":1,"b":`, `{"a":1}`},
+		{`{"a":[1,2,`, `{"a":[1,2]}`},
+		{`{"a":[{"b":tr`, `{"a":[{"b":true}]}`},
+		{`{"a":nu`, `{"a":null}`},
+		{`{"a":-`, `{}`},
+		{`{"a":1.5e-`, `{"a":1.5}`},
+		{`{"a":"x\`, `{"a":"x"}`},
+		{`{"a":"\u00`, `{"a":""}`},
+		{`{"a":"q\"uo`, `{"a":"q\"uo"}`},
+		{`{"a":{"b":"}"`, `{"a":{"b":"}"}}`},
+		{`[1,[2`, `[1,[2]]`},
+		{`{"a":1} trailing`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if got := provider.CompleteJSON(tt.input); got != tt.want {
+			t.Errorf("CompleteJSON(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPartialJSONEveryPrefixDecodes(t *testing.T) {
+	doc := `{"query": "café \"bar\"", "limit": -12.5e+2, "tags": ["a", "b"], "exact": false, "opts": {"deep": [null, true, {}]}}`
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	var p provider.PartialJSON
+	for i := 0; i < len(doc); i++ {
+		p.Append(doc[i : i+1])
+		if i > 0 && !json.Valid([]byte(p.Complete())) {
+			t.Fatalf("after %q: invalid completion %s", doc[:i+1], p.Complete())
+		}
+	}
+	if got := p.Object(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if p.String() != doc {
+		t.Errorf("expected the raw input kept, got %q", p.String())
+	}
+}
+
+func TestPartialJSONKeepsLastObject(t *testing.T) {
+	var p provider.PartialJSON
+	p.Append(`{"a": 1, `)
+	if got := p.Object(); got["a"] != float64(1) {
+		t.Fatalf("unexpected object %v", got)
+	}
+	p.Append(`oops`)
+	if got := p.Object(); len(got) != 1 || got["a"] != float64(1) {
+		t.Errorf("expected the last object after invalid input, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ModelPrice is a model's price in USD per million tokens.
+type ModelPrice struct {
+	Input      float64 // uncached input tokens
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// Pricer looks up the price of a model. Implement it to plug in your own prices (e.g. negotiated rates
+// or a catalog fetched at startup).
+type Pricer interface {
+	Price(model string) (ModelPrice, bool)
+}
+
+// PriceTable is a Pricer backed by a map from model ID to price.
+type PriceTable map[string]ModelPrice
+
+// Price implements Pricer. Model IDs are matched like Catalog.Lookup: exactly, then without a vendor
+// prefix ("openai/gpt-4o" falls back to "gpt-4o"), then a bare ID against vendor-prefixed entries
+// ("gpt-4o" finds "openai/gpt-4o"). Dated snapshots ("gpt-4o-2024-08-06", "claude-3-5-haiku-20241022")
+// fall back to their undated model and its aliases ("claude-3-5-haiku-latest", "claude-sonnet-4-0").
+func (t PriceTable) Price(model string) (ModelPrice, bool) {
+	return lookupModel(t, model)
+}
+
+// snapshotSuffix matches a trailing snapshot date: -2024-08-06 or -20240307
+var snapshotSuffix = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8})$`)
+
+// snapshotAliases are the suffixes vendors give the alias of an undated model
+var snapshotAliases = []string{"", "-latest", "-0"}
+
+// lookupModel finds model in m, falling back from a dated snapshot to its undated model or aliases
+func lookupModel[V any](m map[string]V, model string) (V, bool) {
+	if v, ok := matchModel(m, model); ok {
+		return v, true
+	}
+	if base := snapshotSuffix.ReplaceAllString(model, ""); base != model {
+		for _, alias := range snapshotAliases {
+			if v, ok := matchModel(m, base+alias); ok {
+				return v, true
+			}
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// matchModel matches model exactly, then without a vendor prefix, then a bare ID against vendor-prefixed keys
+func matchModel[V any](m map[string]V, model string) (V, bool) {
+	if v, ok := m[model]; ok {
+		return v, true
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		v, ok := m[model[i+1:]]
+		return v, ok
+	}
+	// a bare ID may match several vendors; pick the first key in sorted order so lookups are stable
+	match := ""
+	for key := range m {
+		if strings.HasSuffix(key, "/"+model) && (match == "" || key < match) {
+			match = key
+		}
+	}
+	if match == "" {
+		var zero V
+		return zero, false
+	}
+	return m[match], true
+}
+
+// DefaultPrices returns list prices for common models at the time of writing. Prices change;
+// pass your own Pricer for billing.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"openai/gpt-4o":               {Input: 2.50, Output: 10.00, CacheRead: 1.25},
+		"openai/gpt-4o-mini":          {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+		"anthropic/claude-3.5-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+		"anthropic/claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30},
+		"claude-3-5-sonnet-latest":    {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+		"claude-3-5-haiku-latest":     {Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00},
+		"claude-3-7-sonnet-latest":    {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+		"claude-sonnet-4-0":           {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+		"claude-opus-4-0":             {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
+		"google/gemini-2.0-flash-exp": {},
+	}
+}
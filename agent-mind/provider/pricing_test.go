@@ -0,0 +1,53 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+func TestPriceTableLookup(t *testing.T) {
+	table := provider.PriceTable{
+		"gpt-4o":             {Input: 2.5, Output: 10},
+		"anthropic/claude-x": {Input: 3, Output: 15},
+	}
+	if p, ok := table.Price("openai/gpt-4o"); !ok || p.Input != 2.5 {
+		t.Errorf("expected vendor-prefixed lookup to fall back to bare ID, got %+v, %v", p, ok)
+	}
+	if p, ok := table.Price("anthropic/claude-x"); !ok || p.Output != 15 {
+		t.Errorf("expected exact match, got %+v, %v", p, ok)
+	}
+	if p, ok := table.Price("claude-x"); !ok || p.Output != 15 {
+		t.Errorf("expected bare ID to match a vendor-prefixed entry, got %+v, %v", p, ok)
+	}
+	if _, ok := table.Price("x"); ok {
+		t.Error("a suffix match must cover the whole ID after the vendor prefix")
+	}
+}
+
+func TestPriceTableSnapshots(t *testing.T) {
+	table := provider.DefaultPrices()
+	tests := []struct {
+		model string
+		input float64
+	}{
+		{"gpt-4o", 2.50},
+		{"gpt-4o-2024-08-06", 2.50},
+		{"openai/gpt-4o-2024-08-06", 2.50},
+		{"gpt-4o-mini-2024-07-18", 0.15},
+		{"claude-3-haiku-20240307", 0.25},
+		{"claude-3-5-haiku-20241022", 0.80},
+		{"claude-3-5-sonnet-20241022", 3.00},
+		{"claude-3-7-sonnet-20250219", 3.00},
+		{"claude-sonnet-4-20250514", 3.00},
+		{"claude-opus-4-20250514", 15.00},
+	}
+	for _, tt := range tests {
+		if p, ok := table.Price(tt.model); !ok || p.Input != tt.input {
+			t.Errorf("Price(%q) = %+v, %v; want input %v", tt.model, p, ok, tt.input)
+		}
+	}
+	if _, ok := table.Price("gpt-4o-2024"); ok {
+		t.Error("a partial date is not a snapshot suffix")
+	}
+}
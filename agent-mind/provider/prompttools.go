@@ -0,0 +1,406 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/biome/agent-core/packages/agent/types"
+)
+
+// Tags delimiting a tool call in prompt-based tool calling. The payload is
+// {"name": "...", "arguments": {...}}.
+const (
+	ToolCallOpenTag  = "<tool_call>"
+	ToolCallCloseTag = "</tool_call>"
+)
+
+// NewToolCallID returns a fresh tool call ID, for backends and adapters whose calls have none.
+func NewToolCallID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// WithPromptTools wraps p for models without native function calling. Tools are described in the system
+// prompt with a strict <tool_call> syntax instead of being sent as tools, earlier calls and results in the
+// conversation are rendered as text, and calls are parsed out of the reply (or stream) and returned as
+// ToolCallResponses, so callers see the same shape as from a native tool-calling provider. A call cut off
+// by the end of the reply (e.g. at max tokens) is never run: its progress is streamed as EventToolDelta
+// and the unfinished block is given back as text. The result is a Cataloger only when p is one.
+func WithPromptTools(p Provider) Provider {
+	t := &promptToolsProvider{Provider: p}
+	if c, ok := p.(Cataloger); ok {
+		return &promptToolsCatalogProvider{promptToolsProvider: t, catalog: c}
+	}
+	return t
+}
+
+type promptToolsProvider struct {
+	Provider
+}
+
+// promptToolsCatalogProvider is a promptToolsProvider whose wrapped provider is a Cataloger
+type promptToolsCatalogProvider struct {
+	*promptToolsProvider
+	catalog Cataloger
+}
+
+func (t *promptToolsProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if !usesTools(req) {
+		return t.Provider.Complete(ctx, req)
+	}
+	resp, err := t.Provider.Complete(ctx, promptToolsRequest(req))
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	var parser toolCallParser
+	var text strings.Builder
+	for _, ev := range append(parser.feed(resp.Text), parser.finish()...) {
+		switch ev.Type {
+		case EventTextDelta:
+			text.WriteString(ev.Delta)
+		case EventToolCall:
+			resp.ToolCalls = append(resp.ToolCalls, *ev.Content.(*ToolCallResponse))
+		}
+	}
+	resp.Text = strings.TrimSpace(text.String())
+	return resp, nil
+}
+
+func (t *promptToolsProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamEvent, error) {
+	if !usesTools(req) {
+		return t.Provider.Stream(ctx, req)
+	}
+	events, err := t.Provider.Stream(ctx, promptToolsRequest(req))
+	if err != nil {
+		return events, err
+	}
+	out := make(chan StreamEvent, 10)
+	go func() {
+		defer close(out)
+		var parser toolCallParser
+		send := func(batch []StreamEvent) bool {
+			for _, e := range batch {
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					go func() {
+						for range events {
+						}
+					}()
+					return false
+				}
+			}
+			return true
+		}
+		done := false
+		for ev := range events {
+			var batch []StreamEvent
+			switch ev.Type {
+			case EventTextDelta:
+				batch = parser.feed(ev.Delta)
+			case EventDone:
+				done = true
+				batch = append(parser.finish(), ev)
+			default:
+				batch = []StreamEvent{ev}
+			}
+			if !send(batch) {
+				return
+			}
+		}
+		if !done {
+			send(parser.finish()) // a stream that ended without EventDone still gets its held-back text
+		}
+	}()
+	return out, nil
+}
+
+// ListModels implements Cataloger, reporting every model as tool-capable.
+func (t *promptToolsCatalogProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	models, err := t.catalog.ListModels(ctx)
+	for i := range models {
+		models[i].Tools = true
+	}
+	return models, err
+}
+
+// Capabilities implements Cataloger, reporting the model as tool-capable.
+func (t *promptToolsCatalogProvider) Capabilities(ctx context.Context) (ModelInfo, error) {
+	m, err := t.catalog.Capabilities(ctx)
+	m.Tools = true
+	return m, err
+}
+
+// usesTools reports whether a request needs rewriting: it offers tools or replays earlier calls
+func usesTools(req CompletionRequest) bool {
+	if len(req.Tools) > 0 {
+		return true
+	}
+	for _, msg := range req.Messages {
+		if _, ok := msg.(types.ToolResultMessage); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// promptToolsRequest moves the tools into the system prompt and renders tool calls and results in the
+// conversation as text, so the wrapped provider sends no native tool fields.
+func promptToolsRequest(req CompletionRequest) CompletionRequest {
+	out := req
+	out.Tools = nil
+	out.ToolChoice = nil
+	if len(req.Tools) > 0 && req.ToolChoice.AllowsTools() {
+		out.SystemPrompt = strings.TrimSpace(req.SystemPrompt + "\n\n" + toolsPrompt(req.Tools, req.ToolChoice))
+	}
+	out.Messages = make([]types.Message, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		switch m := msg.(type) {
+		case types.AssistantMessage:
+			content := make([]types.ContentBlock, 0, len(m.Content))
+			for _, block := range m.Content {
+				if call, ok := block.(types.ToolCallContent); ok {
+					content = append(content, types.TextContent{Text: renderToolCall(call.Name, call.Arguments)})
+					continue
+				}
+				content = append(content, block)
+			}
+			m.Content = content
+			out.Messages = append(out.Messages, m)
+		case types.ToolResultMessage:
+			out.Messages = append(out.Messages, renderToolResult(m))
+		default:
+			out.Messages = append(out.Messages, msg)
+		}
+	}
+	return out
+}
+
+// toolsPrompt describes the tools and the call syntax
+func toolsPrompt(tools []Tool, choice *ToolChoice) string {
+	var b strings.Builder
+	b.WriteString("# Tools\n\n")
+	b.WriteString("You can call the tools listed below. To call one, write a block in exactly this form, ")
+	b.WriteString("with the arguments as a JSON object matching the tool's parameters:\n")
+	b.WriteString(ToolCallOpenTag + "\n{\"name\": \"tool_name\", \"arguments\": {\"arg\": \"value\"}}\n" + ToolCallCloseTag + "\n")
+	b.WriteString("Write one block per call. After your calls, stop: the results arrive in the next message as ")
+	b.WriteString("<tool_result> blocks. Never write <tool_result> blocks yourself. ")
+	b.WriteString("When no tool is needed, answer normally without any tags.\n")
+	if choice != nil {
+		switch choice.Mode {
+		case ToolChoiceRequired:
+			b.WriteString("You must call at least one tool in this reply.\n")
+		case ToolChoiceTool:
+			fmt.Fprintf(&b, "You must call the %s tool in this reply.\n", choice.Name)
+		}
+	}
+	b.WriteString("\nAvailable tools:\n")
+	for _, t := range tools {
+		if choice != nil && choice.Mode == ToolChoiceTool && t.Name != choice.Name {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", t.Name, t.Description)
+		if t.Parameters != nil {
+			if params, err := json.Marshal(t.Parameters); err == nil {
+				fmt.Fprintf(&b, "  Parameters: %s\n", params)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// renderToolCall writes a call in the syntax the model is asked to use
+func renderToolCall(name string, args interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	payload, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	return ToolCallOpenTag + "\n" + string(payload) + "\n" + ToolCallCloseTag
+}
+
+// renderToolResult turns a tool result into a user message holding a <tool_result> block (images follow it)
+func renderToolResult(m types.ToolResultMessage) types.UserMessage {
+	text := ""
+	var images []types.ContentBlock
+	for _, block := range m.Content {
+		switch b := block.(type) {
+		case types.TextContent:
+			text += b.Text
+		case types.ImageContent:
+			images = append(images, b)
+		}
+	}
+	if text == "" && m.Details != nil {
+		if j, err := json.Marshal(m.Details); err == nil {
+			text = string(j)
+		}
+	}
+	attrs := fmt.Sprintf(" name=%q", m.ToolName)
+	if m.IsError {
+		attrs += ` error="true"`
+	}
+	content := []types.ContentBlock{types.TextContent{Text: "<tool_result" + attrs + ">\n" + text + "\n</tool_result>"}}
+	return types.UserMessage{Content: append(content, images...)}
+}
+
+var toolNameRE = regexp.MustCompile(`"name"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// toolCallParser splits streamed text into text deltas and tool calls. Text that may be the start of
+// a tag is held back until the next delta decides it.
+type toolCallParser struct {
+	pending string // text not yet emitted or assigned to a call
+	inCall  bool
+	body    string // payload of the open call so far
+	id      string
+	index   int
+	partial map[string]interface{} // arguments last reported for the open call
+}
+
+// feed consumes a text delta and returns the events it completes
+func (p *toolCallParser) feed(delta string) []StreamEvent {
+	p.pending += delta
+	var out []StreamEvent
+	for {
+		if !p.inCall {
+			if i := strings.Index(p.pending, ToolCallOpenTag); i >= 0 {
+				out = appendText(out, p.pending[:i])
+				p.pending = p.pending[i+len(ToolCallOpenTag):]
+				p.inCall, p.body, p.id, p.partial = true, "", NewToolCallID(), nil
+				continue
+			}
+			keep := tagPrefixLen(p.pending, ToolCallOpenTag)
+			out = appendText(out, p.pending[:len(p.pending)-keep])
+			p.pending = p.pending[len(p.pending)-keep:]
+			return out
+		}
+		if i := strings.Index(p.pending, ToolCallCloseTag); i >= 0 {
+			p.body += p.pending[:i]
+			p.pending = p.pending[i+len(ToolCallCloseTag):]
+			out = append(out, p.closeCall(false)...)
+			continue
+		}
+		keep := tagPrefixLen(p.pending, ToolCallCloseTag)
+		p.body += p.pending[:len(p.pending)-keep]
+		p.pending = p.pending[len(p.pending)-keep:]
+		if ev, ok := p.delta(); ok {
+			out = append(out, ev)
+		}
+		return out
+	}
+}
+
+// finish flushes held-back text and a call left open by the end of the reply
+func (p *toolCallParser) finish() []StreamEvent {
+	if p.inCall {
+		p.body += p.pending
+		p.pending = ""
+		return p.closeCall(true)
+	}
+	out := appendText(nil, p.pending)
+	p.pending = ""
+	return out
+}
+
+// delta reports the open call's progress when its name or arguments changed
+func (p *toolCallParser) delta() (StreamEvent, bool) {
+	name := ""
+	if m := toolNameRE.FindStringSubmatch(p.body); m != nil {
+		_ = json.Unmarshal([]byte(`"`+m[1]+`"`), &name)
+	}
+	if name == "" {
+		return StreamEvent{}, false
+	}
+	args := p.partial
+	if call, ok := parseToolCallPayload(p.body, true); ok {
+		args = call.Arguments
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	p.partial = args
+	return StreamEvent{
+		Type:    EventToolDelta,
+		Content: &ToolCallStreamPayload{Index: p.index, ID: p.id, Name: name, Arguments: args},
+	}, true
+}
+
+// closeCall ends the open call. A payload that is not a valid call is given back as text, tags included,
+// and so is a truncated one (no close tag) unless it is complete as is: a repaired payload is never run.
+func (p *toolCallParser) closeCall(truncated bool) []StreamEvent {
+	p.inCall = false
+	call, ok := parseToolCallPayload(p.body, false)
+	if !ok {
+		raw := ToolCallOpenTag + p.body
+		if !truncated {
+			raw += ToolCallCloseTag
+		}
+		return appendText(nil, raw)
+	}
+	call.ID = p.id
+	p.index++
+	return []StreamEvent{{Type: EventToolCall, Content: &call}}
+}
+
+// parseToolCallPayload decodes {"name": ..., "arguments": ...}. Models sometimes wrap it in a code fence,
+// name the arguments "parameters" or send them as a JSON string. With repair, an unterminated payload is
+// completed before decoding.
+func parseToolCallPayload(body string, repair bool) (ToolCallResponse, bool) {
+	body = strings.TrimSpace(body)
+	if m := jsonFenceRE.FindStringSubmatch(body); m != nil {
+		body = m[1]
+	} else {
+		body = strings.TrimPrefix(strings.TrimPrefix(body, "```json"), "```")
+	}
+	var payload struct {
+		Name       string          `json:"name"`
+		Arguments  json.RawMessage `json:"arguments"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		if !repair || json.Unmarshal([]byte(CompleteJSON(body)), &payload) != nil {
+			return ToolCallResponse{}, false
+		}
+	}
+	if payload.Name == "" {
+		return ToolCallResponse{}, false
+	}
+	raw := payload.Arguments
+	if len(raw) == 0 {
+		raw = payload.Parameters
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		raw = json.RawMessage(s)
+	}
+	args := map[string]interface{}{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &args)
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return ToolCallResponse{Name: payload.Name, Arguments: args}, true
+}
+
+// tagPrefixLen returns the length of the longest suffix of s that is a proper prefix of tag
+func tagPrefixLen(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+func appendText(events []StreamEvent, text string) []StreamEvent {
+	if text == "" {
+		return events
+	}
+	return append(events, StreamEvent{Type: EventTextDelta, Delta: text})
+}
@@ -0,0 +1,189 @@
+package provider_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-core/packages/agent/types"
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+var calculatorTool = provider.Tool{
+	Name:        "calculator",
+	Description: "Evaluates arithmetic",
+	Parameters: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"expression": map[string]interface{}{"type": "string"}},
+	},
+}
+
+func TestPromptToolsRewritesRequest(t *testing.T) {
+	inner := fake.New(fake.Text("done"))
+	p := provider.WithPromptTools(inner)
+
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{
+		SystemPrompt: "You are helpful.",
+		Tools:        []provider.Tool{calculatorTool},
+		ToolChoice:   provider.RequireTools(),
+		Messages: []types.Message{
+			types.UserMessage{Content: []types.ContentBlock{types.TextContent{Text: "2+2?"}}},
+			types.AssistantMessage{Content: []types.ContentBlock{
+				types.ToolCallContent{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"expression": "2+2"}},
+			}},
+			types.ToolResultMessage{ToolCallID: "call_1", ToolName: "calculator", Content: []types.ContentBlock{types.TextContent{Text: "4"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	req := inner.Requests()[0]
+	if len(req.Tools) != 0 || req.ToolChoice != nil {
+		t.Errorf("expected no native tools, got %+v %v", req.Tools, req.ToolChoice)
+	}
+	for _, want := range []string{"You are helpful.", provider.ToolCallOpenTag, "- calculator: Evaluates arithmetic", "must call at least one tool"} {
+		if !strings.Contains(req.SystemPrompt, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, req.SystemPrompt)
+		}
+	}
+	call, ok := req.Messages[1].(types.AssistantMessage).Content[0].(types.TextContent)
+	if !ok || !strings.Contains(call.Text, `{"arguments":{"expression":"2+2"},"name":"calculator"}`) {
+		t.Errorf("expected the earlier call rendered as text, got %+v", req.Messages[1])
+	}
+	result, ok := req.Messages[2].(types.UserMessage)
+	if !ok || !strings.Contains(result.Content[0].(types.TextContent).Text, "<tool_result name=\"calculator\">\n4\n</tool_result>") {
+		t.Errorf("expected the result as a user message, got %+v", req.Messages[2])
+	}
+}
+
+func TestPromptToolsComplete(t *testing.T) {
+	inner := fake.New(fake.Text("Let me check.\n<tool_call>\n{\"name\": \"calculator\", \"arguments\": {\"expression\": \"2+2\"}}\n</tool_call>\n" +
+		"<tool_call>```json\n{\"name\": \"clock\", \"parameters\": \"{}\"}\n```</tool_call>"))
+	p := provider.WithPromptTools(inner)
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{Tools: []provider.Tool{calculatorTool, {Name: "clock"}}})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "Let me check." {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Name != "calculator" || resp.ToolCalls[0].Arguments["expression"] != "2+2" ||
+		resp.ToolCalls[1].Name != "clock" || resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].ID == resp.ToolCalls[1].ID {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+}
+
+func TestPromptToolsInvalidCallStaysText(t *testing.T) {
+	text := "Use <tool_call>like this</tool_call> to call tools."
+	p := provider.WithPromptTools(fake.New(fake.Text(text)))
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{Tools: []provider.Tool{calculatorTool}})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != text || len(resp.ToolCalls) != 0 {
+		t.Errorf("expected the text unchanged, got %q %+v", resp.Text, resp.ToolCalls)
+	}
+}
+
+func TestPromptToolsStream(t *testing.T) {
+	deltas := []string{"Checking", " <tool_", "call>\n{\"name\": \"calc", "ulator\", \"arguments\": {\"expr", "ession\": \"2+2\"}}", "\n</tool_c", "all> ok"}
+	var events []provider.StreamEvent
+	for _, d := range deltas {
+		events = append(events, provider.StreamEvent{Type: provider.EventTextDelta, Delta: d})
+	}
+	events = append(events, provider.StreamEvent{Type: provider.EventDone, Content: &provider.StreamDonePayload{}})
+	p := provider.WithPromptTools(fake.New(fake.Events(events...)))
+
+	stream, err := p.Stream(context.Background(), provider.CompletionRequest{Tools: []provider.Tool{calculatorTool}})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var text []string
+	var partial []map[string]interface{}
+	resp, err := provider.Collect(stream, func(ev provider.StreamEvent) {
+		switch ev.Type {
+		case provider.EventTextDelta:
+			text = append(text, ev.Delta)
+		case provider.EventToolDelta:
+			partial = append(partial, ev.Content.(*provider.ToolCallStreamPayload).Arguments)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if strings.Join(text, "") != "Checking  ok" {
+		t.Errorf("expected tags kept out of the text, got %q", text)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "calculator" || resp.ToolCalls[0].Arguments["expression"] != "2+2" {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if len(partial) == 0 || partial[len(partial)-1]["expression"] != "2+2" {
+		t.Errorf("expected tool deltas with the arguments so far, got %v", partial)
+	}
+}
+
+func TestPromptToolsTruncatedCall(t *testing.T) {
+	reply := "<tool_call>\n{\"name\": \"calculator\", \"arguments\": {\"expression\": \"2+"
+	p := provider.WithPromptTools(fake.New(fake.Text(reply)))
+	stream, err := p.Stream(context.Background(), provider.CompletionRequest{Tools: []provider.Tool{calculatorTool}})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var partial *provider.ToolCallStreamPayload
+	resp, err := provider.Collect(stream, func(ev provider.StreamEvent) {
+		if ev.Type == provider.EventToolDelta {
+			partial = ev.Content.(*provider.ToolCallStreamPayload)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(resp.ToolCalls) != 0 || resp.Text != reply {
+		t.Errorf("expected the cut-off call given back as text, got %q %+v", resp.Text, resp.ToolCalls)
+	}
+	if partial == nil || partial.Arguments["expression"] != "2+" {
+		t.Errorf("expected the cut-off call's progress as a tool delta, got %+v", partial)
+	}
+}
+
+func TestPromptToolsStreamWithoutDone(t *testing.T) {
+	p := provider.WithPromptTools(fake.New(fake.Events(provider.StreamEvent{Type: provider.EventTextDelta, Delta: "Hello <tool"})))
+	stream, err := p.Stream(context.Background(), provider.CompletionRequest{Tools: []provider.Tool{calculatorTool}})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var text string
+	for ev := range stream {
+		text += ev.Delta
+	}
+	if text != "Hello <tool" {
+		t.Errorf("expected the held-back text flushed when the stream ends, got %q", text)
+	}
+}
+
+func TestPromptToolsPassThrough(t *testing.T) {
+	inner := fake.New(fake.Text("a <tool_call> mention"))
+	p := provider.WithPromptTools(inner)
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{SystemPrompt: "plain"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "a <tool_call> mention" || inner.Requests()[0].SystemPrompt != "plain" {
+		t.Errorf("expected requests without tools to pass through, got %q", resp.Text)
+	}
+}
+
+func TestPromptToolsReportsToolSupport(t *testing.T) {
+	inner := fake.New().WithModel("tiny").WithCatalog(provider.ModelInfo{ID: "tiny"})
+	m, err := provider.WithPromptTools(inner).(provider.Cataloger).Capabilities(context.Background())
+	if err != nil || !m.Tools {
+		t.Errorf("expected the model to be reported as tool-capable, got %+v %v", m, err)
+	}
+	if _, ok := provider.WithPromptTools(struct{ provider.Provider }{inner}).(provider.Cataloger); ok {
+		t.Error("expected no Cataloger when the wrapped provider has no catalog")
+	}
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// RetryPolicy configures retries with exponential backoff and jitter.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first (<= 1 disables retries).
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; each later wait is multiplied by Multiplier.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each wait by up to ±Jitter of its value (0..1).
+	Jitter float64
+	// RetryableStatusCodes lists HTTP statuses that are retried (see HTTPError).
+	RetryableStatusCodes []int
+	// RetryNetworkErrors retries transport failures (connection refused/reset, timeouts).
+	RetryNetworkErrors bool
+	// OnRetry, when set, is called before each retry wait.
+	OnRetry func(RetryInfo)
+}
+
+// RetryInfo describes a retry about to happen.
+type RetryInfo struct {
+	Attempt int           // attempt that failed (1-based)
+	Delay   time.Duration // wait before the next attempt
+	Err     error         // error from the failed attempt
+}
+
+// DefaultRetryPolicy retries rate limits and transient server errors up to 3 attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           30 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{408, 409, 429, 500, 502, 503, 504},
+		RetryNetworkErrors:   true,
+	}
+}
+
+// NoRetry is a policy that makes a single attempt.
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// Retryable reports whether err should be retried under this policy. Context cancellation never is.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		for _, code := range p.RetryableStatusCodes {
+			if httpErr.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	if p.RetryNetworkErrors && errors.As(err, &netErr) {
+		return true
+	}
+	return false
+}
+
+// Backoff returns the wait after the given failed attempt (1-based). A server-provided Retry-After
+// on err takes precedence over the computed backoff; both are capped at MaxBackoff.
+func (p RetryPolicy) Backoff(attempt int, err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		if p.MaxBackoff > 0 && httpErr.RetryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return httpErr.RetryAfter
+	}
+	d := float64(p.InitialBackoff)
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	for i := 1; i < attempt; i++ {
+		d *= mult
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, attempts run out, or ctx ends.
+// When ctx ends during a wait, the returned error wraps both ctx.Err() and the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.Retryable(err) {
+			return err
+		}
+		delay := p.Backoff(attempt, err)
+		if p.OnRetry != nil {
+			p.OnRetry(RetryInfo{Attempt: attempt, Delay: delay, Err: err})
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+		}
+	}
+}
+
+// WithRetry wraps any provider so Complete and Stream are retried under policy. A stream is only
+// retried when it fails before producing anything: either Stream returns an error or its first
+// event is EventError. Once any event has been forwarded, errors pass through unchanged. The result
+// is a Cataloger only when p is one.
+func WithRetry(p Provider, policy RetryPolicy) Provider {
+	r := &retryProvider{Provider: p, policy: policy}
+	if c, ok := p.(Cataloger); ok {
+		return &retryCatalogProvider{retryProvider: r, Cataloger: c}
+	}
+	return r
+}
+
+type retryProvider struct {
+	Provider
+	policy RetryPolicy
+}
+
+// retryCatalogProvider is a retryProvider whose wrapped provider is a Cataloger
+type retryCatalogProvider struct {
+	*retryProvider
+	Cataloger
+}
+
+func (r *retryProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var resp *CompletionResponse
+	err := r.policy.Do(ctx, func() error {
+		var err error
+		resp, err = r.Provider.Complete(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamEvent, error) {
+	var events <-chan StreamEvent
+	var first StreamEvent
+	var hasFirst bool
+	err := r.policy.Do(ctx, func() error {
+		var err error
+		events, err = r.Provider.Stream(ctx, req)
+		if err != nil {
+			return err
+		}
+		first, hasFirst = <-events
+		if hasFirst && first.Type == EventError && first.Error != nil && r.policy.Retryable(first.Error) {
+			go func(ch <-chan StreamEvent) {
+				for range ch {
+				}
+			}(events)
+			return first.Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasFirst {
+		return events, nil
+	}
+	out := make(chan StreamEvent, 10)
+	go func() {
+		defer close(out)
+		out <- first
+		for ev := range events {
+			out <- ev
+		}
+	}()
+	return out, nil
+}
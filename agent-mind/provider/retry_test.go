@@ -0,0 +1,135 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+func fastPolicy() provider.RetryPolicy {
+	p := provider.DefaultRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	return p
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"2", 2 * time.Second},
+		{"-1", 0},
+		{"Wed, 01 Jan 2025 00:00:05 GMT", 5 * time.Second},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := provider.ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	p := provider.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	got := []time.Duration{p.Backoff(1, nil), p.Backoff(2, nil), p.Backoff(3, nil)}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got[i], want[i])
+		}
+	}
+	if d := p.Backoff(1, &provider.HTTPError{StatusCode: 429, RetryAfter: 250 * time.Millisecond}); d != 250*time.Millisecond {
+		t.Errorf("Retry-After should take precedence, got %v", d)
+	}
+	if d := p.Backoff(1, &provider.HTTPError{StatusCode: 429, RetryAfter: time.Hour}); d != 300*time.Millisecond {
+		t.Errorf("Retry-After should be capped at MaxBackoff, got %v", d)
+	}
+}
+
+func TestDoReturnsContextErrorDuringWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := provider.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, RetryableStatusCodes: []int{503}}
+	p.OnRetry = func(provider.RetryInfo) { cancel() }
+	last := &provider.HTTPError{StatusCode: 503}
+	err := p.Do(ctx, func() error { return last })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	var httpErr *provider.HTTPError
+	if !errors.As(err, &httpErr) || httpErr != last {
+		t.Errorf("expected the last attempt's error to be wrapped, got %v", err)
+	}
+}
+
+func TestWithRetryComplete(t *testing.T) {
+	inner := fake.New(
+		fake.Error(&provider.HTTPError{StatusCode: 502}),
+		fake.Text("ok"),
+	)
+	var retries int
+	policy := fastPolicy()
+	policy.OnRetry = func(provider.RetryInfo) { retries++ }
+
+	resp, err := provider.WithRetry(inner, policy).Complete(context.Background(), provider.CompletionRequest{})
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("expected success after retry, got %+v, %v", resp, err)
+	}
+	if retries != 1 || inner.Calls() != 2 {
+		t.Errorf("expected 1 retry and 2 calls, got %d and %d", retries, inner.Calls())
+	}
+}
+
+func TestWithRetryStreamFirstEventError(t *testing.T) {
+	inner := fake.New(
+		fake.Events(provider.StreamEvent{Type: provider.EventError, Error: &provider.HTTPError{StatusCode: 503}}),
+		fake.Text("streamed"),
+	)
+	events, err := provider.WithRetry(inner, fastPolicy()).Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	resp, err := provider.Collect(events, nil)
+	if err != nil || resp.Text != "streamed" {
+		t.Fatalf("expected retried stream, got %+v, %v", resp, err)
+	}
+}
+
+func TestWithRetryDoesNotRetryMidStream(t *testing.T) {
+	inner := fake.New(
+		fake.Events(
+			provider.StreamEvent{Type: provider.EventTextDelta, Delta: "partial"},
+			provider.StreamEvent{Type: provider.EventError, Error: &provider.HTTPError{StatusCode: 503}},
+		),
+		fake.Text("should not be used"),
+	)
+	events, err := provider.WithRetry(inner, fastPolicy()).Stream(context.Background(), provider.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if _, err := provider.Collect(events, nil); err == nil {
+		t.Error("expected mid-stream error to pass through")
+	}
+	if inner.Calls() != 1 {
+		t.Errorf("expected no retry after output started, got %d calls", inner.Calls())
+	}
+}
+
+func TestWithRetryKeepsCatalog(t *testing.T) {
+	inner := fake.New().WithModel("m").WithCatalog(provider.ModelInfo{ID: "m", Vision: true})
+	c, ok := provider.Chain(inner, provider.Retry(fastPolicy())).(provider.Cataloger)
+	if !ok {
+		t.Fatal("expected the retried provider to forward Cataloger")
+	}
+	if m, err := c.Capabilities(context.Background()); err != nil || !m.Vision {
+		t.Errorf("expected capabilities from the wrapped provider, got %+v %v", m, err)
+	}
+	if _, ok := provider.WithRetry(struct{ provider.Provider }{inner}, fastPolicy()).(provider.Cataloger); ok {
+		t.Error("expected no Cataloger when the wrapped provider has no catalog")
+	}
+}
@@ -0,0 +1,109 @@
+// Package sse reads Server-Sent Events streams (text/event-stream) for the streaming providers.
+//
+// It follows the WHATWG event stream format: lines end with LF, CRLF or CR; lines starting with ":"
+// are comments (e.g. OpenRouter's ": OPENROUTER PROCESSING" keep-alives); consecutive data fields are
+// joined with newlines; a blank line dispatches the event. Lines have no size limit.
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Event is one dispatched event.
+type Event struct {
+	// Type is the event field; empty means the default type ("message").
+	Type string
+	// Data is the event's data fields joined with newlines.
+	Data string
+	// ID is the last event ID the stream set (IDs carry over to later events).
+	ID string
+}
+
+// Reader reads events from a stream. It is not safe for concurrent use.
+type Reader struct {
+	r       *bufio.Reader
+	lines   []string // complete lines read ahead (a chunk can hold several CR-separated lines)
+	lastID  string
+	started bool
+	eof     bool
+}
+
+// NewReader returns a Reader for the stream r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event, skipping comments and events without data. At the end of the stream it
+// returns io.EOF; a trailing event that was not terminated by a blank line is discarded, as the format
+// requires (the stream was cut off).
+func (r *Reader) Next() (Event, error) {
+	var data strings.Builder
+	hasData := false
+	eventType := ""
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+		if line == "" {
+			if hasData {
+				return Event{Type: eventType, Data: data.String(), ID: r.lastID}, nil
+			}
+			eventType = ""
+			continue
+		}
+
+		field, value, found := strings.Cut(line, ":")
+		if field == "" && found {
+			continue // comment
+		}
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				r.lastID = value
+			}
+		}
+		// "retry" and unknown fields are ignored
+	}
+}
+
+// readLine returns the next complete line without its terminator
+func (r *Reader) readLine() (string, error) {
+	for len(r.lines) == 0 {
+		if r.eof {
+			return "", io.EOF
+		}
+		chunk, err := r.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if !r.started {
+			r.started = true
+			chunk = strings.TrimPrefix(chunk, "\uFEFF") // byte order mark
+		}
+		lines := strings.Split(strings.TrimSuffix(chunk, "\n"), "\r")
+		if err == io.EOF {
+			// The text after the last terminator is an incomplete line
+			r.eof = true
+			lines = lines[:len(lines)-1]
+		} else if n := len(lines); n > 1 && lines[n-1] == "" {
+			// CRLF: the CR belongs to the terminator
+			lines = lines[:n-1]
+		}
+		r.lines = lines
+	}
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	return line, nil
+}
@@ -0,0 +1,81 @@
+package sse_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biome/agent-mind/provider/sse"
+)
+
+func readAll(t *testing.T, stream string) []sse.Event {
+	t.Helper()
+	r := sse.NewReader(strings.NewReader(stream))
+	var events []sse.Event
+	for {
+		ev, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   []sse.Event
+	}{
+		{
+			name:   "data lines",
+			stream: "data: one\n\ndata:two\n\n",
+			want:   []sse.Event{{Data: "one"}, {Data: "two"}},
+		},
+		{
+			name:   "comments and event types",
+			stream: ": OPENROUTER PROCESSING\n\nevent: message_start\ndata: {}\nid: 7\n\n",
+			want:   []sse.Event{{Type: "message_start", Data: "{}", ID: "7"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: {\"a\":\ndata:  1}\n\n",
+			want:   []sse.Event{{Data: "{\"a\":\n 1}"}},
+		},
+		{
+			name:   "CRLF and CR line endings",
+			stream: "\uFEFFdata: one\r\n\r\ndata: two\r\rdata: three\r\n\n",
+			want:   []sse.Event{{Data: "one"}, {Data: "two"}, {Data: "three"}},
+		},
+		{
+			name:   "empty data still dispatches",
+			stream: "data\n\nevent: ping\n\n",
+			want:   []sse.Event{{Data: ""}},
+		},
+		{
+			name:   "unterminated event is discarded",
+			stream: "data: one\n\ndata: cut off",
+			want:   []sse.Event{{Data: "one"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readAll(t, tt.stream); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	events := readAll(t, "data: "+long+"\n\n")
+	if len(events) != 1 || events[0].Data != long {
+		t.Errorf("expected one 1MB event, got %d event(s)", len(events))
+	}
+}
@@ -0,0 +1,316 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ResponseFormatType selects the shape of the model's text output.
+type ResponseFormatType string
+
+const (
+	// ResponseFormatText is free-form text (the default).
+	ResponseFormatText ResponseFormatType = "text"
+	// ResponseFormatJSONObject asks for any valid JSON object.
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	// ResponseFormatJSONSchema asks for JSON matching Schema.
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat constrains the text of a completion. Providers with native support (OpenAI-compatible
+// response_format) pass it through; others fall back to instructions in the system prompt.
+type ResponseFormat struct {
+	Type ResponseFormatType
+	// Name identifies the schema (json_schema only; default "response").
+	Name string
+	// Schema is a JSON Schema object (json_schema only).
+	Schema map[string]interface{}
+	// Strict asks the provider to enforce the schema exactly where supported. Strict schemas must list
+	// every property as required and set additionalProperties to false.
+	Strict bool
+}
+
+// JSONObjectFormat returns a format requesting any JSON object.
+func JSONObjectFormat() *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// JSONSchemaFormat returns a format requesting JSON that matches schema.
+func JSONSchemaFormat(name string, schema map[string]interface{}) *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONSchema, Name: name, Schema: schema}
+}
+
+// IsJSON reports whether the format asks for JSON output.
+func (f *ResponseFormat) IsJSON() bool {
+	return f != nil && (f.Type == ResponseFormatJSONObject || f.Type == ResponseFormatJSONSchema)
+}
+
+// SchemaName returns Name, or "response" when unset.
+func (f *ResponseFormat) SchemaName() string {
+	if f == nil || f.Name == "" {
+		return "response"
+	}
+	return f.Name
+}
+
+// Instructions returns a system prompt addendum describing the format, for providers without native
+// support. Empty for text output.
+func (f *ResponseFormat) Instructions() string {
+	if !f.IsJSON() {
+		return ""
+	}
+	if f.Type == ResponseFormatJSONSchema && f.Schema != nil {
+		schema, _ := json.Marshal(f.Schema)
+		return "Respond with a single JSON value (no prose, no code fences) that matches this JSON Schema:\n" + string(schema)
+	}
+	return "Respond with a single JSON object (no prose, no code fences)."
+}
+
+// ValidationError reports a response that does not match the requested format.
+type ValidationError struct {
+	Path    string // JSON path of the offending value ("$" for the root)
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("response does not match format at %s: %s", e.Path, e.Message)
+}
+
+var jsonFenceRE = regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)\\s*```")
+
+// ExtractJSON returns the JSON payload of a response. Text that is not bare JSON is searched for a
+// markdown code fence, so prose the model added around the fenced JSON is dropped.
+func ExtractJSON(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return trimmed
+	}
+	if m := jsonFenceRE.FindStringSubmatch(trimmed); len(m) > 1 {
+		return strings.TrimSpace(m[1])
+	}
+	return trimmed
+}
+
+// ValidateResponse checks text against format: it must be JSON for json_object and json_schema, and match
+// the schema for json_schema. Text formats always pass. Returns a *ValidationError on mismatch.
+func ValidateResponse(format *ResponseFormat, text string) error {
+	if !format.IsJSON() {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(ExtractJSON(text)), &value); err != nil {
+		return &ValidationError{Path: "$", Message: "invalid JSON: " + err.Error()}
+	}
+	if format.Type == ResponseFormatJSONObject {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return &ValidationError{Path: "$", Message: "expected a JSON object"}
+		}
+		return nil
+	}
+	return ValidateSchema(format.Schema, value)
+}
+
+// DecodeJSON validates text against format and unmarshals it into out.
+func DecodeJSON(text string, format *ResponseFormat, out interface{}) error {
+	if err := ValidateResponse(format, text); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(ExtractJSON(text)), out)
+}
+
+// ValidateSchema validates a decoded JSON value against a JSON Schema. It supports the subset used for
+// structured output: type (single or list), properties, required, additionalProperties (false or a
+// schema), items, enum, const, minItems and maxItems. Unknown keywords are ignored.
+func ValidateSchema(schema map[string]interface{}, value interface{}) error {
+	return validateSchema(schema, value, "$")
+}
+
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected type %v, got %s", t, jsonType(value))}
+	}
+	if enum, ok := toInterfaces(schema["enum"]); ok && !containsJSON(enum, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("value %v is not one of %v", value, enum)}
+	}
+	if c, ok := schema["const"]; ok && !containsJSON([]interface{}{c}, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("value %v is not %v", value, c)}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, r := range toStrings(schema["required"]) {
+			if _, ok := v[r]; !ok {
+				return &ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", r)}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "." + k
+			if ps, ok := props[k].(map[string]interface{}); ok {
+				if err := validateSchema(ps, v[k], childPath); err != nil {
+					return err
+				}
+				continue
+			}
+			switch ap := schema["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					return &ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", k)}
+				}
+			case map[string]interface{}:
+				if err := validateSchema(ap, v[k], childPath); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if min, ok := toInt(schema["minItems"]); ok && len(v) < min {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d items, got %d", min, len(v))}
+		}
+		if max, ok := toInt(schema["maxItems"]); ok && len(v) > max {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d items, got %d", max, len(v))}
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value matches a schema "type" (a string or list of strings).
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []interface{}:
+		for _, one := range tt {
+			if s, ok := one.(string); ok && matchesSingleType(s, value) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, one := range tt {
+			if matchesSingleType(one, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(t string, value interface{}) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == t
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// containsJSON compares values by their JSON encoding, so schemas built in Go (e.g. []string enums,
+// int consts) compare equal to decoded JSON values.
+func containsJSON(candidates []interface{}, value interface{}) bool {
+	want, _ := json.Marshal(value)
+	for _, c := range candidates {
+		if got, _ := json.Marshal(c); string(got) == string(want) {
+			return true
+		}
+	}
+	return false
+}
+
+// toInterfaces accepts a list as decoded from JSON ([]interface{}) or built in Go ([]string).
+func toInterfaces(v interface{}) ([]interface{}, bool) {
+	switch vv := v.(type) {
+	case []interface{}:
+		return vv, true
+	case []string:
+		out := make([]interface{}, len(vv))
+		for i, s := range vv {
+			out[i] = s
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func toStrings(v interface{}) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case []interface{}:
+		out := make([]string, 0, len(vv))
+		for _, s := range vv {
+			if str, ok := s.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// WithValidation wraps p so responses to requests with a JSON ResponseFormat are validated before they
+// are returned. Complete returns the response together with a *ValidationError on mismatch; Stream
+// replaces the final EventDone with an EventError carrying the *ValidationError.
+func WithValidation(p Provider) Provider {
+	return Intercept(Hooks{
+		After: func(_ context.Context, call *Call, resp *CompletionResponse, err error) (*CompletionResponse, error) {
+			if err != nil || resp == nil {
+				return resp, err
+			}
+			return resp, ValidateResponse(call.Request.ResponseFormat, resp.Text)
+		},
+	})(p)
+}
@@ -0,0 +1,89 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+	"github.com/biome/agent-mind/provider/fake"
+)
+
+var personSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"name": map[string]interface{}{"type": "string"},
+		"age":  map[string]interface{}{"type": "integer"},
+		"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "enum": []string{"a", "b"}}},
+	},
+	"required":             []string{"name"},
+	"additionalProperties": false,
+}
+
+func TestValidateResponse(t *testing.T) {
+	format := provider.JSONSchemaFormat("person", personSchema)
+	tests := []struct {
+		name     string
+		text     string
+		wantPath string // empty = valid
+	}{
+		{"valid", `{"name":"Ada","age":36,"tags":["a"]}`, ""},
+		{"fenced", "```json\n{\"name\":\"Ada\"}\n```", ""},
+		{"fenced with prose", "Here you go:\n```json\n{\"name\":\"Ada\"}\n```\nDone.", ""},
+		{"invalid JSON", `{"name":`, "$"},
+		{"missing required", `{"age":3}`, "$"},
+		{"wrong type", `{"name":"Ada","age":3.5}`, "$.age"},
+		{"enum", `{"name":"Ada","tags":["c"]}`, "$.tags[0]"},
+		{"additional property", `{"name":"Ada","extra":1}`, "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := provider.ValidateResponse(format, tt.text)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			var verr *provider.ValidationError
+			if !errors.As(err, &verr) || verr.Path != tt.wantPath {
+				t.Fatalf("expected ValidationError at %s, got %v", tt.wantPath, err)
+			}
+		})
+	}
+
+	if err := provider.ValidateResponse(provider.JSONObjectFormat(), `[1,2]`); err == nil {
+		t.Error("json_object must reject a non-object")
+	}
+	if err := provider.ValidateResponse(nil, "free text"); err != nil {
+		t.Errorf("nil format must accept anything, got %v", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := provider.DecodeJSON("```\n{\"name\":\"Ada\"}\n```", provider.JSONSchemaFormat("person", personSchema), &out); err != nil || out.Name != "Ada" {
+		t.Fatalf("DecodeJSON = %+v, %v", out, err)
+	}
+}
+
+func TestWithValidation(t *testing.T) {
+	req := provider.CompletionRequest{ResponseFormat: provider.JSONSchemaFormat("person", personSchema)}
+	p := provider.WithValidation(fake.New(fake.Text(`{"age":1}`), fake.Text(`{"name":"Ada"}`)))
+
+	events, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var verr *provider.ValidationError
+	if _, err := provider.Collect(events, nil); !errors.As(err, &verr) {
+		t.Errorf("expected stream to end with a ValidationError, got %v", err)
+	}
+
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil || resp.Text != `{"name":"Ada"}` {
+		t.Errorf("expected valid completion, got %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ToolChoiceMode controls whether and which tools the model may call.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide (the default when ToolChoice is nil).
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceNone forbids tool calls; the model answers with text.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceRequired makes the model call at least one tool.
+	ToolChoiceRequired ToolChoiceMode = "required"
+	// ToolChoiceTool makes the model call the tool named in ToolChoice.Name.
+	ToolChoiceTool ToolChoiceMode = "tool"
+)
+
+// ToolChoice constrains tool calling for a request. A nil *ToolChoice leaves the provider default (auto).
+type ToolChoice struct {
+	Mode ToolChoiceMode
+	// Name is the tool to call (ToolChoiceTool only).
+	Name string
+}
+
+// AutoTools returns a choice that lets the model decide whether to call tools.
+func AutoTools() *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceAuto}
+}
+
+// DisableTools returns a choice that forbids tool calls.
+func DisableTools() *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceNone}
+}
+
+// RequireTools returns a choice that makes the model call at least one tool.
+func RequireTools() *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceRequired}
+}
+
+// ForceTool returns a choice that makes the model call the named tool.
+func ForceTool(name string) *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceTool, Name: name}
+}
+
+// ErrInvalidToolChoice is returned (wrapped) when a ToolChoice cannot be satisfied by the request's tools.
+var ErrInvalidToolChoice = errors.New("invalid tool choice")
+
+// Validate checks that the choice can be honored with tools: required needs at least one tool, and a
+// specific tool must be among them. A nil choice is always valid.
+func (c *ToolChoice) Validate(tools []Tool) error {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case ToolChoiceAuto, ToolChoiceNone:
+		return nil
+	case ToolChoiceRequired:
+		if len(tools) == 0 {
+			return fmt.Errorf("%w: %s with no tools", ErrInvalidToolChoice, c.Mode)
+		}
+		return nil
+	case ToolChoiceTool:
+		for _, t := range tools {
+			if t.Name == c.Name {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: tool %q is not in the request", ErrInvalidToolChoice, c.Name)
+	}
+	return fmt.Errorf("%w: unknown mode %q", ErrInvalidToolChoice, c.Mode)
+}
+
+// AllowsTools reports whether the model may call tools under this choice.
+func (c *ToolChoice) AllowsTools() bool {
+	return c == nil || c.Mode != ToolChoiceNone
+}
+
+// String returns the mode, or "tool:<name>" for a specific tool.
+func (c *ToolChoice) String() string {
+	if c == nil {
+		return string(ToolChoiceAuto)
+	}
+	if c.Mode == ToolChoiceTool {
+		return "tool:" + c.Name
+	}
+	return string(c.Mode)
+}
@@ -0,0 +1,37 @@
+package provider_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/biome/agent-mind/provider"
+)
+
+func TestToolChoiceValidate(t *testing.T) {
+	tools := []provider.Tool{{Name: "search"}}
+	tests := []struct {
+		name    string
+		choice  *provider.ToolChoice
+		tools   []provider.Tool
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"none without tools", provider.DisableTools(), nil, false},
+		{"required", provider.RequireTools(), tools, false},
+		{"required without tools", provider.RequireTools(), nil, true},
+		{"known tool", provider.ForceTool("search"), tools, false},
+		{"unknown tool", provider.ForceTool("fetch"), tools, true},
+		{"unknown mode", &provider.ToolChoice{Mode: "sometimes"}, tools, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.choice.Validate(tt.tools)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, provider.ErrInvalidToolChoice) {
+				t.Errorf("expected ErrInvalidToolChoice, got %v", err)
+			}
+		})
+	}
+}
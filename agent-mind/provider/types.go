@@ -8,10 +8,38 @@ import (
 type StreamEvent struct {
 	Type    string
 	Delta   string
-	Content interface{} // For EventToolDelta: *ToolCallStreamPayload; for EventToolCall: *ToolCallResponse
+	Content interface{} // For EventToolDelta: *ToolCallStreamPayload; for EventToolCall: *ToolCallResponse; for EventDone: *StreamDonePayload (optional)
 	Error   error
 }
 
+// StreamDonePayload is optionally sent with EventDone to report stream-level metadata.
+type StreamDonePayload struct {
+	// Model is the model identifier that served the stream. Empty if the provider does not report it.
+	Model string
+	// Provider names the backend that served the stream when it differs from the wrapper's Name (e.g. failover).
+	Provider string
+	Usage    UsageInfo
+	// FinishReason says why generation stopped. Empty if the provider does not report it.
+	FinishReason FinishReason `json:",omitempty"`
+}
+
+// FinishReason is why the model stopped generating, normalized across providers.
+type FinishReason string
+
+const (
+	FinishStop          FinishReason = "stop"           // natural end of the answer or a stop sequence
+	FinishLength        FinishReason = "length"         // the MaxTokens limit was reached
+	FinishToolCalls     FinishReason = "tool_calls"     // the model stopped to call tools
+	FinishContentFilter FinishReason = "content_filter" // the output was cut off by moderation
+)
+
+// ReasoningStreamPayload is sent with EventReasoningDelta. Index identifies the reasoning block;
+// Signature is set (with an empty Delta) when the provider signs a completed block.
+type ReasoningStreamPayload struct {
+	Index     int
+	Signature string
+}
+
 // ToolCallStreamPayload is sent with EventToolDelta for incremental tool-call args (partial JSON)
 type ToolCallStreamPayload struct {
 	Index     int
@@ -25,6 +53,7 @@ const (
 	EventTextDelta = "text_delta"
 	EventToolCall = "tool_call"
 	EventToolDelta = "tool_delta"
+	EventReasoningDelta = "reasoning_delta" // Reasoning/thinking text, kept separate from answer text
 	EventDone = "done"
 	EventError = "error"
 )
@@ -36,6 +65,31 @@ type CompletionRequest struct {
 	Temperature  float64
 	MaxTokens    int
 	Tools        []Tool
+	// ResponseFormat constrains the output to JSON (optionally matching a schema). Nil = free-form text.
+	ResponseFormat *ResponseFormat `json:",omitempty"`
+	// ToolChoice controls whether and which tools the model may call. Nil = provider default (auto).
+	ToolChoice *ToolChoice `json:",omitempty"`
+}
+
+// HasImages reports whether any message in the request carries image content.
+func (r CompletionRequest) HasImages() bool {
+	for _, msg := range r.Messages {
+		var content []types.ContentBlock
+		switch m := msg.(type) {
+		case types.UserMessage:
+			content = m.Content
+		case types.ToolResultMessage:
+			content = m.Content
+		case types.AssistantMessage:
+			content = m.Content
+		}
+		for _, block := range content {
+			if _, ok := block.(types.ImageContent); ok {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // CompletionResponse represents a completed LLM response
@@ -45,6 +99,20 @@ type CompletionResponse struct {
 	Usage     UsageInfo
 	// Model is the model identifier used for this completion (e.g. anthropic/claude-3-haiku). Empty if the provider does not report it.
 	Model string
+	// Provider names the backend that served this completion when it differs from the provider's Name
+	// (set by composite providers such as failover). Empty means the provider itself.
+	Provider string
+	// Reasoning holds reasoning/thinking blocks the model produced before its answer, in order.
+	Reasoning []ReasoningBlock
+	// FinishReason says why generation stopped. Empty if the provider does not report it.
+	FinishReason FinishReason `json:",omitempty"`
+}
+
+// ReasoningBlock is one block of model reasoning. Signature is provider-issued (e.g. Anthropic thinking)
+// and must be sent back unchanged when the block is replayed in a later request.
+type ReasoningBlock struct {
+	Text      string
+	Signature string
 }
 
 // ToolCallResponse represents a tool call from the LLM
@@ -56,9 +124,11 @@ type ToolCallResponse struct {
 
 // UsageInfo tracks token usage
 type UsageInfo struct {
-	PromptTokens     int
+	PromptTokens     int // all input tokens, including cache reads and writes
 	CompletionTokens int
 	TotalTokens      int
+	CacheReadTokens  int // portion of PromptTokens served from the prompt cache
+	CacheWriteTokens int // portion of PromptTokens written to the prompt cache
 }
 
 // Tool definition for function calling